@@ -0,0 +1,649 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/google/go-github/github"
+	"github.com/kolide/kit/env"
+	"golang.org/x/oauth2"
+
+	"k8s.io/release/pkg/notes"
+	"k8s.io/release/pkg/sink"
+)
+
+type options struct {
+	githubToken string
+	githubOrg   string
+	githubRepo  string
+	branch      string
+	tag         string
+	workDir     string
+	formats     string
+	draftTarget string
+	policyPath  string
+	restart     bool
+	nomock      bool
+	auditLog    string
+	auditActor  string
+
+	// requiredAuthor and postProcess default to the values of release-notes
+	requiredAuthor string
+	postProcess    bool
+
+	// dedup, byKind, taxonomyPath, attribution and authorNames are applied
+	// like release-notes does
+	dedup        bool
+	byKind       bool
+	taxonomyPath string
+	attribution  string
+	authorNames  string
+
+	// taxonomy buckets the notes by kind if -by-kind or -taxonomy is set
+	taxonomy *notes.Taxonomy
+
+	// attributionPolicy credits the authors of the notes as set by
+	// -attribution
+	attributionPolicy *notes.AttributionPolicy
+}
+
+func (o *options) BindFlags() *flag.FlagSet {
+	flags := flag.NewFlagSet("cut-notes", flag.ExitOnError)
+
+	// githubToken contains a personal GitHub access token. This is used to
+	// scrape the commits of the release.
+	flags.StringVar(
+		&o.githubToken,
+		"github-token",
+		env.String("GITHUB_TOKEN", ""),
+		"A personal GitHub access token (required)",
+	)
+
+	// githubOrg contains the name of the github organization the release is
+	// tagged in.
+	flags.StringVar(
+		&o.githubOrg,
+		"github-org",
+		env.String("GITHUB_ORG", "kubernetes"),
+		"Name of the github organization the release is tagged in",
+	)
+
+	// githubRepo contains the name of the github repository the release is
+	// tagged in.
+	flags.StringVar(
+		&o.githubRepo,
+		"github-repo",
+		env.String("GITHUB_REPO", "kubernetes"),
+		"Name of the github repository the release is tagged in",
+	)
+
+	// branch is the branch the release is cut from.
+	flags.StringVar(
+		&o.branch,
+		"branch",
+		env.String("BRANCH", "master"),
+		"The branch the release is cut from",
+	)
+
+	// tag is the tag of the release the notes are cut for.
+	flags.StringVar(
+		&o.tag,
+		"tag",
+		env.String("TAG", ""),
+		"The tag of the release, e.g. v1.16.0 (required)",
+	)
+
+	// workDir keeps the outputs of the stages, so that an interrupted run can
+	// be resumed.
+	flags.StringVar(
+		&o.workDir,
+		"work-dir",
+		env.String("WORK_DIR", ""),
+		"The directory the outputs of the stages are kept in, so that an interrupted run resumes after the last completed stage. Defaults to cut-notes-<tag>",
+	)
+
+	// formats lists the formats the notes are rendered in.
+	flags.StringVar(
+		&o.formats,
+		"formats",
+		env.String("FORMATS", ""),
+		"Comma separated list of the formats to render, e.g. markdown,json. Defaults to all formats",
+	)
+
+	// draftTarget is where the draft PR of the markdown notes is opened.
+	flags.StringVar(
+		&o.draftTarget,
+		"draft-target",
+		env.String("DRAFT_TARGET", ""),
+		"Where to open the draft PR of the markdown notes, in the form org/repo@branch:path. Defaults to the release notes draft of the release in kubernetes/sig-release",
+	)
+
+	// requiredAuthor restricts the commits to those of a GitHub user, like
+	// release-notes does.
+	flags.StringVar(
+		&o.requiredAuthor,
+		"requiredAuthor",
+		env.String("REQUIRED_AUTHOR", "k8s-ci-robot"),
+		"Only commits from this GitHub user are considered. Set to empty string to include all users",
+	)
+
+	// postProcess cleans up the notes and links references, like
+	// release-notes does.
+	flags.BoolVar(
+		&o.postProcess,
+		"post-process",
+		env.Bool("POST_PROCESS", false),
+		"Strip HTML comments and smart quotes from the notes, and link bare commit SHAs and issue references in the markdown",
+	)
+
+	// dedup merges release notes which describe the same change.
+	flags.BoolVar(
+		&o.dedup,
+		"dedup",
+		env.Bool("DEDUP", false),
+		"Merge release notes with near-identical text or references to the same KEP or issue",
+	)
+
+	// byKind organizes the notes by kind like the upstream changelogs.
+	flags.BoolVar(
+		&o.byKind,
+		"by-kind",
+		env.Bool("BY_KIND", false),
+		"Bucket the notes into the changelog categories by their kind/ labels and text, instead of by SIG",
+	)
+
+	// taxonomyPath configures the categories of -by-kind.
+	flags.StringVar(
+		&o.taxonomyPath,
+		"taxonomy",
+		env.String("TAXONOMY", ""),
+		"The path of a JSON file defining the categories to bucket the notes into by kind, implies -by-kind",
+	)
+
+	// attribution controls how the authors of the notes are credited.
+	flags.StringVar(
+		&o.attribution,
+		"attribution",
+		env.String("ATTRIBUTION", string(notes.AttributeHandles)),
+		"How to credit the authors of the notes in all formats (options: handles, names, none). names uses the display names of -author-names",
+	)
+
+	// authorNames maps GitHub handles to display names.
+	flags.StringVar(
+		&o.authorNames,
+		"author-names",
+		env.String("AUTHOR_NAMES", ""),
+		"The path of a JSON object mapping GitHub handles to display names, used with -attribution names",
+	)
+
+	// policyPath is the path of the output policy applied to every format.
+	flags.StringVar(
+		&o.policyPath,
+		"output-policy",
+		env.String("OUTPUT_POLICY", ""),
+		"The path of a JSON file with the fields of the release notes every format includes or redacts, as for release-notes",
+	)
+
+	// restart discards the outputs of a previous run.
+	flags.BoolVar(
+		&o.restart,
+		"restart",
+		env.Bool("RESTART", false),
+		"Discard the outputs of a previous run and start from the first stage",
+	)
+
+	// nomock opens or updates the draft PR instead of only rendering the notes.
+	flags.BoolVar(
+		&o.nomock,
+		"nomock",
+		env.Bool("NOMOCK", false),
+		"Open or update the draft PR, instead of only rendering the notes",
+	)
+
+	// auditLog is the path of the log of the GitHub API calls of the run.
+	flags.StringVar(
+		&o.auditLog,
+		"audit-log",
+		env.String("AUDIT_LOG", ""),
+		"The path of a JSON lines file recording every GitHub API call of the run",
+	)
+
+	// auditActor is who the GitHub API calls are recorded as made by.
+	flags.StringVar(
+		&o.auditActor,
+		"audit-actor",
+		env.String("AUDIT_ACTOR", env.String("USER", "")),
+		"The actor recorded in the audit log. Defaults to $USER",
+	)
+
+	return flags
+}
+
+func (o *options) validate() error {
+	if o.githubToken == "" {
+		return errors.New("GitHub token must be set via -github-token or $GITHUB_TOKEN")
+	}
+	if o.tag == "" {
+		return errors.New("The tag must be set via -tag or $TAG")
+	}
+	if o.workDir == "" {
+		o.workDir = "cut-notes-" + o.tag
+	}
+	parts := strings.SplitN(strings.TrimPrefix(o.tag, "v"), ".", 3)
+	if len(parts) < 3 {
+		return fmt.Errorf("invalid release tag %s", o.tag)
+	}
+	if o.draftTarget == "" {
+		minor := parts[0] + "." + parts[1]
+		o.draftTarget = fmt.Sprintf("kubernetes/sig-release@release-notes-%s:releases/release-%s/release-notes-draft.md", o.tag, minor)
+	}
+
+	o.attributionPolicy = &notes.AttributionPolicy{Attribution: notes.Attribution(o.attribution)}
+	if o.attribution == "" {
+		o.attributionPolicy.Attribution = notes.AttributeHandles
+	}
+	if err := o.attributionPolicy.Validate(); err != nil {
+		return err
+	}
+	if o.attributionPolicy.Attribution == notes.AttributeNames {
+		if o.authorNames == "" {
+			return errors.New("The display names must be set via -author-names or $AUTHOR_NAMES to attribute by name")
+		}
+		names, err := notes.LoadAuthorNames(o.authorNames)
+		if err != nil {
+			return err
+		}
+		o.attributionPolicy.Names = names
+	}
+
+	if o.taxonomyPath != "" {
+		taxonomy, err := notes.LoadTaxonomy(o.taxonomyPath)
+		if err != nil {
+			return err
+		}
+		o.taxonomy = taxonomy
+	} else if o.byKind {
+		o.taxonomy = notes.DefaultTaxonomy
+	}
+	return nil
+}
+
+// prepareWorkDir creates the work directory, discarding the outputs of a
+// previous run with -restart
+func (o *options) prepareWorkDir() error {
+	if o.restart {
+		if err := os.RemoveAll(o.workDir); err != nil {
+			return err
+		}
+	}
+	return os.MkdirAll(o.workDir, 0755)
+}
+
+// stage is a step of the pipeline. On resume, stages whose outputs all exist
+// in the work directory are skipped.
+type stage struct {
+	name    string
+	outputs []string
+	run     func(ctx context.Context) (status string, err error)
+}
+
+// The statuses of the stages in the summary.
+const (
+	statusDone    = "done"
+	statusResumed = "resumed"
+	statusMocked  = "mocked"
+)
+
+type stageResult struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+}
+
+// summary is the report of a run, printed to stdout
+type summary struct {
+	Range    *notes.ReleaseRange `json:"range"`
+	Notes    int                 `json:"notes"`
+	Problems int                 `json:"problems"`
+	Outputs  []string            `json:"outputs"`
+	Draft    string              `json:"draft,omitempty"`
+	AuditLog string              `json:"audit_log,omitempty"`
+	Stages   []*stageResult      `json:"stages"`
+}
+
+// pipeline holds the state passed from stage to stage
+type pipeline struct {
+	opts     *options
+	logger   log.Logger
+	client   *github.Client
+	registry notes.FormatRegistry
+	formats  []*notes.Format
+
+	releaseRange *notes.ReleaseRange
+	problems     []*notes.DigestProblem
+	summary      *summary
+}
+
+func (p *pipeline) path(name string) string {
+	return filepath.Join(p.opts.workDir, name)
+}
+
+func (p *pipeline) stages() []*stage {
+	rendered := []string{}
+	for _, format := range p.formats {
+		rendered = append(rendered, p.renderedPath(format))
+	}
+	return []*stage{
+		{name: "resolve", outputs: []string{p.path("range.json")}, run: p.resolve},
+		{name: "collect", outputs: []string{p.path("notes.json")}, run: p.collect},
+		{name: "apply-maps", outputs: []string{p.path("mapped.json")}, run: p.applyMaps},
+		{name: "validate", outputs: []string{p.path("validated.json"), p.path("problems.json")}, run: p.validate},
+		{name: "render", outputs: rendered, run: p.render},
+		{name: "publish", outputs: []string{p.path("draft.json")}, run: p.publish},
+	}
+}
+
+func (p *pipeline) renderedPath(format *notes.Format) string {
+	return p.path("release-notes-" + p.opts.tag + format.Extension)
+}
+
+// resolve finds the commit range from the previous tag to the tag
+func (p *pipeline) resolve(ctx context.Context) (string, error) {
+	releaseRange, err := notes.ResolveReleaseRange(
+		p.client, p.opts.tag,
+		notes.WithContext(ctx), notes.WithOrg(p.opts.githubOrg), notes.WithRepo(p.opts.githubRepo),
+	)
+	if err != nil {
+		return "", err
+	}
+	level.Info(p.logger).Log("msg", "resolved the commit range", "previous_tag", releaseRange.PreviousTag, "start", releaseRange.StartSHA, "end", releaseRange.EndSHA)
+	return statusDone, writeJSON(p.path("range.json"), releaseRange)
+}
+
+// collect fetches the release notes of the PRs in the commit range
+func (p *pipeline) collect(ctx context.Context) (string, error) {
+	if err := readJSON(p.path("range.json"), &p.releaseRange); err != nil {
+		return "", err
+	}
+	releaseNotes, err := notes.ListReleaseNotes(
+		p.client, p.logger, p.opts.branch, p.releaseRange.StartSHA, p.releaseRange.EndSHA, p.opts.requiredAuthor, p.opts.tag,
+		notes.WithContext(ctx), notes.WithOrg(p.opts.githubOrg), notes.WithRepo(p.opts.githubRepo),
+	)
+	if commitErrs, ok := err.(notes.CommitErrors); ok {
+		level.Warn(p.logger).Log("msg", "some commits could not be processed, continuing with the remaining release notes", "failed", len(commitErrs))
+		err = nil
+	}
+	if err != nil {
+		return "", err
+	}
+	level.Info(p.logger).Log("msg", "collected the release notes", "count", len(releaseNotes))
+	return statusDone, writeJSON(p.path("notes.json"), releaseNotes)
+}
+
+// applyMaps merges the duplicate notes and maps the notes to the categories
+// of the taxonomy, in the order release-notes applies them
+func (p *pipeline) applyMaps(ctx context.Context) (string, error) {
+	releaseNotes := notes.ReleaseNoteList{}
+	if err := readJSON(p.path("notes.json"), &releaseNotes); err != nil {
+		return "", err
+	}
+	if p.opts.dedup {
+		for _, merge := range notes.NewDeduplicator().Deduplicate(releaseNotes) {
+			for _, pr := range merge.Merged {
+				level.Info(p.logger).Log("msg", "merged duplicate release note", "pr", pr, "into", merge.Into, "reason", merge.Reasons[pr])
+			}
+		}
+	}
+	if p.opts.taxonomy != nil {
+		p.opts.taxonomy.Apply(releaseNotes)
+	}
+	return statusDone, writeJSON(p.path("mapped.json"), releaseNotes)
+}
+
+// validate lists the wording and documentation problems of the notes. The
+// notes with the warnings of the linter are written as the input of render,
+// so that a resumed run renders the same notes as a fresh one.
+func (p *pipeline) validate(ctx context.Context) (string, error) {
+	releaseNotes := notes.ReleaseNoteList{}
+	if err := readJSON(p.path("mapped.json"), &releaseNotes); err != nil {
+		return "", err
+	}
+	notes.NewLinter().Lint(releaseNotes)
+	problems := []*notes.DigestProblem{}
+	for _, note := range releaseNotes {
+		problems = append(problems, notes.NoteProblems(note)...)
+	}
+	for _, problem := range problems {
+		level.Warn(p.logger).Log("msg", "release note problem", "pr", problem.PrUrl, "problem", problem.Problem)
+	}
+	if err := writeJSON(p.path("validated.json"), releaseNotes); err != nil {
+		return "", err
+	}
+	return statusDone, writeJSON(p.path("problems.json"), problems)
+}
+
+// render applies the post-processing and attribution to the validated notes,
+// like release-notes does before rendering, and writes them in every format
+func (p *pipeline) render(ctx context.Context) (string, error) {
+	releaseNotes := notes.ReleaseNoteList{}
+	if err := readJSON(p.path("validated.json"), &releaseNotes); err != nil {
+		return "", err
+	}
+	if p.opts.postProcess {
+		notes.NewPostProcessing(p.opts.githubOrg, p.opts.githubRepo).Apply(releaseNotes)
+	}
+	p.opts.attributionPolicy.Apply(releaseNotes)
+	for _, format := range p.formats {
+		var b bytes.Buffer
+		if err := format.Renderer.Render(&b, releaseNotes); err != nil {
+			return "", err
+		}
+		if err := ioutil.WriteFile(p.renderedPath(format), b.Bytes(), 0644); err != nil {
+			return "", err
+		}
+		level.Info(p.logger).Log("msg", "release notes rendered", "format", format.Name, "path", p.renderedPath(format))
+	}
+	return statusDone, nil
+}
+
+// publish opens or updates the draft PR of the markdown notes
+func (p *pipeline) publish(ctx context.Context) (string, error) {
+	markdown, err := p.registry.Lookup("markdown")
+	if err != nil {
+		return "", err
+	}
+	content, err := ioutil.ReadFile(p.renderedPath(markdown))
+	if err != nil {
+		return "", err
+	}
+
+	if !p.opts.nomock {
+		level.Info(p.logger).Log("msg", "not opening the draft PR without -nomock", "target", p.opts.draftTarget)
+		return statusMocked, nil
+	}
+
+	out, err := sink.New(sink.TypePullRequest, p.opts.draftTarget, p.client)
+	if err != nil {
+		return "", err
+	}
+	if err := out.Write(ctx, content); err != nil {
+		return "", err
+	}
+	level.Info(p.logger).Log("msg", "draft PR updated", "location", out.Location())
+	return statusDone, writeJSON(p.path("draft.json"), map[string]string{"location": out.Location()})
+}
+
+// run runs the stages in order, skipping completed ones, and fills the summary
+func (p *pipeline) run(ctx context.Context) error {
+	for _, s := range p.stages() {
+		status := statusResumed
+		if !exist(s.outputs) {
+			var err error
+			if status, err = s.run(ctx); err != nil {
+				// the outputs written before the failure would mark the stage
+				// as completed
+				for _, output := range s.outputs {
+					os.Remove(output)
+				}
+				return fmt.Errorf("stage %s: %v", s.name, err)
+			}
+		} else {
+			level.Info(p.logger).Log("msg", "resuming after completed stage", "stage", s.name)
+		}
+		p.summary.Stages = append(p.summary.Stages, &stageResult{Name: s.name, Status: status})
+	}
+
+	if err := readJSON(p.path("range.json"), &p.summary.Range); err != nil {
+		return err
+	}
+	validated := notes.ReleaseNoteList{}
+	if err := readJSON(p.path("validated.json"), &validated); err != nil {
+		return err
+	}
+	p.summary.Notes = len(validated)
+	if err := readJSON(p.path("problems.json"), &p.problems); err != nil {
+		return err
+	}
+	p.summary.Problems = len(p.problems)
+	for _, format := range p.formats {
+		p.summary.Outputs = append(p.summary.Outputs, p.renderedPath(format))
+	}
+	draft := map[string]string{}
+	if exist([]string{p.path("draft.json")}) {
+		if err := readJSON(p.path("draft.json"), &draft); err != nil {
+			return err
+		}
+	}
+	p.summary.Draft = draft["location"]
+	return nil
+}
+
+func exist(paths []string) bool {
+	for _, path := range paths {
+		if _, err := os.Stat(path); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+func writeJSON(path string, v interface{}) error {
+	content, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, content, 0644)
+}
+
+func readJSON(path string, v interface{}) error {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(content, v); err != nil {
+		return fmt.Errorf("error parsing %s: %v", path, err)
+	}
+	return nil
+}
+
+func run(logger log.Logger, args []string) error {
+	opts := &options{}
+	if err := opts.BindFlags().Parse(args); err != nil {
+		return err
+	}
+	if err := opts.validate(); err != nil {
+		return err
+	}
+
+	registry := notes.NewFormatRegistry()
+	if opts.taxonomy != nil {
+		registry.Register(&notes.Format{
+			Name:           "markdown",
+			Extension:      ".md",
+			Renderer:       notes.MarkdownRenderer(opts.taxonomy),
+			DocumentFields: notes.MarkdownDocumentFields,
+		})
+	}
+	if opts.policyPath != "" {
+		policies, err := notes.LoadOutputPolicies(opts.policyPath)
+		if err != nil {
+			return err
+		}
+		policies.Wrap(registry)
+	}
+	names := registry.Names()
+	if opts.formats != "" {
+		names = []string{}
+		for _, name := range strings.Split(opts.formats, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, name)
+			}
+		}
+	}
+	// the draft PR is opened with the markdown notes
+	if !notes.HasString(names, "markdown") {
+		names = append(names, "markdown")
+	}
+	formats := []*notes.Format{}
+	for _, name := range names {
+		format, err := registry.Lookup(name)
+		if err != nil {
+			return err
+		}
+		formats = append(formats, format)
+	}
+
+	if err := opts.prepareWorkDir(); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	httpClient := oauth2.NewClient(ctx, oauth2.StaticTokenSource(
+		&oauth2.Token{AccessToken: opts.githubToken},
+	))
+	p := &pipeline{
+		opts:     opts,
+		logger:   logger,
+		client:   github.NewClient(httpClient),
+		registry: registry,
+		formats:  formats,
+		summary:  &summary{},
+	}
+	if opts.auditLog != "" {
+		audit, err := notes.NewAuditLog(opts.auditLog, opts.auditActor)
+		if err != nil {
+			return err
+		}
+		defer audit.Close()
+		httpClient.Transport = audit.Wrap(httpClient.Transport)
+		p.summary.AuditLog = audit.Path()
+	}
+	if err := p.run(ctx); err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(p.summary)
+}
+
+func main() {
+	logger := level.NewInjector(
+		log.NewLogfmtLogger(log.NewSyncWriter(os.Stderr)),
+		level.DebugValue(),
+	)
+
+	if err := run(logger, os.Args[1:]); err != nil {
+		level.Error(logger).Log("msg", "error cutting the release notes", "err", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/release/pkg/notes"
+)
+
+func TestPipelineResume(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cut-notes")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	opts := &options{githubToken: "token", tag: "v1.16.0", workDir: filepath.Join(dir, "work")}
+	require.NoError(t, opts.validate())
+	require.NoError(t, opts.prepareWorkDir())
+
+	// the range and notes of a previous run are resumed, so that GitHub is
+	// not called
+	releaseRange := &notes.ReleaseRange{Tag: "v1.16.0", PreviousTag: "v1.15.0", StartSHA: "a", EndSHA: "b"}
+	require.NoError(t, writeJSON(filepath.Join(opts.workDir, "range.json"), releaseRange))
+	require.NoError(t, writeJSON(filepath.Join(opts.workDir, "notes.json"), notes.ReleaseNoteList{
+		1: {Text: "Fixed it", Markdown: "Fixed it", PrNumber: 1, SIGs: []string{"node"}},
+	}))
+
+	registry := notes.NewFormatRegistry()
+	markdown, err := registry.Lookup("markdown")
+	require.NoError(t, err)
+	broken := &notes.Format{Name: "text", Extension: ".txt", Renderer: notes.RendererFunc(func(w io.Writer, releaseNotes notes.ReleaseNoteList) error {
+		return errors.New("broken renderer")
+	})}
+	newPipeline := func(formats ...*notes.Format) *pipeline {
+		return &pipeline{
+			opts:     opts,
+			logger:   log.NewNopLogger(),
+			registry: registry,
+			formats:  formats,
+			summary:  &summary{},
+		}
+	}
+
+	// a failed stage stops the run after the stages before it
+	p := newPipeline(markdown, broken)
+	err = p.run(context.Background())
+	require.EqualError(t, err, "stage render: broken renderer")
+	require.FileExists(t, filepath.Join(opts.workDir, "problems.json"))
+	require.False(t, exist([]string{filepath.Join(opts.workDir, "release-notes-v1.16.0.md")}))
+
+	// the next run resumes after the last completed stage
+	p = newPipeline(markdown)
+	require.NoError(t, p.run(context.Background()))
+	require.Equal(t, []*stageResult{
+		{Name: "resolve", Status: statusResumed},
+		{Name: "collect", Status: statusResumed},
+		{Name: "apply-maps", Status: statusResumed},
+		{Name: "validate", Status: statusResumed},
+		{Name: "render", Status: statusDone},
+		{Name: "publish", Status: statusMocked},
+	}, p.summary.Stages)
+	require.Equal(t, releaseRange, p.summary.Range)
+	require.Equal(t, 1, p.summary.Notes)
+	require.Equal(t, []string{filepath.Join(opts.workDir, "release-notes-v1.16.0.md")}, p.summary.Outputs)
+	content, err := ioutil.ReadFile(p.summary.Outputs[0])
+	require.NoError(t, err)
+	require.Contains(t, string(content), "Fixed it")
+
+	// -restart discards the outputs of the previous runs
+	opts.restart = true
+	require.NoError(t, opts.prepareWorkDir())
+	files, err := ioutil.ReadDir(opts.workDir)
+	require.NoError(t, err)
+	require.Empty(t, files)
+}
+
+func TestPipelineMatchesReleaseNotes(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cut-notes")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	opts := &options{githubToken: "token", tag: "v1.16.0", workDir: filepath.Join(dir, "work"), dedup: true, byKind: true, attribution: "none"}
+	require.NoError(t, opts.validate())
+	require.NoError(t, opts.prepareWorkDir())
+
+	require.NoError(t, writeJSON(filepath.Join(opts.workDir, "range.json"), &notes.ReleaseRange{Tag: "v1.16.0", StartSHA: "a", EndSHA: "b"}))
+	require.NoError(t, writeJSON(filepath.Join(opts.workDir, "notes.json"), notes.ReleaseNoteList{
+		1: {Text: "Pods now recieve the signal.", Markdown: "Pods now recieve the signal. ([#1](https://github.com/kubernetes/kubernetes/pull/1), [@alice](https://github.com/alice))", PrNumber: 1, Author: "alice", AuthorUrl: "https://github.com/alice", Kinds: []string{"bug"}},
+		2: {Text: "Pods now recieve the signal.", Markdown: "Pods now recieve the signal. ([#2](https://github.com/kubernetes/kubernetes/pull/2), [@bob](https://github.com/bob))", PrNumber: 2, Author: "bob", AuthorUrl: "https://github.com/bob", Kinds: []string{"bug"}},
+	}))
+
+	registry := notes.NewFormatRegistry()
+	jsonFormat, err := registry.Lookup("json")
+	require.NoError(t, err)
+	markdown, err := registry.Lookup("markdown")
+	require.NoError(t, err)
+	newPipeline := func() *pipeline {
+		return &pipeline{
+			opts:     opts,
+			logger:   log.NewNopLogger(),
+			registry: registry,
+			formats:  []*notes.Format{jsonFormat, markdown},
+			summary:  &summary{},
+		}
+	}
+
+	// the duplicates are merged, categorized and credited like release-notes
+	// does, and the warnings of the linter are rendered
+	p := newPipeline()
+	require.NoError(t, p.run(context.Background()))
+	require.Equal(t, 1, p.summary.Notes)
+	fresh, err := ioutil.ReadFile(p.summary.Outputs[0])
+	require.NoError(t, err)
+	rendered := notes.ReleaseNoteList{}
+	require.NoError(t, json.Unmarshal(fresh, &rendered))
+	require.Len(t, rendered, 1)
+	for _, note := range rendered {
+		require.Equal(t, "Bug or Regression", note.Category)
+		require.Empty(t, note.Author)
+		require.NotEmpty(t, note.Warnings)
+	}
+
+	// rendering again after a completed validate gives the same notes
+	require.NoError(t, os.Remove(p.summary.Outputs[0]))
+	p = newPipeline()
+	require.NoError(t, p.run(context.Background()))
+	require.Equal(t, &stageResult{Name: "validate", Status: statusResumed}, p.summary.Stages[3])
+	resumed, err := ioutil.ReadFile(p.summary.Outputs[0])
+	require.NoError(t, err)
+	require.Equal(t, string(fresh), string(resumed))
+}
+
+func TestValidate(t *testing.T) {
+	opts := &options{githubToken: "token", tag: "v1.16.2"}
+	require.NoError(t, opts.validate())
+	require.Equal(t, "cut-notes-v1.16.2", opts.workDir)
+	require.Equal(t, "kubernetes/sig-release@release-notes-v1.16.2:releases/release-1.16/release-notes-draft.md", opts.draftTarget)
+
+	// the defaults match those of release-notes
+	opts = &options{}
+	require.NoError(t, opts.BindFlags().Parse([]string{"-tag", "v1.16.0"}))
+	require.Equal(t, "k8s-ci-robot", opts.requiredAuthor)
+	require.False(t, opts.postProcess)
+
+	require.Error(t, (&options{githubToken: "token", tag: "v1.16"}).validate())
+}
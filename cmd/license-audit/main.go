@@ -0,0 +1,204 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/google/go-github/github"
+	"github.com/kolide/kit/env"
+	"golang.org/x/oauth2"
+
+	"k8s.io/release/pkg/license"
+)
+
+type options struct {
+	repoDir        string
+	releaseVersion string
+	policy         string
+	output         string
+	githubToken    string
+	githubOrg      string
+	githubRepo     string
+	nomock         bool
+}
+
+func (o *options) BindFlags() *flag.FlagSet {
+	flags := flag.NewFlagSet("license-audit", flag.ExitOnError)
+
+	// repoDir is the checkout of the repository whose dependencies are
+	// audited.
+	flags.StringVar(
+		&o.repoDir,
+		"repo-dir",
+		env.String("REPO_DIR", ""),
+		"The directory of a checkout of the repository at the release tag (required)",
+	)
+
+	// releaseVersion is the tag of the release the report is for.
+	flags.StringVar(
+		&o.releaseVersion,
+		"release-version",
+		env.String("RELEASE_VERSION", ""),
+		"The release tag, e.g. v1.15.0 (required)",
+	)
+
+	// policy is the path of the allowed and disallowed licenses.
+	flags.StringVar(
+		&o.policy,
+		"policy",
+		env.String("POLICY", ""),
+		"The path of a JSON file listing the allowed and disallowed licenses. Defaults to the licenses the CNCF allows",
+	)
+
+	// output is the path the report is written to.
+	flags.StringVar(
+		&o.output,
+		"output",
+		env.String("OUTPUT", "LICENSES.md"),
+		"The path the report is written to",
+	)
+
+	// githubToken contains a personal GitHub access token. This is used to
+	// attach the report to the release.
+	flags.StringVar(
+		&o.githubToken,
+		"github-token",
+		env.String("GITHUB_TOKEN", ""),
+		"A personal GitHub access token, required with -nomock",
+	)
+
+	// githubOrg contains the name of the github organization of the release.
+	flags.StringVar(
+		&o.githubOrg,
+		"github-org",
+		env.String("GITHUB_ORG", "kubernetes"),
+		"Name of the github organization of the release",
+	)
+
+	// githubRepo contains the name of the github repository of the release.
+	flags.StringVar(
+		&o.githubRepo,
+		"github-repo",
+		env.String("GITHUB_REPO", "kubernetes"),
+		"Name of the github repository of the release",
+	)
+
+	// nomock attaches the report to the release instead of only writing it.
+	flags.BoolVar(
+		&o.nomock,
+		"nomock",
+		env.Bool("NOMOCK", false),
+		"Attach the report to the GitHub release instead of only writing it",
+	)
+
+	return flags
+}
+
+func (o *options) validate() error {
+	if o.repoDir == "" {
+		return errors.New("The repository directory must be set via -repo-dir or $REPO_DIR")
+	}
+	if o.releaseVersion == "" {
+		return errors.New("The release version must be set via -release-version or $RELEASE_VERSION")
+	}
+	if o.nomock && o.githubToken == "" {
+		return errors.New("GitHub token must be set via -github-token or $GITHUB_TOKEN to attach the report")
+	}
+	return nil
+}
+
+// attach uploads the report as an asset of the GitHub release, replacing an
+// asset of the same name
+func (o *options) attach(ctx context.Context) error {
+	client := github.NewClient(oauth2.NewClient(ctx, oauth2.StaticTokenSource(
+		&oauth2.Token{AccessToken: o.githubToken},
+	)))
+
+	release, _, err := client.Repositories.GetReleaseByTag(ctx, o.githubOrg, o.githubRepo, o.releaseVersion)
+	if err != nil {
+		return err
+	}
+	name := filepath.Base(o.output)
+	assets, _, err := client.Repositories.ListReleaseAssets(ctx, o.githubOrg, o.githubRepo, release.GetID(), &github.ListOptions{PerPage: 100})
+	if err != nil {
+		return err
+	}
+	for _, asset := range assets {
+		if asset.GetName() == name {
+			if _, err := client.Repositories.DeleteReleaseAsset(ctx, o.githubOrg, o.githubRepo, asset.GetID()); err != nil {
+				return err
+			}
+		}
+	}
+
+	file, err := os.Open(o.output)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, _, err = client.Repositories.UploadReleaseAsset(ctx, o.githubOrg, o.githubRepo, release.GetID(), &github.UploadOptions{Name: name}, file)
+	return err
+}
+
+func run(logger log.Logger, args []string) error {
+	opts := &options{}
+	if err := opts.BindFlags().Parse(args); err != nil {
+		return err
+	}
+	if err := opts.validate(); err != nil {
+		return err
+	}
+
+	policy := license.DefaultPolicy
+	if opts.policy != "" {
+		var err error
+		if policy, err = license.LoadPolicy(opts.policy); err != nil {
+			return err
+		}
+	}
+
+	deps, err := license.Dependencies(opts.repoDir)
+	if err != nil {
+		return err
+	}
+	findings := policy.Evaluate(deps)
+	report := license.Report(opts.releaseVersion, deps, findings)
+	if err := ioutil.WriteFile(opts.output, []byte(report), 0644); err != nil {
+		return err
+	}
+	level.Info(logger).Log("msg", "license report written", "path", opts.output, "dependencies", len(deps))
+
+	if opts.nomock {
+		if err := opts.attach(context.Background()); err != nil {
+			return err
+		}
+		level.Info(logger).Log("msg", "license report attached to the release", "release", opts.releaseVersion)
+	}
+
+	for _, finding := range findings {
+		fmt.Println(finding)
+	}
+	if len(findings) > 0 {
+		return fmt.Errorf("%d of %d dependencies violate the license policy", len(findings), len(deps))
+	}
+	return nil
+}
+
+func main() {
+	logger := level.NewInjector(
+		log.NewLogfmtLogger(log.NewSyncWriter(os.Stderr)),
+		level.DebugValue(),
+	)
+
+	if err := run(logger, os.Args[1:]); err != nil {
+		level.Error(logger).Log("msg", "license audit failed", "err", err)
+		os.Exit(1)
+	}
+}
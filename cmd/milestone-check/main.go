@@ -0,0 +1,303 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/google/go-github/github"
+	"github.com/kolide/kit/env"
+	"golang.org/x/oauth2"
+
+	"k8s.io/release/pkg/milestone"
+	"k8s.io/release/pkg/mutation"
+	"k8s.io/release/pkg/notes"
+)
+
+type options struct {
+	githubToken      string
+	githubOrg        string
+	githubRepo       string
+	branch           string
+	startSHA         string
+	endSHA           string
+	milestone        string
+	enhancementsOrg  string
+	enhancementsRepo string
+	trackedLabel     string
+	nomock           bool
+	auditLog         string
+	auditActor       string
+	resumeFile       string
+	mutationReport   string
+}
+
+func (o *options) BindFlags() *flag.FlagSet {
+	flags := flag.NewFlagSet("milestone-check", flag.ExitOnError)
+
+	// githubToken contains a personal GitHub access token. It is used to
+	// list the PRs and issues and to apply the milestone.
+	flags.StringVar(
+		&o.githubToken,
+		"github-token",
+		env.String("GITHUB_TOKEN", ""),
+		"A personal GitHub access token (required)",
+	)
+
+	// githubOrg contains the name of the github organization of the repo the
+	// release is cut from.
+	flags.StringVar(
+		&o.githubOrg,
+		"github-org",
+		env.String("GITHUB_ORG", "kubernetes"),
+		"Name of the github organization of the repository the release is cut from",
+	)
+
+	// githubRepo contains the name of the github repository the release is
+	// cut from.
+	flags.StringVar(
+		&o.githubRepo,
+		"github-repo",
+		env.String("GITHUB_REPO", "kubernetes"),
+		"Name of the github repository the release is cut from",
+	)
+
+	// branch is the branch the release is cut from.
+	flags.StringVar(
+		&o.branch,
+		"branch",
+		env.String("BRANCH", "master"),
+		"The branch the release is cut from",
+	)
+
+	// startSHA contains the commit SHA where the release range starts.
+	flags.StringVar(
+		&o.startSHA,
+		"start-sha",
+		env.String("START_SHA", ""),
+		"The first commit of the release range",
+	)
+
+	// endSHA contains the commit SHA where the release range ends.
+	flags.StringVar(
+		&o.endSHA,
+		"end-sha",
+		env.String("END_SHA", ""),
+		"The last commit of the release range",
+	)
+
+	// milestone is the milestone every PR and issue of the release has to be
+	// in.
+	flags.StringVar(
+		&o.milestone,
+		"milestone",
+		env.String("MILESTONE", ""),
+		"The milestone of the release, e.g. v1.15 (required)",
+	)
+
+	// enhancementsOrg contains the name of the github organization of the
+	// enhancements repo.
+	flags.StringVar(
+		&o.enhancementsOrg,
+		"enhancements-org",
+		env.String("ENHANCEMENTS_ORG", "kubernetes"),
+		"Name of the github organization of the enhancements repository",
+	)
+
+	// enhancementsRepo contains the name of the repository tracking the
+	// enhancements of the release.
+	flags.StringVar(
+		&o.enhancementsRepo,
+		"enhancements-repo",
+		env.String("ENHANCEMENTS_REPO", "enhancements"),
+		"Name of the enhancements repository. Set to empty string to skip checking enhancements",
+	)
+
+	// trackedLabel is the label of the enhancements tracked for the release.
+	flags.StringVar(
+		&o.trackedLabel,
+		"tracked-label",
+		env.String("TRACKED_LABEL", "tracked/yes"),
+		"The label of the enhancements tracked for the release",
+	)
+
+	// nomock applies the missing milestones instead of only reporting them.
+	flags.BoolVar(
+		&o.nomock,
+		"nomock",
+		env.Bool("NOMOCK", false),
+		"Apply the milestone to every PR and issue lacking it instead of only reporting them",
+	)
+
+	// auditLog is the path of the log of the GitHub API calls of the run.
+	flags.StringVar(
+		&o.auditLog,
+		"audit-log",
+		env.String("AUDIT_LOG", ""),
+		"The path of a JSON lines file recording every GitHub API call of the run",
+	)
+
+	// auditActor is who the GitHub API calls are recorded as made by.
+	flags.StringVar(
+		&o.auditActor,
+		"audit-actor",
+		env.String("AUDIT_ACTOR", env.String("USER", "")),
+		"The actor recorded in the audit log. Defaults to $USER",
+	)
+
+	// resumeFile records the milestones already applied, so that they are not
+	// applied again when a failed run is resumed.
+	flags.StringVar(
+		&o.resumeFile,
+		"resume-file",
+		env.String("RESUME_FILE", ""),
+		"The path of a file recording the milestones applied with -nomock. Milestones listed in it are not applied again, so that a failed run can be resumed",
+	)
+
+	// mutationReport is the path of the report of the applied milestones.
+	flags.StringVar(
+		&o.mutationReport,
+		"mutation-report",
+		env.String("MUTATION_REPORT", ""),
+		"The path of a JSON report of the milestones applied and failed with -nomock",
+	)
+
+	return flags
+}
+
+func (o *options) validate() error {
+	if o.githubToken == "" {
+		return errors.New("GitHub token must be set via -github-token or $GITHUB_TOKEN")
+	}
+	if o.milestone == "" {
+		return errors.New("The milestone must be set via -milestone or $MILESTONE")
+	}
+	if (o.startSHA == "") != (o.endSHA == "") {
+		return errors.New("Both -start-sha and -end-sha must be set to check the PRs of a release range")
+	}
+	return nil
+}
+
+// releasePRs returns the PRs merged in the release range
+func (o *options) releasePRs(client *github.Client) ([]*github.PullRequest, error) {
+	opts := []notes.GithubApiOption{notes.WithOrg(o.githubOrg), notes.WithRepo(o.githubRepo)}
+	commits, err := notes.ListCommits(client, o.branch, o.startSHA, o.endSHA, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	prs := []*github.PullRequest{}
+	for _, commit := range commits {
+		pr, err := notes.PRFromCommit(client, commit, opts...)
+		if err != nil {
+			if err.Error() == "no matches found when parsing PR from commit" {
+				continue
+			}
+			return nil, err
+		}
+		prs = append(prs, pr)
+	}
+	return prs, nil
+}
+
+func run(logger log.Logger, args []string) error {
+	opts := &options{}
+	if err := opts.BindFlags().Parse(args); err != nil {
+		return err
+	}
+	if err := opts.validate(); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	httpClient := oauth2.NewClient(ctx, oauth2.StaticTokenSource(
+		&oauth2.Token{AccessToken: opts.githubToken},
+	))
+	if opts.auditLog != "" {
+		audit, err := notes.NewAuditLog(opts.auditLog, opts.auditActor)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			audit.Close()
+			level.Info(logger).Log("msg", "audit log written", "path", audit.Path())
+		}()
+		httpClient.Transport = audit.Wrap(httpClient.Transport)
+	}
+	client := github.NewClient(httpClient)
+
+	checker := milestone.NewChecker(client, opts.milestone)
+	checker.NoMock = opts.nomock
+	checker.Mutations.ResumeFile = opts.resumeFile
+	if opts.mutationReport != "" {
+		defer func() {
+			if err := writeMutationReport(opts.mutationReport, checker.Mutations.Report()); err != nil {
+				level.Error(logger).Log("msg", "error writing the mutation report", "err", err)
+				return
+			}
+			level.Info(logger).Log("msg", "mutation report written", "path", opts.mutationReport)
+		}()
+	}
+	problems := []*milestone.Problem{}
+
+	if opts.startSHA != "" {
+		level.Info(logger).Log("msg", "fetching the PRs of the release range. this might take a while...")
+		prs, err := opts.releasePRs(client)
+		if err != nil {
+			return err
+		}
+		prProblems, err := checker.CheckPRs(ctx, opts.githubOrg, opts.githubRepo, prs)
+		problems = append(problems, prProblems...)
+		if err != nil {
+			return err
+		}
+	}
+
+	if opts.enhancementsRepo != "" {
+		issueProblems, err := checker.CheckTrackedIssues(ctx, opts.enhancementsOrg, opts.enhancementsRepo, opts.trackedLabel)
+		problems = append(problems, issueProblems...)
+		if err != nil {
+			return err
+		}
+	}
+
+	unfixed := 0
+	for _, problem := range problems {
+		fmt.Println(problem)
+		if !problem.Fixed {
+			unfixed++
+		}
+	}
+	if unfixed > 0 {
+		return fmt.Errorf("%d PRs and issues lack the milestone %s, run with -nomock to apply it", unfixed, opts.milestone)
+	}
+	return nil
+}
+
+func writeMutationReport(path string, report *mutation.Report) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := report.Write(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+func main() {
+	logger := level.NewInjector(
+		log.NewLogfmtLogger(log.NewSyncWriter(os.Stderr)),
+		level.DebugValue(),
+	)
+
+	if err := run(logger, os.Args[1:]); err != nil {
+		level.Error(logger).Log("msg", "milestone check failed", "err", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/kolide/kit/env"
+
+	"k8s.io/release/pkg/mirror"
+)
+
+type options struct {
+	releaseVersion string
+	canonicalURL   string
+	mirrorURLs     string
+	artifacts      string
+	concurrency    int
+}
+
+func (o *options) BindFlags() *flag.FlagSet {
+	flags := flag.NewFlagSet("mirror-check", flag.ExitOnError)
+
+	// releaseVersion is the version of the release the artifacts belong to.
+	flags.StringVar(
+		&o.releaseVersion,
+		"release-version",
+		env.String("RELEASE_VERSION", ""),
+		"The version of the published release, e.g. v1.15.1 (required)",
+	)
+
+	// canonicalURL is the URL of the bucket holding the canonical artifacts.
+	flags.StringVar(
+		&o.canonicalURL,
+		"canonical-url",
+		env.String("CANONICAL_URL", "https://storage.googleapis.com/kubernetes-release"),
+		"The URL of the bucket holding the canonical artifacts",
+	)
+
+	// mirrorURLs lists the mirrors and CDN endpoints the artifacts are
+	// compared across.
+	flags.StringVar(
+		&o.mirrorURLs,
+		"mirror-urls",
+		env.String("MIRROR_URLS", "https://dl.k8s.io"),
+		"Comma separated URLs of the mirrors and CDN endpoints serving the artifacts",
+	)
+
+	// artifacts lists the artifacts which are verified.
+	flags.StringVar(
+		&o.artifacts,
+		"artifacts",
+		env.String("ARTIFACTS", ""),
+		"Comma separated paths of the artifacts to verify, relative to the bucket. Defaults to a sample of the artifacts of the release",
+	)
+
+	// concurrency limits the artifacts verified at the same time.
+	flags.IntVar(
+		&o.concurrency,
+		"concurrency",
+		env.Int("CONCURRENCY", 4),
+		"The number of artifacts verified at the same time",
+	)
+
+	return flags
+}
+
+func run(logger log.Logger, args []string) error {
+	opts := &options{}
+	if err := opts.BindFlags().Parse(args); err != nil {
+		return err
+	}
+	if opts.releaseVersion == "" && opts.artifacts == "" {
+		return errors.New("The release version must be set via -release-version or $RELEASE_VERSION")
+	}
+
+	artifacts := mirror.Artifacts(opts.releaseVersion)
+	if opts.artifacts != "" {
+		artifacts = strings.Split(opts.artifacts, ",")
+	}
+
+	verifier := mirror.NewVerifier()
+	verifier.CanonicalURL = opts.canonicalURL
+	verifier.MirrorURLs = strings.Split(opts.mirrorURLs, ",")
+	verifier.Concurrency = opts.concurrency
+
+	level.Info(logger).Log("msg", "verifying artifacts. this might take a while...", "artifacts", len(artifacts))
+	inconsistent := mirror.Inconsistent(verifier.Verify(context.Background(), artifacts))
+	for _, result := range inconsistent {
+		for _, problem := range result.Problems() {
+			fmt.Println(problem)
+		}
+	}
+	if len(inconsistent) > 0 {
+		return fmt.Errorf("%d of %d artifacts are not served consistently, hold the announcement", len(inconsistent), len(artifacts))
+	}
+	level.Info(logger).Log("msg", "all artifacts are served consistently")
+	return nil
+}
+
+func main() {
+	logger := level.NewInjector(
+		log.NewLogfmtLogger(log.NewSyncWriter(os.Stderr)),
+		level.DebugValue(),
+	)
+
+	if err := run(logger, os.Args[1:]); err != nil {
+		level.Error(logger).Log("msg", "mirror check failed", "err", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/kolide/kit/env"
+
+	"k8s.io/release/pkg/notes"
+)
+
+type options struct {
+	index string
+	add   string
+	query string
+}
+
+func (o *options) BindFlags() *flag.FlagSet {
+	flags := flag.NewFlagSet("notes-search", flag.ExitOnError)
+
+	// index is the path of the search index.
+	flags.StringVar(
+		&o.index,
+		"index",
+		env.String("INDEX", ""),
+		"The path of the search index, as written by release-notes -format index or by -add (required)",
+	)
+
+	// add lists the JSON release notes added to the index.
+	flags.StringVar(
+		&o.add,
+		"add",
+		env.String("ADD", ""),
+		"Comma separated list of release notes written by release-notes -format json, which are added to the index. The index is created if it does not exist",
+	)
+
+	// query contains the terms searched for.
+	flags.StringVar(
+		&o.query,
+		"query",
+		env.String("QUERY", ""),
+		"The terms to search for, e.g. --cpu-manager-policy. All terms have to match",
+	)
+
+	return flags
+}
+
+func (o *options) validate() error {
+	if o.index == "" {
+		return errors.New("The search index must be set via -index or $INDEX")
+	}
+	if o.add == "" && o.query == "" {
+		return errors.New("Either release notes to add must be set via -add or $ADD, or a query via -query or $QUERY")
+	}
+	return nil
+}
+
+func loadReleaseNotes(path string) (notes.ReleaseNoteList, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	releaseNotes := notes.ReleaseNoteList{}
+	if err := json.Unmarshal(content, &releaseNotes); err != nil {
+		return nil, fmt.Errorf("error parsing release notes %s: %v", path, err)
+	}
+	return releaseNotes, nil
+}
+
+func run(logger log.Logger, args []string) error {
+	opts := &options{}
+	if err := opts.BindFlags().Parse(args); err != nil {
+		return err
+	}
+	if err := opts.validate(); err != nil {
+		return err
+	}
+
+	index, err := notes.LoadSearchIndex(opts.index)
+	if os.IsNotExist(err) && opts.add != "" {
+		index, err = notes.NewSearchIndex(), nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if opts.add != "" {
+		for _, path := range strings.Split(opts.add, ",") {
+			releaseNotes, err := loadReleaseNotes(strings.TrimSpace(path))
+			if err != nil {
+				return err
+			}
+			index.Add(releaseNotes)
+			level.Info(logger).Log("msg", "release notes indexed", "path", path, "count", len(releaseNotes))
+		}
+		var b bytes.Buffer
+		if err := index.Write(&b); err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(opts.index, b.Bytes(), 0644); err != nil {
+			return err
+		}
+		level.Info(logger).Log("msg", "search index written", "path", opts.index, "documents", len(index.Documents))
+	}
+
+	if opts.query != "" {
+		for _, doc := range index.Search(opts.query) {
+			fmt.Printf("%s\t%s\t%s\n", doc.Release, doc.PrUrl, strings.Join(strings.Fields(doc.Text), " "))
+		}
+	}
+	return nil
+}
+
+func main() {
+	logger := level.NewInjector(
+		log.NewLogfmtLogger(log.NewSyncWriter(os.Stderr)),
+		level.DebugValue(),
+	)
+
+	if err := run(logger, os.Args[1:]); err != nil {
+		level.Error(logger).Log("msg", "error searching the release notes", "err", err)
+		os.Exit(1)
+	}
+}
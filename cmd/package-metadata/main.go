@@ -0,0 +1,188 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/kolide/kit/env"
+
+	"k8s.io/release/pkg/notes"
+	"k8s.io/release/pkg/packages"
+)
+
+type options struct {
+	releaseVersion  string
+	releaseNotes    string
+	debRevision     string
+	rpmRelease      string
+	distribution    string
+	cniVersion      string
+	criToolsVersion string
+	outputDir       string
+}
+
+func (o *options) BindFlags() *flag.FlagSet {
+	flags := flag.NewFlagSet("package-metadata", flag.ExitOnError)
+
+	// releaseVersion is the version the packages are built for.
+	flags.StringVar(
+		&o.releaseVersion,
+		"release-version",
+		env.String("RELEASE_VERSION", ""),
+		"The version of the release, e.g. v1.15.1 (required)",
+	)
+
+	// releaseNotes is the path of the JSON release notes summarized in the
+	// changelogs.
+	flags.StringVar(
+		&o.releaseNotes,
+		"release-notes",
+		env.String("RELEASE_NOTES", ""),
+		"The release notes of the release as written by release-notes -format json. Without them the changelogs only link to the kubernetes changelog",
+	)
+
+	// debRevision is the revision of the deb packages.
+	flags.StringVar(
+		&o.debRevision,
+		"deb-revision",
+		env.String("DEB_REVISION", "00"),
+		"The revision of the deb packages",
+	)
+
+	// rpmRelease is the release of the rpm packages.
+	flags.StringVar(
+		&o.rpmRelease,
+		"rpm-release",
+		env.String("RPM_RELEASE", "0"),
+		"The release of the rpm packages",
+	)
+
+	// distribution is the distribution of the debian changelog entries.
+	flags.StringVar(
+		&o.distribution,
+		"distribution",
+		env.String("DISTRIBUTION", "kubernetes-xenial"),
+		"The distribution of the debian changelog entries",
+	)
+
+	// cniVersion is the version of the kubernetes-cni package.
+	flags.StringVar(
+		&o.cniVersion,
+		"cni-version",
+		env.String("CNI_VERSION", "0.7.5"),
+		"The version of the kubernetes-cni package",
+	)
+
+	// criToolsVersion is the version of the cri-tools package.
+	flags.StringVar(
+		&o.criToolsVersion,
+		"cri-tools-version",
+		env.String("CRI_TOOLS_VERSION", "1.13.0"),
+		"The version of the cri-tools package",
+	)
+
+	// outputDir is the directory the metadata is written to.
+	flags.StringVar(
+		&o.outputDir,
+		"output-dir",
+		env.String("OUTPUT_DIR", ""),
+		"The directory the metadata is written to (required)",
+	)
+
+	return flags
+}
+
+func (o *options) loadReleaseNotes() (notes.ReleaseNoteList, error) {
+	releaseNotes := notes.ReleaseNoteList{}
+	if o.releaseNotes == "" {
+		return releaseNotes, nil
+	}
+	content, err := ioutil.ReadFile(o.releaseNotes)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(content, &releaseNotes); err != nil {
+		return nil, fmt.Errorf("error parsing release notes %s: %v", o.releaseNotes, err)
+	}
+	return releaseNotes, nil
+}
+
+func run(logger log.Logger, args []string) error {
+	opts := &options{}
+	if err := opts.BindFlags().Parse(args); err != nil {
+		return err
+	}
+	if opts.releaseVersion == "" {
+		return errors.New("The release version must be set via -release-version or $RELEASE_VERSION")
+	}
+	if opts.outputDir == "" {
+		return errors.New("The output directory must be set via -output-dir or $OUTPUT_DIR")
+	}
+
+	versions, err := packages.NewVersions(opts.releaseVersion, opts.debRevision, opts.rpmRelease)
+	if err != nil {
+		return err
+	}
+	releaseNotes, err := opts.loadReleaseNotes()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(opts.outputDir, 0755); err != nil {
+		return err
+	}
+
+	write := func(name string, content []byte) error {
+		path := filepath.Join(opts.outputDir, name)
+		if err := ioutil.WriteFile(path, content, 0644); err != nil {
+			return err
+		}
+		level.Info(logger).Log("msg", "written", "path", path)
+		return nil
+	}
+
+	now := time.Now().UTC()
+	pkgs := []string{}
+	for pkg := range packages.PackageSIGs {
+		pkgs = append(pkgs, pkg)
+	}
+	sort.Strings(pkgs)
+
+	entries := map[string][]string{}
+	for _, pkg := range pkgs {
+		entries[pkg] = packages.ChangelogEntries(pkg, versions, releaseNotes)
+		changelog := packages.DebianChangelog(pkg, opts.distribution, versions, entries[pkg], now)
+		if err := write(pkg+".debian.changelog", []byte(changelog)); err != nil {
+			return err
+		}
+	}
+	if err := write("kubelet.spec.changelog", []byte(packages.RPMChangelog(versions, entries, now))); err != nil {
+		return err
+	}
+
+	inputs, err := json.MarshalIndent(packages.NewSpecInputs(versions, opts.cniVersion, opts.criToolsVersion), "", "  ")
+	if err != nil {
+		return err
+	}
+	return write("spec-inputs.json", append(inputs, '\n'))
+}
+
+func main() {
+	logger := level.NewInjector(
+		log.NewLogfmtLogger(log.NewSyncWriter(os.Stderr)),
+		level.DebugValue(),
+	)
+
+	if err := run(logger, os.Args[1:]); err != nil {
+		level.Error(logger).Log("msg", "error generating package metadata", "err", err)
+		os.Exit(1)
+	}
+}
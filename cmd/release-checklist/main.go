@@ -0,0 +1,203 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/google/go-github/github"
+	"github.com/kolide/kit/env"
+	"golang.org/x/oauth2"
+
+	"k8s.io/release/pkg/checklist"
+	"k8s.io/release/pkg/notes"
+)
+
+type options struct {
+	githubToken string
+	version     string
+	issueOrg    string
+	issueRepo   string
+	nomock      bool
+	auditLog    string
+	auditActor  string
+}
+
+func (o *options) BindFlags() *flag.FlagSet {
+	flags := flag.NewFlagSet("release-checklist", flag.ExitOnError)
+
+	// githubToken contains a personal GitHub access token. This is used to
+	// file the tracking issue.
+	flags.StringVar(
+		&o.githubToken,
+		"github-token",
+		env.String("GITHUB_TOKEN", ""),
+		"A personal GitHub access token (required)",
+	)
+
+	// version is the version of the release cut the checklist is for.
+	flags.StringVar(
+		&o.version,
+		"release-version",
+		env.String("RELEASE_VERSION", ""),
+		"The version of the release cut, e.g. v1.15.0 (required)",
+	)
+
+	// issueOrg contains the name of the github organization of the repo
+	// tracking the release cut.
+	flags.StringVar(
+		&o.issueOrg,
+		"issue-org",
+		env.String("ISSUE_ORG", "kubernetes"),
+		"Name of the github organization of the repository tracking the release cut",
+	)
+
+	// issueRepo contains the name of the repository tracking the release cut.
+	flags.StringVar(
+		&o.issueRepo,
+		"issue-repo",
+		env.String("ISSUE_REPO", "sig-release"),
+		"Name of the repository tracking the release cut",
+	)
+
+	// nomock files the tracking issue instead of only printing the checklist.
+	flags.BoolVar(
+		&o.nomock,
+		"nomock",
+		env.Bool("NOMOCK", false),
+		"Create or update the tracking issue instead of only printing the checklist",
+	)
+
+	// auditLog is the path of the log of the API calls of the run.
+	flags.StringVar(
+		&o.auditLog,
+		"audit-log",
+		env.String("AUDIT_LOG", ""),
+		"The path of a JSON lines file recording every GitHub API call and release artifact check of the run",
+	)
+
+	// auditActor is who the API calls are recorded as made by.
+	flags.StringVar(
+		&o.auditActor,
+		"audit-actor",
+		env.String("AUDIT_ACTOR", env.String("USER", "")),
+		"The actor recorded in the audit log. Defaults to $USER",
+	)
+
+	return flags
+}
+
+// findIssue returns the open tracking issue of the release, or nil if there
+// is none yet
+func (o *options) findIssue(ctx context.Context, client *github.Client) (*github.Issue, error) {
+	title := checklist.Title(o.version)
+	opts := &github.IssueListByRepoOptions{
+		State:       "open",
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+	for {
+		issues, resp, err := client.Issues.ListByRepo(ctx, o.issueOrg, o.issueRepo, opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, issue := range issues {
+			if issue.GetTitle() == title && !issue.IsPullRequest() {
+				return issue, nil
+			}
+		}
+		if resp.NextPage == 0 {
+			return nil, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+func run(logger log.Logger, args []string) error {
+	opts := &options{}
+	if err := opts.BindFlags().Parse(args); err != nil {
+		return err
+	}
+	if opts.githubToken == "" {
+		return errors.New("GitHub token must be set via -github-token or $GITHUB_TOKEN")
+	}
+	if opts.version == "" {
+		return errors.New("The release version must be set via -release-version or $RELEASE_VERSION")
+	}
+
+	ctx := context.Background()
+	httpClient := oauth2.NewClient(ctx, oauth2.StaticTokenSource(
+		&oauth2.Token{AccessToken: opts.githubToken},
+	))
+	client := github.NewClient(httpClient)
+	checks := checklist.NewChecks(client)
+	if opts.auditLog != "" {
+		audit, err := notes.NewAuditLog(opts.auditLog, opts.auditActor)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			audit.Close()
+			level.Info(logger).Log("msg", "audit log written", "path", audit.Path())
+		}()
+		httpClient.Transport = audit.Wrap(httpClient.Transport)
+		checks.HTTP = &http.Client{Transport: audit.Wrap(nil)}
+	}
+
+	items, err := checks.Items(opts.version)
+	if err != nil {
+		return err
+	}
+
+	// keep the items which have been ticked by hand
+	issue, err := opts.findIssue(ctx, client)
+	if err != nil {
+		return err
+	}
+	status := checklist.Status{}
+	if issue != nil {
+		status = checklist.Parse(issue.GetBody())
+	}
+
+	if err := checklist.Refresh(ctx, items, status); err != nil {
+		return err
+	}
+	body := checklist.Render(opts.version, items, status)
+
+	if !opts.nomock {
+		fmt.Print(body)
+		return nil
+	}
+
+	request := &github.IssueRequest{
+		Title: github.String(checklist.Title(opts.version)),
+		Body:  &body,
+	}
+	if issue == nil {
+		issue, _, err = client.Issues.Create(ctx, opts.issueOrg, opts.issueRepo, request)
+	} else {
+		issue, _, err = client.Issues.Edit(ctx, opts.issueOrg, opts.issueRepo, issue.GetNumber(), request)
+	}
+	if err != nil {
+		return err
+	}
+
+	level.Info(logger).Log("msg", "checklist updated", "issue", issue.GetHTMLURL())
+	return nil
+}
+
+func main() {
+	logger := level.NewInjector(
+		log.NewLogfmtLogger(log.NewSyncWriter(os.Stderr)),
+		level.DebugValue(),
+	)
+
+	if err := run(logger, os.Args[1:]); err != nil {
+		level.Error(logger).Log("msg", "release checklist failed", "err", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"sync"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+
+	"k8s.io/release/pkg/notes"
+	"k8s.io/release/pkg/sink"
+)
+
+// batchRelease is a release listed in a batch file, e.g. one of the patch
+// releases cut on the same day
+type batchRelease struct {
+	Branch         string `json:"branch"`
+	StartSHA       string `json:"start_sha"`
+	EndSHA         string `json:"end_sha"`
+	ReleaseVersion string `json:"release_version"`
+
+	// Output is the path of the release notes for the file output type, and
+	// the target for all other output types
+	Output string `json:"output"`
+}
+
+// batchResult is the outcome of generating the release notes of a release,
+// as listed in the batch summary
+type batchResult struct {
+	ReleaseVersion string `json:"release_version"`
+	Branch         string `json:"branch"`
+	Output         string `json:"output"`
+	Notes          int    `json:"notes"`
+	Error          string `json:"error,omitempty"`
+}
+
+// loadBatch reads and validates the releases of a batch file
+func loadBatch(path string) ([]*batchRelease, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, notes.NewError(notes.ErrValidation, "error reading batch file: %v", err)
+	}
+
+	releases := []*batchRelease{}
+	if err := json.Unmarshal(content, &releases); err != nil {
+		return nil, notes.NewError(notes.ErrParse, "error parsing batch file %s: %v", path, err)
+	}
+	if len(releases) == 0 {
+		return nil, notes.NewError(notes.ErrValidation, "batch file %s lists no releases", path)
+	}
+
+	outputs := map[string]bool{}
+	for i, release := range releases {
+		if release.Branch == "" || release.StartSHA == "" || release.EndSHA == "" || release.Output == "" {
+			return nil, notes.NewError(notes.ErrValidation, "release %d of batch file %s requires branch, start_sha, end_sha and output", i+1, path)
+		}
+		if outputs[release.Output] {
+			return nil, notes.NewError(notes.ErrValidation, "releases of batch file %s share the output %s", path, release.Output)
+		}
+		outputs[release.Output] = true
+	}
+	return releases, nil
+}
+
+// forRelease returns a copy of the options which generates the release notes
+// of the given release
+func (o *options) forRelease(release *batchRelease) *options {
+	opts := *o
+	opts.branch = release.Branch
+	opts.startSHA = release.StartSHA
+	opts.endSHA = release.EndSHA
+	opts.releaseVersion = release.ReleaseVersion
+	if sink.Type(o.outputType) == sink.TypeFile {
+		opts.output = release.Output
+	} else {
+		opts.outputTarget = release.Output
+	}
+	opts.logger = log.With(o.logger, "release", release.ReleaseVersion, "branch", release.Branch)
+	return &opts
+}
+
+// runBatch generates the release notes of all releases of the batch file
+// concurrently and prints a summary. The GitHub API responses are shared
+// between the releases. It returns the error of the first failed release.
+func (o *options) runBatch() error {
+	releases, err := loadBatch(o.batch)
+	if err != nil {
+		level.Error(o.logger).Log("msg", "error loading batch file", "err", err)
+		return err
+	}
+
+	// the releases share the local repository, which is cloned and fetched up
+	// front so that they do not race to do it
+	if o.repoPath != "" {
+		for _, release := range releases {
+			if err := notes.PrepareGitRepo(o.logger, o.repoPath, release.StartSHA, release.EndSHA, notes.WithOrg(o.githubOrg), notes.WithRepo(o.githubRepo)); err != nil {
+				level.Error(o.logger).Log("msg", "error preparing the local repository", "err", err)
+				return err
+			}
+		}
+	}
+
+	// the releases share the cache given by -cache, or an in-memory one
+	if o.cache == nil {
+		o.cache = notes.NewResponseCache()
+	}
+	results := make([]*batchResult, len(releases))
+	errs := make([]error, len(releases))
+
+	var wg sync.WaitGroup
+	for i, release := range releases {
+		wg.Add(1)
+		go func(i int, release *batchRelease) {
+			defer wg.Done()
+			count, err := o.forRelease(release).generate()
+			results[i] = &batchResult{
+				ReleaseVersion: release.ReleaseVersion,
+				Branch:         release.Branch,
+				Output:         release.Output,
+				Notes:          count,
+			}
+			if err != nil {
+				results[i].Error = err.Error()
+				errs[i] = err
+			}
+		}(i, release)
+	}
+	wg.Wait()
+
+	level.Info(o.logger).Log(
+		"msg", "batch finished",
+		"releases", len(releases),
+		"cached_responses", o.cache.Hits(),
+	)
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(results); err != nil {
+		return err
+	}
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+
+	"k8s.io/release/pkg/notes"
+)
+
+// The exit codes of the release-notes tool, one per category of failure, so
+// that CI pipelines can branch on the cause of a failure.
+const (
+	exitCodeUnknown     = 1
+	exitCodeValidation  = 2
+	exitCodeNotFound    = 3
+	exitCodeRateLimited = 4
+	exitCodeParse       = 5
+	exitCodeTimeout     = 6
+	exitCodeUnavailable = 7
+)
+
+// failureCategories maps the error categories of the notes package to the
+// name used in the failure report and the exit code
+var failureCategories = []struct {
+	kind     error
+	name     string
+	exitCode int
+}{
+	{notes.ErrValidation, "validation", exitCodeValidation},
+	{notes.ErrNotFound, "not_found", exitCodeNotFound},
+	{notes.ErrRateLimited, "rate_limited", exitCodeRateLimited},
+	{notes.ErrParse, "parse", exitCodeParse},
+	{notes.ErrUnavailable, "unavailable", exitCodeUnavailable},
+	{context.DeadlineExceeded, "timeout", exitCodeTimeout},
+}
+
+// failureReport is the machine readable report of a failed run
+type failureReport struct {
+	Error    string `json:"error"`
+	Category string `json:"category"`
+	ExitCode int    `json:"exit_code"`
+
+	// FailedCommits lists the commits which could not be processed, if the
+	// failure is caused by a single commit
+	FailedCommits []string `json:"failed_commits,omitempty"`
+
+	// AuditLog is the path of the audit log of the run, if enabled
+	AuditLog string `json:"audit_log,omitempty"`
+
+	// Dependencies is the health of the external dependencies of the run,
+	// which tells whether the failure is caused by an outage
+	Dependencies []*notes.DependencyHealth `json:"dependencies,omitempty"`
+}
+
+// categorize returns the name and the exit code of the error's category
+func categorize(err error) (string, int) {
+	for _, category := range failureCategories {
+		if errors.Is(err, category.kind) {
+			return category.name, category.exitCode
+		}
+	}
+	return "unknown", exitCodeUnknown
+}
+
+// exitCode returns the exit code of the tool for the given error
+func exitCode(err error) int {
+	_, code := categorize(err)
+	return code
+}
+
+// writeFailureReport writes the failure report of the error as JSON
+func writeFailureReport(w io.Writer, err error, auditLog string, dependencies []*notes.DependencyHealth) error {
+	category, code := categorize(err)
+	report := failureReport{
+		Error:        err.Error(),
+		Category:     category,
+		ExitCode:     code,
+		AuditLog:     auditLog,
+		Dependencies: dependencies,
+	}
+
+	var commitErr *notes.CommitError
+	if errors.As(err, &commitErr) {
+		report.FailedCommits = []string{commitErr.SHA}
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(report)
+}
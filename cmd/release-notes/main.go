@@ -3,11 +3,18 @@ package main
 import (
 	"context"
 	"encoding/json"
-	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"io/ioutil"
+	"net/http"
+	"net/url"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/go-kit/kit/log"
 	"github.com/go-kit/kit/log/level"
@@ -15,7 +22,12 @@ import (
 	"github.com/kolide/kit/env"
 	"golang.org/x/oauth2"
 
+	"k8s.io/release/pkg/community"
+	"k8s.io/release/pkg/flagutil"
+	"k8s.io/release/pkg/linkcheck"
 	"k8s.io/release/pkg/notes"
+	"k8s.io/release/pkg/sink"
+	"k8s.io/release/pkg/version"
 )
 
 type options struct {
@@ -23,13 +35,84 @@ type options struct {
 	githubOrg      string
 	githubRepo     string
 	output         string
+	outputType     string
+	outputTarget   string
 	branch         string
 	startSHA       string
 	endSHA         string
+	searchQuery    string
+	repoPath       string
 	releaseVersion string
-	format         string
+	formats        listFlag
 	requiredAuthor string
+	sigsYAML       string
+	strict         bool
+	dedup          bool
+	lint           bool
+	lintMinWords   int
+	lintMaxWords   int
+	errorFormat    string
+	checkLinks     string
+	requestTimeout time.Duration
+	timeout        time.Duration
+	mergedSince    string
+	mergedUntil    string
+	auditLog       string
+	auditActor     string
+	batch          string
+	serveAddress   string
+	apiToken       string
+	showVersion    bool
+	versionCheck   bool
+	embargoed      bool
+	advisories     string
+	cacheLocation  string
+	cacheMaxAge    time.Duration
+	quotaBudget    int
+	byKind         bool
+	taxonomyPath   string
+	postProcess    bool
+	rulesPath      string
+	policyPath     string
+	attribution    string
+	authorNames    string
+	split          bool
+	splitBaseURL   string
 	logger         log.Logger
+
+	// githubURL overrides the URL of the GitHub API, for tests
+	githubURL string
+
+	// audit records the GitHub API calls if -audit-log is set
+	audit *notes.AuditLog
+
+	// extractionRules are loaded from -extraction-rules
+	extractionRules *notes.ExtractionRules
+
+	// outputPolicies are loaded from -output-policy
+	outputPolicies *notes.OutputPolicies
+
+	// window restricts the notes to the PRs merged within -merged-since and
+	// -merged-until
+	window notes.TimeWindow
+
+	// taxonomy buckets the notes by kind if -by-kind or -taxonomy is set
+	taxonomy *notes.Taxonomy
+
+	// attributionPolicy credits the authors of the notes as set by
+	// -attribution
+	attributionPolicy *notes.AttributionPolicy
+
+	// cache is shared by the GitHub clients of all releases in batch mode, or
+	// kept in the store given by -cache
+	cache *notes.ResponseCache
+
+	// budget limits the GitHub API requests to -quota-budget
+	budget *notes.QuotaBudget
+
+	// breaker tracks the health of the GitHub API and GCS, and stops calling
+	// them for a while after repeated failures
+	breaker *notes.CircuitBreaker
 }
 
 func (o *options) BindFlags() *flag.FlagSet {
@@ -40,7 +123,7 @@ func (o *options) BindFlags() *flag.FlagSet {
 		&o.githubToken,
 		"github-token",
 		env.String("GITHUB_TOKEN", ""),
-		"A personal GitHub access token (required)",
+		"A personal GitHub access token. Without a token, public repositories can be read within the unauthenticated rate limit",
 	)
 
 	// githubOrg contains name of github organization that holds the repo to scrape.
@@ -68,6 +151,22 @@ func (o *options) BindFlags() *flag.FlagSet {
 		"The path to the where the release notes will be printed",
 	)
 
+	// outputType selects the sink the rendered release notes are written to.
+	flags.StringVar(
+		&o.outputType,
+		"output-type",
+		env.String("OUTPUT_TYPE", string(sink.TypeFile)),
+		"Where to write the release notes (options: file, github, pr, gist, gcs)",
+	)
+
+	// outputTarget is the sink specific location of the release notes.
+	flags.StringVar(
+		&o.outputTarget,
+		"output-target",
+		env.String("OUTPUT_TARGET", ""),
+		"The location within the output type: a path for file, org/repo@branch:path for github and pr, a file name for gist, gs://bucket/object for gcs",
+	)
+
 	// branch is which branch to scrape.
 	flags.StringVar(
 		&o.branch,
@@ -93,6 +192,24 @@ func (o *options) BindFlags() *flag.FlagSet {
 		"The commit hash to end at",
 	)
 
+	// searchQuery selects the PRs by a GitHub search query instead of the
+	// commit range.
+	flags.StringVar(
+		&o.searchQuery,
+		"search-query",
+		env.String("SEARCH_QUERY", ""),
+		"A GitHub search query selecting the PRs to collect release notes from instead of a commit range, e.g. 'repo:kubernetes/kubernetes is:pr is:merged milestone:v1.15'",
+	)
+
+	// repoPath walks the commit range in a local clone instead of listing the
+	// commits with the GitHub API.
+	flags.StringVar(
+		&o.repoPath,
+		"repo-path",
+		env.String("REPO_PATH", ""),
+		"The path of a local clone of the repository to walk the commit range in instead of using the GitHub API, which is much faster for large ranges. The repository is cloned if the path does not exist",
+	)
+
 	// releaseVersion is the version number you want to tag the notes with.
 	flags.StringVar(
 		&o.releaseVersion,
@@ -101,30 +218,348 @@ func (o *options) BindFlags() *flag.FlagSet {
 		"Which release version to tag the entries as.",
 	)
 
-	// format is the output format to produce the notes in.
-	flags.StringVar(
-		&o.format,
+	// formats are the output formats to produce the notes in.
+	o.formats = newListFlag(env.String("FORMAT", "markdown"))
+	flags.Var(
+		&o.formats,
 		"format",
-		env.String("FORMAT", "markdown"),
-		"The format for notes output (options: markdown, json)",
+		"The format for notes output (options: "+strings.Join(notes.NewFormatRegistry().Names(), ", ")+"). Repeat the flag or list several formats separated by commas to write the notes in each of them, named after the output with the extension of the format",
 	)
 
 	flags.StringVar(
 		&o.requiredAuthor,
 		"requiredAuthor",
 		env.String("REQUIRED_AUTHOR", "k8s-ci-robot"),
-		"Only commits from this GitHub user are considered. Set to empty string to include all users",
+		"Only commits from this GitHub user are considered. Set to empty string to include all users. Cannot be set with -search-query or -repo-path, which do not filter by author",
+	)
+
+	// sigsYAML is the location of the kubernetes/community sigs.yaml which
+	// is used to render the full names of the SIGs.
+	flags.StringVar(
+		&o.sigsYAML,
+		"sigs-yaml",
+		env.String("SIGS_YAML", ""),
+		fmt.Sprintf("Path or URL of a sigs.yaml to look up SIG names in, e.g. %s", community.SigsURL),
+	)
+
+	// strict aborts at the first commit which cannot be processed instead of
+	// skipping it.
+	flags.BoolVar(
+		&o.strict,
+		"strict",
+		env.Bool("STRICT", false),
+		"Fail at the first commit which cannot be processed instead of skipping it",
+	)
+
+	// dedup merges release notes which describe the same change.
+	flags.BoolVar(
+		&o.dedup,
+		"dedup",
+		env.Bool("DEDUP", false),
+		"Merge release notes with near-identical text or references to the same KEP or issue",
+	)
+
+	// byKind organizes the notes by kind like the upstream changelogs.
+	flags.BoolVar(
+		&o.byKind,
+		"by-kind",
+		env.Bool("BY_KIND", false),
+		"Bucket the notes into the changelog categories API Change, Feature, Bug or Regression, Cleanup and Failing Test by their kind/ labels and text, instead of by SIG",
+	)
+
+	// taxonomyPath configures the categories of -by-kind.
+	flags.StringVar(
+		&o.taxonomyPath,
+		"taxonomy",
+		env.String("TAXONOMY", ""),
+		"The path of a JSON file defining the categories to bucket the notes into by kind, implies -by-kind",
+	)
+
+	// attribution controls how the authors of the notes are credited.
+	flags.StringVar(
+		&o.attribution,
+		"attribution",
+		env.String("ATTRIBUTION", string(notes.AttributeHandles)),
+		"How to credit the authors of the notes in all formats (options: handles, names, none). names uses the display names of -author-names",
+	)
+
+	// rulesPath overrides how the notes are extracted from PR descriptions.
+	flags.StringVar(
+		&o.rulesPath,
+		"extraction-rules",
+		env.String("EXTRACTION_RULES", ""),
+		"The path of a JSON file with the rules extracting the notes from the descriptions of PRs, for repositories with another PR template than kubernetes/kubernetes",
+	)
+
+	// policyPath selects the fields of the notes every format includes.
+	flags.StringVar(
+		&o.policyPath,
+		"output-policy",
+		env.String("OUTPUT_POLICY", ""),
+		"The path of a JSON file with the fields of the release notes every format includes or redacts, e.g. to publish only the text and links",
+	)
+
+	// postProcess cleans up the text of the notes.
+	flags.BoolVar(
+		&o.postProcess,
+		"post-process",
+		env.Bool("POST_PROCESS", false),
+		"Strip HTML comments and smart quotes from the notes, and link bare commit SHAs and issue references in the markdown",
+	)
+
+	// authorNames maps GitHub handles to display names.
+	flags.StringVar(
+		&o.authorNames,
+		"author-names",
+		env.String("AUTHOR_NAMES", ""),
+		"The path of a JSON object mapping GitHub handles to display names, used with -attribution names",
+	)
+
+	// split splits markdown notes too long for a GitHub release body.
+	flags.BoolVar(
+		&o.split,
+		"split",
+		env.Bool("SPLIT", false),
+		"Split markdown notes longer than the limit of GitHub release bodies into a main file and supplementary files it links to, named after the output with a -2, -3, ... suffix. Not supported by the pr and gist output types",
+	)
+
+	// splitBaseURL is the location the supplementary files are linked at.
+	flags.StringVar(
+		&o.splitBaseURL,
+		"split-base-url",
+		env.String("SPLIT_BASE_URL", ""),
+		"The URL prepended to the names of the supplementary files when linking to them, e.g. the directory they are committed to. Defaults to relative links",
+	)
+
+	// lint checks the wording of the release notes.
+	flags.BoolVar(
+		&o.lint,
+		"lint",
+		env.Bool("LINT", false),
+		"Check the release notes for misspellings and style issues and log a warning for every issue found",
+	)
+
+	// lintMinWords and lintMaxWords limit the length of the release notes
+	// when linting.
+	flags.IntVar(
+		&o.lintMinWords,
+		"lint-min-words",
+		env.Int("LINT_MIN_WORDS", 0),
+		"The minimum number of words of a release note when linting. Zero means no limit",
+	)
+	flags.IntVar(
+		&o.lintMaxWords,
+		"lint-max-words",
+		env.Int("LINT_MAX_WORDS", 0),
+		"The maximum number of words of a release note when linting, longer notes get a shortened version proposed. Zero means no limit",
+	)
+
+	// requestTimeout limits the duration of every single GitHub API request.
+	flags.DurationVar(
+		&o.requestTimeout,
+		"request-timeout",
+		env.Duration("REQUEST_TIMEOUT", 0),
+		"The timeout of every single GitHub API request, e.g. 30s. Zero means no timeout",
+	)
+
+	// timeout limits the overall duration of fetching the release notes.
+	flags.DurationVar(
+		&o.timeout,
+		"timeout",
+		env.Duration("TIMEOUT", 0),
+		"The overall time to spend fetching release notes, e.g. 30m. The notes fetched so far are written once it passes. Zero means no timeout",
+	)
+
+	// mergedSince and mergedUntil restrict the notes to the PRs merged
+	// within a time window.
+	flags.StringVar(
+		&o.mergedSince,
+		"merged-since",
+		env.String("MERGED_SINCE", ""),
+		"Only include the PRs merged at or after this time, given as RFC 3339 time, as date, e.g. 2019-07-01, or as duration before now, e.g. 168h. The commits merged before are skipped without fetching their PRs",
+	)
+
+	flags.StringVar(
+		&o.mergedUntil,
+		"merged-until",
+		env.String("MERGED_UNTIL", ""),
+		"Only include the PRs merged before this time, given like -merged-since",
+	)
+
+	// checkLinks verifies the URLs in the rendered release notes.
+	flags.StringVar(
+		&o.checkLinks,
+		"check-links",
+		env.String("CHECK_LINKS", ""),
+		"Check every URL in the rendered release notes before publishing them, and warn or fail on broken links (options: warn, fail)",
+	)
+
+	// auditLog is the path of the audit log of all GitHub API calls.
+	flags.StringVar(
+		&o.auditLog,
+		"audit-log",
+		env.String("AUDIT_LOG", ""),
+		"The path of a JSON lines file recording every GitHub API call of the run",
+	)
+
+	// auditActor is the person or automation the audited calls are
+	// attributed to.
+	flags.StringVar(
+		&o.auditActor,
+		"audit-actor",
+		env.String("AUDIT_ACTOR", env.String("USER", "")),
+		"The actor recorded in the audit log. Defaults to $USER",
+	)
+
+	// batch is the path of a file listing several releases to generate the
+	// release notes for at once.
+	flags.StringVar(
+		&o.batch,
+		"batch",
+		env.String("BATCH", ""),
+		"The path of a JSON file listing releases to generate concurrently, overriding -branch, -start-sha, -end-sha, -release-version and the output per release. A summary is printed to stdout",
+	)
+
+	// serveAddress runs the REST API instead of generating release notes.
+	flags.StringVar(
+		&o.serveAddress,
+		"serve",
+		env.String("SERVE", ""),
+		"The address to serve a REST API for collecting, rendering and validating release notes on, e.g. :8080, instead of generating release notes. Requires -api-token",
+	)
+
+	// apiToken authenticates the clients of the REST API.
+	flags.StringVar(
+		&o.apiToken,
+		"api-token",
+		env.String("API_TOKEN", ""),
+		"The bearer token the clients of the REST API have to present",
+	)
+
+	// showVersion prints the build information.
+	flags.BoolVar(
+		&o.showVersion,
+		"version",
+		false,
+		"Print the version and build information and exit",
+	)
+
+	// versionCheck compares the binary against the latest release.
+	flags.BoolVar(
+		&o.versionCheck,
+		"version-check",
+		false,
+		"Check whether a newer release of this tool is available and exit",
+	)
+
+	// embargoed runs against a private security fork without writing to any
+	// public location.
+	flags.BoolVar(
+		&o.embargoed,
+		"embargoed",
+		env.Bool("EMBARGOED", false),
+		"Prepare the notes of an embargoed security release: all GitHub API writes are blocked and the notes can only be written to a local file or a private gcs bucket",
+	)
+
+	// advisories injects the details of the fixed vulnerabilities.
+	flags.StringVar(
+		&o.advisories,
+		"advisories",
+		env.String("ADVISORIES", ""),
+		"The path of a JSON file listing the CVEs fixed by the release and their PRs, whose details are added to the notes when publishing. Not allowed with -embargoed",
+	)
+
+	// cacheLocation is the store the GitHub API responses are cached in
+	// across runs.
+	flags.StringVar(
+		&o.cacheLocation,
+		"cache",
+		env.String("CACHE", ""),
+		"Where to cache GitHub API responses across runs: a directory, or gs://bucket/prefix or redis://[:password@]host:port/db to share the cache between CI runners",
+	)
+
+	// cacheMaxAge limits how long cached responses are reused.
+	flags.DurationVar(
+		&o.cacheMaxAge,
+		"cache-max-age",
+		env.Duration("CACHE_MAX_AGE", time.Hour),
+		"The age after which responses cached with -cache are fetched again. Zero means they never expire",
+	)
+
+	// quotaBudget limits the number of GitHub API requests.
+	flags.IntVar(
+		&o.quotaBudget,
+		"quota-budget",
+		env.Int("QUOTA_BUDGET", 0),
+		"The number of GitHub API requests a run may make. PRs beyond the budget are skipped and the notes of the others are written. Defaults to the unauthenticated rate limit without -github-token",
+	)
+
+	// errorFormat is the format failures are reported in.
+	flags.StringVar(
+		&o.errorFormat,
+		"error-format",
+		env.String("ERROR_FORMAT", "text"),
+		"The format failures are reported in (options: text, json). The json report is printed to stdout",
 	)
 	return flags
 }
 
+// githubClient creates a GitHub API client authenticated with the configured
+// token
+func (o *options) githubClient(ctx context.Context) *github.Client {
+	httpClient := &http.Client{}
+	if o.githubToken != "" {
+		httpClient = oauth2.NewClient(ctx, oauth2.StaticTokenSource(
+			&oauth2.Token{AccessToken: o.githubToken},
+		))
+	}
+	// writes blocked by the embargo never reach GitHub, so they are not
+	// failures of the API
+	if o.breaker != nil {
+		httpClient.Transport = o.breaker.Wrap("github", httpClient.Transport)
+	}
+	if o.embargoed {
+		httpClient.Transport = notes.ReadOnly(httpClient.Transport)
+	}
+	if o.audit != nil {
+		httpClient.Transport = o.audit.Wrap(httpClient.Transport)
+	}
+	// cached responses do not spend the budget
+	if o.budget != nil {
+		httpClient.Transport = o.budget.Wrap(httpClient.Transport)
+	}
+	if o.cache != nil {
+		httpClient.Transport = o.cache.Wrap(httpClient.Transport)
+	}
+	client := github.NewClient(httpClient)
+	if o.githubURL != "" {
+		client.BaseURL, _ = url.Parse(o.githubURL)
+	}
+	return client
+}
+
+// GetReleaseNotes fetches the release notes. If fetching is interrupted by
+// the overall timeout or a signal, the notes fetched so far are returned
+// together with a *notes.InterruptedError.
 func (o *options) GetReleaseNotes() (notes.ReleaseNoteList, error) {
+	// Stop fetching gracefully on SIGINT and SIGTERM, so that the notes fetched
+	// so far can still be written
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+	go func() {
+		select {
+		case <-signals:
+			level.Warn(o.logger).Log("msg", "received signal, stopping after the current request")
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
 	// Create the GitHub API client
-	ctx := context.Background()
-	httpClient := oauth2.NewClient(ctx, oauth2.StaticTokenSource(
-		&oauth2.Token{AccessToken: o.githubToken},
-	))
-	githubClient := github.NewClient(httpClient)
+	githubClient := o.githubClient(ctx)
 
 	// Fetch a list of fully-contextualized release notes
 	level.Info(o.logger).Log("msg", "fetching all commits. this might take a while...")
@@ -136,41 +571,202 @@ func (o *options) GetReleaseNotes() (notes.ReleaseNoteList, error) {
 	if o.githubRepo != "" {
 		opts = append(opts, notes.WithRepo(o.githubRepo))
 	}
+	if o.strict {
+		opts = append(opts, notes.WithFailFast(true))
+	}
+	if o.requestTimeout > 0 {
+		opts = append(opts, notes.WithRequestTimeout(o.requestTimeout))
+	}
+	if o.timeout > 0 {
+		opts = append(opts, notes.WithOverallDeadline(time.Now().Add(o.timeout)))
+	}
+	if !o.window.IsZero() {
+		opts = append(opts, notes.WithTimeWindow(o.window))
+	}
+	if o.extractionRules != nil {
+		opts = append(opts, notes.WithExtractionRules(o.extractionRules))
+	}
+	if o.budget != nil {
+		opts = append(opts, notes.WithQuotaBudget(o.budget))
+	}
 
-	releaseNotes, err := notes.ListReleaseNotes(githubClient, o.logger, o.branch, o.startSHA, o.endSHA, o.requiredAuthor, o.releaseVersion, opts...)
-	if err != nil {
+	var releaseNotes notes.ReleaseNoteList
+	var err error
+	switch {
+	case o.searchQuery != "":
+		releaseNotes, err = notes.ListReleaseNotesFromSearch(githubClient, o.logger, o.searchQuery, o.releaseVersion, opts...)
+	case o.repoPath != "":
+		if err := notes.PrepareGitRepo(o.logger, o.repoPath, o.startSHA, o.endSHA, opts...); err != nil {
+			level.Error(o.logger).Log("msg", "error preparing the local repository", "err", err)
+			return nil, err
+		}
+		releaseNotes, err = notes.ListReleaseNotesFromGit(githubClient, o.logger, o.repoPath, o.startSHA, o.endSHA, o.releaseVersion, opts...)
+	default:
+		releaseNotes, err = notes.ListReleaseNotes(githubClient, o.logger, o.branch, o.startSHA, o.endSHA, o.requiredAuthor, o.releaseVersion, opts...)
+	}
+	if commitErrs, ok := err.(notes.CommitErrors); ok {
+		level.Warn(o.logger).Log(
+			"msg", "some commits could not be processed, continuing with the remaining release notes",
+			"failed", len(commitErrs),
+		)
+		err = nil
+	}
+	// the notes fetched before an interruption are processed like all others
+	interrupted, partial := err.(*notes.InterruptedError)
+	if partial {
+		level.Warn(o.logger).Log(
+			"msg", "fetching release notes was interrupted, continuing with the notes fetched so far",
+			"err", interrupted,
+			"failed", len(interrupted.Failed),
+		)
+	} else if err != nil {
 		level.Error(o.logger).Log("msg", "error generating release notes", "err", err)
 		return nil, err
 	}
 
+	if o.dedup {
+		for _, merge := range notes.NewDeduplicator().Deduplicate(releaseNotes) {
+			for _, pr := range merge.Merged {
+				level.Info(o.logger).Log("msg", "merged duplicate release note", "pr", pr, "into", merge.Into, "reason", merge.Reasons[pr])
+			}
+		}
+	}
+
+	if o.lint {
+		o.lintReleaseNotes(releaseNotes)
+	}
+
+	if o.taxonomy != nil {
+		o.taxonomy.Apply(releaseNotes)
+	}
+
+	if partial {
+		return releaseNotes, interrupted
+	}
 	return releaseNotes, nil
 }
 
+// lintReleaseNotes attaches warnings about wording issues to the release
+// notes and logs them
+func (o *options) lintReleaseNotes(releaseNotes notes.ReleaseNoteList) {
+	linter := notes.NewLinter()
+	linter.MinWords = o.lintMinWords
+	linter.MaxWords = o.lintMaxWords
+	linter.Lint(releaseNotes)
+	for _, note := range releaseNotes {
+		for _, warning := range note.Warnings {
+			level.Warn(o.logger).Log("msg", "release note wording", "pr", note.PrUrl, "warning", warning)
+		}
+	}
+}
+
+// prepareReleaseNotes applies the post-processing, attribution and
+// advisories to the release notes before they are rendered
+func (o *options) prepareReleaseNotes(releaseNotes notes.ReleaseNoteList) error {
+	// the post-processing and attribution are applied to all notes, including
+	// partial ones, and after deduplication so that merged notes are covered
+	if o.postProcess {
+		notes.NewPostProcessing(o.githubOrg, o.githubRepo).Apply(releaseNotes)
+	}
+	o.attributionPolicy.Apply(releaseNotes)
+
+	if o.advisories != "" {
+		advisories, err := notes.LoadAdvisories(o.advisories)
+		if err != nil {
+			level.Error(o.logger).Log("msg", "error loading the advisories", "err", err)
+			return err
+		}
+		if err := notes.InjectAdvisories(releaseNotes, advisories); err != nil {
+			level.Error(o.logger).Log("msg", "error adding the advisories to the release notes", "err", err)
+			return err
+		}
+	}
+	return nil
+}
+
 func (o *options) WriteReleaseNotes(releaseNotes notes.ReleaseNoteList) error {
 	level.Info(o.logger).Log("msg", "got the commits, performing rendering")
 
+	if err := o.prepareReleaseNotes(releaseNotes); err != nil {
+		return err
+	}
+
+	registry := o.formatRegistry()
+	for _, name := range o.formats.values {
+		format, err := registry.Lookup(name)
+		if err != nil {
+			level.Error(o.logger).Log("msg", err)
+			return err
+		}
+		if err := o.writeFormat(format, releaseNotes); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// formatRegistry returns the output formats, with the markdown format
+// configured by the options
+func (o *options) formatRegistry() notes.FormatRegistry {
+	registry := notes.NewFormatRegistry()
+	registry.Register(&notes.Format{
+		Name:      "markdown",
+		Extension: ".md",
+		Renderer: notes.RendererFunc(func(w io.Writer, releaseNotes notes.ReleaseNoteList) error {
+			renderOpts := []notes.RenderOption{}
+			if o.sigsYAML != "" {
+				sigs, err := community.Load(context.Background(), o.sigsYAML)
+				if err != nil {
+					return fmt.Errorf("error loading sigs.yaml: %v", err)
+				}
+				renderOpts = append(renderOpts, notes.WithSIGs(sigs))
+			}
+			return notes.MarkdownRenderer(o.taxonomy, renderOpts...).Render(w, releaseNotes)
+		}),
+		DocumentFields: notes.MarkdownDocumentFields,
+	})
+	if o.outputPolicies != nil {
+		o.outputPolicies.Wrap(registry)
+	}
+	return registry
+}
+
+// formatPath returns the path or target of the output in the given format.
+// When several formats are written, the extension of the format replaces the
+// extension of the path.
+func (o *options) formatPath(path string, format *notes.Format) string {
+	if path == "" || len(o.formats.values) < 2 {
+		return path
+	}
+	return strings.TrimSuffix(path, filepath.Ext(path)) + format.Extension
+}
+
+// writeFormat renders the release notes in the format and publishes them
+func (o *options) writeFormat(format *notes.Format, releaseNotes notes.ReleaseNoteList) error {
 	// Open a handle to the file which will contain the release notes output
 	var output *os.File
 	var err error
-	var existingNotes notes.ReleaseNoteList
 
 	if o.output != "" {
-		output, err = os.OpenFile(o.output, os.O_RDWR|os.O_CREATE, 0644)
+		output, err = os.OpenFile(o.formatPath(o.output, format), os.O_RDWR|os.O_CREATE, 0644)
 		if err != nil {
 			level.Error(o.logger).Log("msg", "error opening the supplied output file", "err", err)
 			return err
 		}
 	} else {
-		output, err = ioutil.TempFile("", "release-notes-")
+		output, err = ioutil.TempFile("", "release-notes-*"+format.Extension)
 		if err != nil {
 			level.Error(o.logger).Log("msg", "error creating a temporary file to write the release notes to", "err", err)
 			return err
 		}
 	}
+	defer output.Close()
 
-	// Contextualized release notes can be printed in a variety of formats
-	switch o.format {
-	case "json":
+	// the notes already in a JSON output file are kept, in a copy of the notes
+	// so that the other formats only render the collected notes
+	rendered := releaseNotes
+	if format.Name == "json" {
+		var existingNotes notes.ReleaseNoteList
 		byteValue, _ := ioutil.ReadAll(output)
 
 		if len(byteValue) > 0 {
@@ -180,46 +776,147 @@ func (o *options) WriteReleaseNotes(releaseNotes notes.ReleaseNoteList) error {
 			}
 		}
 
-		if len(existingNotes) > 0 {
-			output.Truncate(0)
-			output.Seek(0, 0)
-
-			for i := 0; i < len(existingNotes); i++ {
-				_, ok := releaseNotes[existingNotes[i].PrNumber]
-				if !ok {
-					releaseNotes[existingNotes[i].PrNumber] = existingNotes[i]
-				}
-			}
+		rendered = make(notes.ReleaseNoteList, len(releaseNotes)+len(existingNotes))
+		for pr, note := range existingNotes {
+			rendered[pr] = note
 		}
+		for pr, note := range releaseNotes {
+			rendered[pr] = note
+		}
+	}
+	output.Truncate(0)
+	output.Seek(0, 0)
 
-		enc := json.NewEncoder(output)
-		enc.SetIndent("", "  ")
-		if err := enc.Encode(releaseNotes); err != nil {
-			level.Error(o.logger).Log("msg", "error encoding JSON output", "err", err)
-			os.Exit(1)
+	if err := format.Renderer.Render(output, rendered); err != nil {
+		level.Error(o.logger).Log("msg", "error rendering the release notes", "format", format.Name, "err", err)
+		return err
+	}
+
+	level.Info(o.logger).Log(
+		"msg", "release notes written to file",
+		"path", output.Name(),
+		"format", format.Name,
+	)
+
+	// the links are the same in all formats, so they are only checked once
+	if o.checkLinks != "" && format.Name == o.formats.values[0] {
+		if err := o.checkReleaseNoteLinks(output.Name()); err != nil {
+			return err
 		}
-	case "markdown":
-		doc, err := notes.CreateDocument(releaseNotes)
-		if err != nil {
-			level.Error(o.logger).Log("msg", "error creating release note document", "err", err)
+	}
+
+	paths, targets := []string{output.Name()}, []string{o.formatPath(o.outputTarget, format)}
+	if o.split && format.Name == "markdown" {
+		if paths, targets, err = o.splitReleaseNotes(output.Name(), targets[0]); err != nil {
 			return err
 		}
+	}
 
-		if err := notes.RenderMarkdown(doc, output); err != nil {
-			level.Error(o.logger).Log("msg", "error rendering release note document to markdown", "err", err)
+	if sink.Type(o.outputType) == sink.TypeFile {
+		return nil
+	}
+	for i := range paths {
+		if err := o.publishReleaseNotes(paths[i], targets[i]); err != nil {
 			return err
 		}
+	}
+	return nil
+}
 
-	default:
-		errString := fmt.Sprintf("%q is an unsupported format", o.format)
-		level.Error(o.logger).Log("msg", errString)
-		return errors.New(errString)
+// splitReleaseNotes splits the rendered release notes at the given path if
+// they are too long for a GitHub release body. The main part replaces the
+// notes and the supplementary parts are written next to them. It returns the
+// paths of all parts and their targets within the output sink, derived from
+// the given target.
+func (o *options) splitReleaseNotes(path, target string) (paths, targets []string, err error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		level.Error(o.logger).Log("msg", "error reading the rendered release notes", "err", err)
+		return nil, nil, err
+	}
+
+	name := filepath.Base(path)
+	if target != "" {
+		name = filepath.Base(target)
+	}
+	splitter := notes.NewSplitter(name)
+	splitter.BaseURL = o.splitBaseURL
+	parts := splitter.Split(string(content))
+
+	for i, part := range parts {
+		partPath := notes.PartName(path, i+1)
+		if err := ioutil.WriteFile(partPath, []byte(part.Content), 0644); err != nil {
+			level.Error(o.logger).Log("msg", "error writing the split release notes", "err", err)
+			return nil, nil, err
+		}
+		paths = append(paths, partPath)
+		targets = append(targets, notes.PartName(target, i+1))
+	}
+	if len(parts) > 1 {
+		level.Info(o.logger).Log("msg", "release notes split", "parts", len(parts), "path", path)
+	}
+	return paths, targets, nil
+}
+
+// checkReleaseNoteLinks checks the URLs in the rendered release notes at the
+// given path and logs the broken ones. It fails if there are broken links
+// and -check-links is set to fail.
+func (o *options) checkReleaseNoteLinks(path string) error {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		level.Error(o.logger).Log("msg", "error reading the rendered release notes", "err", err)
+		return err
+	}
+
+	urls := linkcheck.Extract(content)
+	level.Info(o.logger).Log("msg", "checking links", "count", len(urls))
+
+	broken := linkcheck.Broken(linkcheck.NewChecker().Check(context.Background(), urls))
+	for _, result := range broken {
+		level.Warn(o.logger).Log("msg", "broken link", "url", result.URL, "err", result)
+	}
+
+	if len(broken) > 0 && o.checkLinks == "fail" {
+		err := notes.NewError(notes.ErrNotFound, "%d of %d links in the release notes are broken", len(broken), len(urls))
+		level.Error(o.logger).Log("msg", "link check failed", "err", err)
+		return err
+	}
+	return nil
+}
+
+// publishReleaseNotes writes the rendered release notes at the given path to
+// the target within the configured output sink
+func (o *options) publishReleaseNotes(path, target string) error {
+	ctx := context.Background()
+
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		level.Error(o.logger).Log("msg", "error reading the rendered release notes", "err", err)
+		return err
+	}
+
+	out, err := sink.New(sink.Type(o.outputType), target, o.githubClient(ctx))
+	if err != nil {
+		level.Error(o.logger).Log("msg", "error creating the output sink", "err", err)
+		return err
+	}
+	if gcs, ok := out.(*sink.GCS); ok && o.audit != nil {
+		gcs.Transport = o.audit.Wrap(nil)
+	}
+
+	write := func() error { return out.Write(ctx, content) }
+	if sink.Type(o.outputType) == sink.TypeGCS && o.breaker != nil {
+		write = func() error { return o.breaker.Call("gcs-output", func() error { return out.Write(ctx, content) }) }
+	}
+	if err := write(); err != nil {
+		level.Error(o.logger).Log("msg", "error writing release notes to the output sink", "err", err)
+		return err
 	}
 
 	level.Info(o.logger).Log(
-		"msg", "release notes written to file",
-		"path", output.Name(),
-		"format", o.format,
+		"msg", "release notes published",
+		"type", o.outputType,
+		"location", out.Location(),
 	)
 	return nil
 }
@@ -230,49 +927,380 @@ func parseOptions(args []string, logger log.Logger) (*options, error) {
 
 	// Parse the args.
 	if err := flags.Parse(args); err != nil {
-		return nil, err
+		return opts, &notes.Error{Kind: notes.ErrValidation, Err: err}
 	}
 
-	// The GitHub Token is required.
+	opts.logger = logger
+
+	// Printing or checking the version does not require any other option.
+	if opts.showVersion || opts.versionCheck {
+		return opts, nil
+	}
+
+	// Without a GitHub token only public repositories can be read, within the
+	// unauthenticated rate limit.
 	if opts.githubToken == "" {
-		return nil, errors.New("GitHub token must be set via -github-token or $GITHUB_TOKEN")
+		if opts.serveAddress != "" {
+			return opts, notes.NewError(notes.ErrValidation, "GitHub token must be set via -github-token or $GITHUB_TOKEN to serve the REST API")
+		}
+		if opts.embargoed {
+			return opts, notes.NewError(notes.ErrValidation, "GitHub token must be set via -github-token or $GITHUB_TOKEN to read the security fork")
+		}
+		switch sink.Type(opts.outputType) {
+		case sink.TypeFile, sink.TypeGCS:
+		default:
+			return opts, notes.NewError(notes.ErrValidation, "GitHub token must be set via -github-token or $GITHUB_TOKEN to write to the %q output type", opts.outputType)
+		}
+		if opts.quotaBudget == 0 {
+			opts.quotaBudget = notes.UnauthenticatedRateLimit
+		}
+	}
+	if opts.quotaBudget < 0 {
+		return opts, notes.NewError(notes.ErrValidation, "the quota budget must not be negative")
+	}
+
+	// The commit range is selected per request when serving the REST API.
+	if opts.serveAddress != "" {
+		if opts.apiToken == "" {
+			return opts, notes.NewError(notes.ErrValidation, "The API token must be set via -api-token or $API_TOKEN to serve the REST API")
+		}
+		if opts.batch != "" {
+			return opts, notes.NewError(notes.ErrValidation, "-batch cannot be used to serve the REST API")
+		}
 	}
 
-	// The start SHA is required.
-	if opts.startSHA == "" {
-		return nil, errors.New("The starting commit hash must be set via -start-sha or $START_SHA")
+	// The start SHA is required, unless the PRs are selected by a search query
+	// or the releases are listed in a batch file.
+	if opts.startSHA == "" && opts.searchQuery == "" && opts.batch == "" && opts.serveAddress == "" {
+		return opts, notes.NewError(notes.ErrValidation, "The starting commit hash must be set via -start-sha or $START_SHA")
 	}
 
-	// The end SHA is required.
-	if opts.endSHA == "" {
-		return nil, errors.New("The ending commit hash must be set via -end-sha or $END_SHA")
+	// The end SHA is required, unless the PRs are selected by a search query or
+	// the releases are listed in a batch file.
+	if opts.endSHA == "" && opts.searchQuery == "" && opts.batch == "" && opts.serveAddress == "" {
+		return opts, notes.NewError(notes.ErrValidation, "The ending commit hash must be set via -end-sha or $END_SHA")
 	}
 
-	opts.logger = logger
+	// The search results and the local history are not filtered by author, so
+	// an explicit required author would be silently ignored
+	if opts.searchQuery != "" || opts.repoPath != "" {
+		authorSet := os.Getenv("REQUIRED_AUTHOR") != ""
+		flags.Visit(func(f *flag.Flag) {
+			if f.Name == "requiredAuthor" {
+				authorSet = true
+			}
+		})
+		if authorSet && opts.requiredAuthor != "" {
+			return opts, notes.NewError(notes.ErrValidation, "-requiredAuthor cannot be used with -search-query or -repo-path")
+		}
+	}
+
+	registry := notes.NewFormatRegistry()
+	for _, format := range opts.formats.values {
+		if _, err := registry.Lookup(format); err != nil {
+			return opts, err
+		}
+	}
+
+	switch sink.Type(opts.outputType) {
+	case sink.TypeFile, sink.TypeGitHub, sink.TypePullRequest, sink.TypeGist, sink.TypeGCS:
+	default:
+		return opts, notes.NewError(notes.ErrValidation, "%q is an unsupported output type", opts.outputType)
+	}
+
+	// Embargoed notes must not reach any public location, and the details of
+	// the vulnerabilities are only added once the embargo is lifted.
+	if opts.embargoed {
+		switch sink.Type(opts.outputType) {
+		case sink.TypeFile, sink.TypeGCS:
+		default:
+			return opts, notes.NewError(notes.ErrValidation, "the %q output type is not allowed for embargoed releases, use file or a private gcs bucket", opts.outputType)
+		}
+		if opts.advisories != "" {
+			return opts, notes.NewError(notes.ErrValidation, "advisories can only be added once the embargo is lifted, remove -embargoed to publish them")
+		}
+		if strings.HasPrefix(opts.cacheLocation, "gs://") || strings.HasPrefix(opts.cacheLocation, "redis://") {
+			return opts, notes.NewError(notes.ErrValidation, "the responses of embargoed releases must not be cached in a shared bucket or Redis, use a local directory")
+		}
+	}
+
+	opts.attributionPolicy = &notes.AttributionPolicy{Attribution: notes.Attribution(opts.attribution)}
+	if err := opts.attributionPolicy.Validate(); err != nil {
+		return opts, err
+	}
+	if opts.attributionPolicy.Attribution == notes.AttributeNames {
+		if opts.authorNames == "" {
+			return opts, notes.NewError(notes.ErrValidation, "The display names must be set via -author-names or $AUTHOR_NAMES to attribute by name")
+		}
+		names, err := notes.LoadAuthorNames(opts.authorNames)
+		if err != nil {
+			return opts, err
+		}
+		opts.attributionPolicy.Names = names
+	}
+
+	if opts.split {
+		switch sink.Type(opts.outputType) {
+		case sink.TypePullRequest, sink.TypeGist:
+			return opts, notes.NewError(notes.ErrValidation, "split notes cannot be written to the %q output type", opts.outputType)
+		}
+	}
+
+	if len(opts.formats.values) > 1 {
+		switch sink.Type(opts.outputType) {
+		case sink.TypePullRequest, sink.TypeGist:
+			return opts, notes.NewError(notes.ErrValidation, "several formats cannot be written to the %q output type", opts.outputType)
+		}
+	}
+
+	if opts.rulesPath != "" {
+		rules, err := notes.LoadExtractionRules(opts.rulesPath)
+		if err != nil {
+			return opts, err
+		}
+		opts.extractionRules = rules
+	}
+
+	if opts.policyPath != "" {
+		policies, err := notes.LoadOutputPolicies(opts.policyPath)
+		if err != nil {
+			return opts, err
+		}
+		opts.outputPolicies = policies
+	}
+
+	if opts.taxonomyPath != "" {
+		taxonomy, err := notes.LoadTaxonomy(opts.taxonomyPath)
+		if err != nil {
+			return opts, err
+		}
+		opts.taxonomy = taxonomy
+	} else if opts.byKind {
+		opts.taxonomy = notes.DefaultTaxonomy
+	}
+
+	now := time.Now()
+	since, err := notes.ParseWindowBound(opts.mergedSince, now)
+	if err != nil {
+		return opts, err
+	}
+	until, err := notes.ParseWindowBound(opts.mergedUntil, now)
+	if err != nil {
+		return opts, err
+	}
+	opts.window = notes.TimeWindow{Since: since, Until: until}
+	if err := opts.window.Validate(); err != nil {
+		return opts, err
+	}
+
+	switch opts.checkLinks {
+	case "", "warn", "fail":
+	default:
+		return opts, notes.NewError(notes.ErrValidation, "%q is an unsupported link check mode", opts.checkLinks)
+	}
+
+	switch opts.errorFormat {
+	case "text", "json":
+	default:
+		return opts, notes.NewError(notes.ErrValidation, "%q is an unsupported error format", opts.errorFormat)
+	}
+
+	// For the file sink the target is the path of the output file.
+	if sink.Type(opts.outputType) == sink.TypeFile && opts.outputTarget != "" {
+		opts.output = opts.outputTarget
+	}
 
 	return opts, nil
 }
 
-func run(logger log.Logger, args []string) error {
+func run(logger log.Logger, args []string) (err error) {
 	// Parse the CLI options and enforce required defaults
 	opts, err := parseOptions(args, logger)
+
+	// CI pipelines can ask for a machine readable report of the failure
+	if opts != nil && opts.errorFormat == "json" {
+		defer func() {
+			if err != nil {
+				writeFailureReport(os.Stdout, err, opts.auditLog, opts.dependencyHealth())
+			}
+		}()
+	}
+
 	if err != nil {
 		level.Error(logger).Log("msg", "error parsing options", "err", err)
 		return err
 	}
 
-	// get the release notes
-	releaseNotes, err := opts.GetReleaseNotes()
+	if opts.showVersion {
+		fmt.Println(version.Get())
+		return nil
+	}
+	if opts.versionCheck {
+		return opts.checkVersion()
+	}
+
+	// the health of the dependencies tells whether a failure is caused by an
+	// outage
+	opts.breaker = notes.NewCircuitBreaker()
+	defer func() {
+		for _, d := range opts.breaker.Health() {
+			logLevel := level.Info
+			if d.Failures > 0 {
+				logLevel = level.Warn
+			}
+			logLevel(logger).Log(
+				"msg", "dependency health",
+				"dependency", d.Name,
+				"state", d.State,
+				"calls", d.Calls,
+				"failures", d.Failures,
+				"rejected", d.Rejected,
+				"last_error", d.LastError,
+			)
+		}
+	}()
+
+	if opts.auditLog != "" {
+		opts.audit, err = notes.NewAuditLog(opts.auditLog, opts.auditActor)
+		if err != nil {
+			level.Error(logger).Log("msg", "error creating the audit log", "err", err)
+			return err
+		}
+		defer func() {
+			opts.audit.Close()
+			level.Info(logger).Log("msg", "audit log written", "path", opts.audit.Path())
+		}()
+	}
+
+	if opts.cacheLocation != "" {
+		store, err := notes.OpenStore(context.Background(), opts.cacheLocation)
+		if err != nil {
+			level.Error(logger).Log("msg", "error opening the cache", "err", err)
+			return err
+		}
+		if closer, ok := store.(io.Closer); ok {
+			defer closer.Close()
+		}
+		switch {
+		case strings.HasPrefix(opts.cacheLocation, "gs://"):
+			store = opts.breaker.WrapStore("gcs-cache", store)
+		case strings.HasPrefix(opts.cacheLocation, "redis://"):
+			store = opts.breaker.WrapStore("redis-cache", store)
+		}
+		opts.cache = notes.NewResponseCacheWithStore(store)
+		opts.cache.MaxAge = opts.cacheMaxAge
+		// responses only visible to the token are not shared with others
+		opts.cache.Identity = opts.githubToken
+		defer func() {
+			level.Info(logger).Log("msg", "cache used", "location", opts.cacheLocation, "cached_responses", opts.cache.Hits())
+		}()
+	}
+
+	if opts.quotaBudget > 0 {
+		opts.budget = notes.NewQuotaBudget(opts.quotaBudget)
+		// the PR of every commit is fetched twice, which only spends the
+		// budget once with a cache
+		if opts.cache == nil {
+			opts.cache = notes.NewResponseCache()
+		}
+		opts.budget.Cache = opts.cache
+		if opts.githubToken == "" {
+			level.Warn(logger).Log("msg", "no GitHub token set, running unauthenticated", "quota_budget", opts.quotaBudget)
+		}
+		defer func() {
+			level.Info(logger).Log("msg", "quota budget used", "spent", opts.budget.Spent(), "remaining", opts.budget.Remaining())
+		}()
+	}
+
+	if opts.serveAddress != "" {
+		return opts.serve()
+	}
+
+	if opts.batch != "" {
+		return opts.runBatch()
+	}
+
+	_, err = opts.generate()
+	return err
+}
+
+// dependencyHealth returns the health of the external dependencies of the run
+func (o *options) dependencyHealth() []*notes.DependencyHealth {
+	if o.breaker == nil {
+		return nil
+	}
+	return o.breaker.Health()
+}
+
+// checkVersion prints whether a newer release of the tool is available
+func (o *options) checkVersion() error {
+	ctx := context.Background()
+	client := github.NewClient(nil)
+	if o.githubToken != "" {
+		client = o.githubClient(ctx)
+	}
+
+	check, err := version.CheckLatest(ctx, client, "kubernetes", "release")
 	if err != nil {
+		level.Error(o.logger).Log("msg", "error checking for a newer release", "err", err)
 		return err
 	}
 
-	err = opts.WriteReleaseNotes(releaseNotes)
+	if check.Outdated {
+		fmt.Printf("release-notes %s is outdated, the latest release is %s: %s\n", check.Current, check.Latest, check.URL)
+	} else {
+		fmt.Printf("release-notes %s, the latest release is %s\n", check.Current, check.Latest)
+	}
+	return nil
+}
+
+// generate fetches and writes the release notes and returns the number of
+// notes written
+func (o *options) generate() (int, error) {
+	// get the release notes, which might only be partial if fetching them has
+	// been interrupted
+	releaseNotes, err := o.GetReleaseNotes()
+	interrupted, partial := err.(*notes.InterruptedError)
+	if err != nil && !partial {
+		return 0, err
+	}
+
+	err = o.WriteReleaseNotes(releaseNotes)
 	if err != nil {
-		level.Error(logger).Log("msg", "error writing to file", "err", err)
-		return err
+		level.Error(o.logger).Log("msg", "error writing to file", "err", err)
+		return 0, err
+	}
+
+	// the partial notes have been written, but the run still failed
+	if partial {
+		return len(releaseNotes), interrupted
 	}
+	return len(releaseNotes), nil
+}
+
+// listFlag is a flag which can be repeated or set to a comma separated list.
+// The first use of the flag replaces the default.
+type listFlag struct {
+	values []string
+	set    bool
+}
 
+func newListFlag(value string) listFlag {
+	return listFlag{values: flagutil.SplitList(value)}
+}
+
+// String implements flag.Value.
+func (l *listFlag) String() string {
+	return strings.Join(l.values, ",")
+}
+
+// Set implements flag.Value.
+func (l *listFlag) Set(value string) error {
+	if !l.set {
+		l.values = nil
+		l.set = true
+	}
+	l.values = append(l.values, flagutil.SplitList(value)...)
 	return nil
 }
 
@@ -285,6 +1313,6 @@ func main() {
 	)
 
 	if err := run(logger, os.Args[1:]); err != nil {
-		os.Exit(-1)
+		os.Exit(exitCode(err))
 	}
 }
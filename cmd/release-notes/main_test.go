@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/release/pkg/notes"
+)
+
+func TestGetReleaseNotesPartial(t *testing.T) {
+	var github *httptest.Server
+	mux := http.NewServeMux()
+	mux.HandleFunc("/search/issues", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"total_count": 3, "items": [
+			{"number": 1, "repository_url": "%[1]s/repos/o/r", "pull_request": {}},
+			{"number": 2, "repository_url": "%[1]s/repos/o/r", "pull_request": {}},
+			{"number": 3, "repository_url": "%[1]s/repos/o/r", "pull_request": {}}
+		]}`, github.URL)
+	})
+	for _, pr := range []int{1, 2, 3} {
+		pr := pr
+		mux.HandleFunc(fmt.Sprintf("/repos/o/r/pulls/%d", pr), func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintf(w, `{
+				"number": %d,
+				"body": "`+"```release-note\\nAdds a flag to kubectl.\\n```"+`",
+				"merge_commit_sha": "sha%[1]d",
+				"user": {"login": "alice"},
+				"base": {"repo": {"url": "%s/repos/o/r"}}
+			}`, pr, github.URL)
+		})
+	}
+	github = httptest.NewServer(mux)
+	defer github.Close()
+
+	// the budget covers the search and two of the three PRs
+	o := &options{
+		githubURL:    github.URL + "/",
+		searchQuery:  "repo:o/r is:pr",
+		budget:       notes.NewQuotaBudget(3),
+		dedup:        true,
+		lint:         true,
+		lintMinWords: 10,
+		taxonomy:     notes.DefaultTaxonomy,
+		logger:       log.NewNopLogger(),
+	}
+	releaseNotes, err := o.GetReleaseNotes()
+	interrupted, ok := err.(*notes.InterruptedError)
+	require.True(t, ok, "%v", err)
+	require.Equal(t, notes.ErrQuotaExhausted, interrupted.Err)
+
+	// the partial notes are deduplicated, linted and categorized
+	require.Len(t, releaseNotes, 1)
+	require.Equal(t, []string{"note has 5 words, fewer than 10"}, releaseNotes[1].Warnings)
+	require.Equal(t, "Feature", releaseNotes[1].Category)
+}
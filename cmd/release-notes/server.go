@@ -0,0 +1,247 @@
+package main
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log/level"
+
+	"k8s.io/release/pkg/notes"
+)
+
+// collectRequest selects the release notes to collect, overriding the
+// options the server has been started with
+type collectRequest struct {
+	Branch         string `json:"branch"`
+	StartSHA       string `json:"start_sha"`
+	EndSHA         string `json:"end_sha"`
+	SearchQuery    string `json:"search_query"`
+	ReleaseVersion string `json:"release_version"`
+}
+
+// server exposes the collection, rendering and validation of release notes
+// over a REST API, so that bots and web UIs can drive them without running
+// the tool. All endpoints but /healthz require the API token as bearer
+// token:
+//
+//	POST /v1/collect   collects the notes selected by a collectRequest,
+//	                   keeps them as draft and returns them as JSON
+//	GET  /v1/draft     renders the draft in the format given by ?format=
+//	POST /v1/render    renders the posted notes in the format given by ?format=
+//	POST /v1/validate  returns the problems of the posted notes
+type server struct {
+	opts *options
+
+	// collecting serializes the collections, which share the rate limit
+	collecting sync.Mutex
+
+	mu    sync.Mutex
+	draft notes.ReleaseNoteList
+}
+
+// maxRequestBytes limits the size of the request bodies, i.e. of the posted
+// release notes
+const maxRequestBytes = 32 << 20
+
+// serve runs the REST API on the address given by -serve
+func (o *options) serve() error {
+	// the responses of /v1/collect are written once the notes are collected,
+	// which takes as long as -timeout allows
+	writeTimeout := time.Hour
+	if o.timeout > 0 {
+		writeTimeout = o.timeout + time.Minute
+	}
+	srv := &http.Server{
+		Addr:         o.serveAddress,
+		Handler:      (&server{opts: o}).handler(),
+		ReadTimeout:  time.Minute,
+		WriteTimeout: writeTimeout,
+		IdleTimeout:  2 * time.Minute,
+	}
+
+	level.Info(o.logger).Log("msg", "serving the release notes API", "address", o.serveAddress)
+	return srv.ListenAndServe()
+}
+
+// handler routes the requests to the endpoints of the API
+func (s *server) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok\n"))
+	})
+	mux.Handle("/v1/collect", s.authorized(http.MethodPost, s.collect))
+	mux.Handle("/v1/draft", s.authorized(http.MethodGet, s.getDraft))
+	mux.Handle("/v1/render", s.authorized(http.MethodPost, s.render))
+	mux.Handle("/v1/validate", s.authorized(http.MethodPost, s.validate))
+	return mux
+}
+
+// authorized checks the method and the API token of the requests before
+// passing them to the handler, and limits the size of their bodies
+func (s *server) authorized(method string, handler http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(token), []byte(s.opts.apiToken)) != 1 {
+			http.Error(w, "invalid API token", http.StatusUnauthorized)
+			return
+		}
+		if r.Method != method {
+			w.Header().Set("Allow", method)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, maxRequestBytes)
+		handler(w, r)
+	})
+}
+
+func (s *server) collect(w http.ResponseWriter, r *http.Request) {
+	req := &collectRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		s.fail(w, notes.NewError(notes.ErrParse, "error parsing the request: %v", err))
+		return
+	}
+	if req.SearchQuery == "" && (req.StartSHA == "" || req.EndSHA == "") {
+		s.fail(w, notes.NewError(notes.ErrValidation, "either start_sha and end_sha or search_query must be set"))
+		return
+	}
+
+	opts := *s.opts
+	opts.startSHA = req.StartSHA
+	opts.endSHA = req.EndSHA
+	opts.searchQuery = req.SearchQuery
+	if req.Branch != "" {
+		opts.branch = req.Branch
+	}
+	if req.ReleaseVersion != "" {
+		opts.releaseVersion = req.ReleaseVersion
+	}
+
+	s.collecting.Lock()
+	releaseNotes, err := opts.GetReleaseNotes()
+	if err == nil {
+		err = opts.prepareReleaseNotes(releaseNotes)
+	}
+	s.collecting.Unlock()
+	if err != nil {
+		s.fail(w, err)
+		return
+	}
+
+	s.mu.Lock()
+	s.draft = releaseNotes
+	s.mu.Unlock()
+	s.respond(w, "json", releaseNotes)
+}
+
+func (s *server) getDraft(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	draft := s.draft
+	s.mu.Unlock()
+	if draft == nil {
+		s.fail(w, notes.NewError(notes.ErrNotFound, "no release notes have been collected yet"))
+		return
+	}
+	s.respond(w, r.URL.Query().Get("format"), draft)
+}
+
+func (s *server) render(w http.ResponseWriter, r *http.Request) {
+	releaseNotes := notes.ReleaseNoteList{}
+	if err := json.NewDecoder(r.Body).Decode(&releaseNotes); err != nil {
+		s.fail(w, notes.NewError(notes.ErrParse, "error parsing the release notes: %v", err))
+		return
+	}
+	s.respond(w, r.URL.Query().Get("format"), releaseNotes)
+}
+
+func (s *server) validate(w http.ResponseWriter, r *http.Request) {
+	releaseNotes := notes.ReleaseNoteList{}
+	if err := json.NewDecoder(r.Body).Decode(&releaseNotes); err != nil {
+		s.fail(w, notes.NewError(notes.ErrParse, "error parsing the release notes: %v", err))
+		return
+	}
+
+	linter := notes.NewLinter()
+	linter.MinWords = s.opts.lintMinWords
+	linter.MaxWords = s.opts.lintMaxWords
+	linter.Lint(releaseNotes)
+
+	prs := make([]int, 0, len(releaseNotes))
+	for pr := range releaseNotes {
+		prs = append(prs, pr)
+	}
+	sort.Ints(prs)
+
+	problems := []*notes.DigestProblem{}
+	for _, pr := range prs {
+		problems = append(problems, notes.NoteProblems(releaseNotes[pr])...)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(problems)
+}
+
+// respond renders the release notes in the format, which defaults to the
+// first format of the options
+func (s *server) respond(w http.ResponseWriter, format string, releaseNotes notes.ReleaseNoteList) {
+	if format == "" {
+		format = s.opts.formats.values[0]
+	}
+	f, err := s.opts.formatRegistry().Lookup(format)
+	if err != nil {
+		s.fail(w, err)
+		return
+	}
+
+	var b bytes.Buffer
+	if err := f.Renderer.Render(&b, releaseNotes); err != nil {
+		s.fail(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", contentTypes[f.Name])
+	w.Write(b.Bytes())
+}
+
+// contentTypes are the media types of the formats
+var contentTypes = map[string]string{
+	"markdown": "text/markdown; charset=utf-8",
+	"json":     "application/json",
+	"yaml":     "application/yaml",
+	"csv":      "text/csv; charset=utf-8",
+	"html":     "text/html; charset=utf-8",
+	"index":    "application/json",
+}
+
+// failureStatuses maps the failure categories to HTTP statuses
+var failureStatuses = map[string]int{
+	"validation":   http.StatusBadRequest,
+	"not_found":    http.StatusNotFound,
+	"rate_limited": http.StatusTooManyRequests,
+	"parse":        http.StatusBadRequest,
+	"timeout":      http.StatusGatewayTimeout,
+	"unavailable":  http.StatusServiceUnavailable,
+}
+
+// fail responds with the failure report of the error
+func (s *server) fail(w http.ResponseWriter, err error) {
+	category, code := categorize(err)
+	status, ok := failureStatuses[category]
+	if !ok {
+		status = http.StatusInternalServerError
+	}
+	level.Error(s.opts.logger).Log("msg", "API request failed", "err", err)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(&failureReport{
+		Error:        err.Error(),
+		Category:     category,
+		ExitCode:     code,
+		Dependencies: s.opts.dependencyHealth(),
+	})
+}
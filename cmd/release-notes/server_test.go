@@ -0,0 +1,167 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/release/pkg/notes"
+)
+
+// newTestServer runs the API against a fake GitHub API, which answers the
+// search for PRs with PR 1
+func newTestServer(t *testing.T) (*httptest.Server, func()) {
+	var github *httptest.Server
+	mux := http.NewServeMux()
+	mux.HandleFunc("/search/issues", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("q") == "repo:o/r is:pr missing" {
+			http.Error(w, `{"message": "Not Found"}`, http.StatusNotFound)
+			return
+		}
+		fmt.Fprintf(w, `{"total_count": 1, "items": [
+			{"number": 1, "repository_url": "%s/repos/o/r", "pull_request": {}}
+		]}`, github.URL)
+	})
+	mux.HandleFunc("/repos/o/r/pulls/1", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{
+			"number": 1,
+			"body": "`+"```release-note\\nAdds a flag to kubectl.\\n```"+`",
+			"merge_commit_sha": "sha1",
+			"user": {"login": "alice"},
+			"labels": [{"name": "sig/cli"}],
+			"base": {"repo": {"url": "%s/repos/o/r"}}
+		}`, github.URL)
+	})
+	github = httptest.NewServer(mux)
+
+	opts := &options{
+		githubURL:         github.URL + "/",
+		apiToken:          "secret",
+		formats:           listFlag{values: []string{"markdown"}},
+		attributionPolicy: &notes.AttributionPolicy{Attribution: notes.AttributeHandles},
+		logger:            log.NewNopLogger(),
+	}
+	api := httptest.NewServer((&server{opts: opts}).handler())
+	return api, func() {
+		api.Close()
+		github.Close()
+	}
+}
+
+func request(t *testing.T, method, url, token, body string) (*http.Response, string) {
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	require.NoError(t, err)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	content, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp, string(content)
+}
+
+func TestServerAuthorization(t *testing.T) {
+	api, stop := newTestServer(t)
+	defer stop()
+
+	resp, body := request(t, http.MethodGet, api.URL+"/healthz", "", "")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "ok\n", body)
+
+	resp, _ = request(t, http.MethodGet, api.URL+"/v1/draft", "", "")
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	resp, _ = request(t, http.MethodGet, api.URL+"/v1/draft", "wrong", "")
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	resp, _ = request(t, http.MethodPost, api.URL+"/v1/draft", "secret", "")
+	require.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+	require.Equal(t, http.MethodGet, resp.Header.Get("Allow"))
+	resp, _ = request(t, http.MethodGet, api.URL+"/v1/collect", "secret", "")
+	require.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+}
+
+func TestServerCollectAndDraft(t *testing.T) {
+	api, stop := newTestServer(t)
+	defer stop()
+
+	// nothing has been collected yet
+	resp, body := request(t, http.MethodGet, api.URL+"/v1/draft", "secret", "")
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+	report := &failureReport{}
+	require.NoError(t, json.Unmarshal([]byte(body), report))
+	require.Equal(t, "not_found", report.Category)
+
+	resp, body = request(t, http.MethodPost, api.URL+"/v1/collect", "secret", `{"search_query": "repo:o/r is:pr"}`)
+	require.Equal(t, http.StatusOK, resp.StatusCode, body)
+	require.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+	collected := notes.ReleaseNoteList{}
+	require.NoError(t, json.Unmarshal([]byte(body), &collected))
+	require.Len(t, collected, 1)
+	require.Equal(t, "Adds a flag to kubectl.", collected[1].Text)
+
+	// the draft is rendered in the first format by default
+	resp, body = request(t, http.MethodGet, api.URL+"/v1/draft", "secret", "")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "text/markdown; charset=utf-8", resp.Header.Get("Content-Type"))
+	require.Contains(t, body, "Adds a flag to kubectl.")
+
+	resp, body = request(t, http.MethodGet, api.URL+"/v1/draft?format=index", "secret", "")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+	require.Contains(t, body, "kubectl")
+}
+
+func TestServerFailures(t *testing.T) {
+	api, stop := newTestServer(t)
+	defer stop()
+
+	for _, tc := range []struct {
+		path     string
+		body     string
+		status   int
+		category string
+	}{
+		{"/v1/collect", `{"branch": "master"}`, http.StatusBadRequest, "validation"},
+		{"/v1/collect", `{`, http.StatusBadRequest, "parse"},
+		{"/v1/collect", `{"search_query": "repo:o/r is:pr missing"}`, http.StatusNotFound, "not_found"},
+		{"/v1/render?format=xml", `{}`, http.StatusBadRequest, "validation"},
+		{"/v1/render", `[`, http.StatusBadRequest, "parse"},
+		{"/v1/validate", `[`, http.StatusBadRequest, "parse"},
+		{"/v1/render", `{"1": {"text": "` + strings.Repeat("x", maxRequestBytes) + `"}}`, http.StatusBadRequest, "parse"},
+	} {
+		resp, body := request(t, http.MethodPost, api.URL+tc.path, "secret", tc.body)
+		require.Equal(t, tc.status, resp.StatusCode, tc.path)
+		report := &failureReport{}
+		require.NoError(t, json.Unmarshal([]byte(body), report))
+		require.Equal(t, tc.category, report.Category, tc.path)
+	}
+}
+
+func TestServerRenderAndValidate(t *testing.T) {
+	api, stop := newTestServer(t)
+	defer stop()
+
+	posted := `{"1": {"text": "fixed it", "markdown": "fixed it", "pr_number": 1, "sigs": ["node"]}}`
+	resp, body := request(t, http.MethodPost, api.URL+"/v1/render?format=csv", "secret", posted)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "text/csv; charset=utf-8", resp.Header.Get("Content-Type"))
+	require.Contains(t, body, "1,,,node,,,,false,,fixed it")
+
+	resp, body = request(t, http.MethodPost, api.URL+"/v1/validate", "secret", posted)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	problems := []*notes.DigestProblem{}
+	require.NoError(t, json.Unmarshal([]byte(body), &problems))
+	require.NotEmpty(t, problems)
+	for _, problem := range problems {
+		require.Equal(t, 1, problem.PrNumber)
+	}
+}
@@ -0,0 +1,333 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/google/go-github/github"
+	"github.com/kolide/kit/env"
+	"golang.org/x/oauth2"
+
+	"k8s.io/release/pkg/community"
+	"k8s.io/release/pkg/flagutil"
+	"k8s.io/release/pkg/notes"
+)
+
+type options struct {
+	githubToken    string
+	releaseVersion string
+	releaseNotes   string
+	sigsYAML       string
+	sigs           string
+	outputDir      string
+	issueOrg       string
+	issueRepo      string
+	slackWebhook   string
+	nomock         bool
+	auditLog       string
+	auditActor     string
+}
+
+func (o *options) BindFlags() *flag.FlagSet {
+	flags := flag.NewFlagSet("sig-digest", flag.ExitOnError)
+
+	// githubToken contains a personal GitHub access token. It is only needed to
+	// file the digests as issues.
+	flags.StringVar(
+		&o.githubToken,
+		"github-token",
+		env.String("GITHUB_TOKEN", ""),
+		"A personal GitHub access token (required with -nomock)",
+	)
+
+	// releaseVersion is the version the digests are written for.
+	flags.StringVar(
+		&o.releaseVersion,
+		"release-version",
+		env.String("RELEASE_VERSION", ""),
+		"The version of the release, e.g. v1.16.0 (required)",
+	)
+
+	// releaseNotes is the path of the JSON release notes the digests are
+	// created from.
+	flags.StringVar(
+		&o.releaseNotes,
+		"release-notes",
+		env.String("RELEASE_NOTES", ""),
+		"The release notes of the release as written by release-notes -format json (required)",
+	)
+
+	// sigsYAML is the location of sigs.yaml, which names the SIGs and their
+	// leads and Slack channels.
+	flags.StringVar(
+		&o.sigsYAML,
+		"sigs-yaml",
+		env.String("SIGS_YAML", community.SigsURL),
+		"The path or URL of sigs.yaml, for the names, leads and Slack channels of the SIGs. Set to empty string to skip",
+	)
+
+	// sigs restricts the digests to the listed SIGs.
+	flags.StringVar(
+		&o.sigs,
+		"sigs",
+		env.String("SIGS", ""),
+		"Comma separated list of the SIGs to write digests for, e.g. node,cli. Defaults to all SIGs owning any of the notes",
+	)
+
+	// outputDir is the directory the digests are written to.
+	flags.StringVar(
+		&o.outputDir,
+		"output-dir",
+		env.String("OUTPUT_DIR", ""),
+		"The directory the digests are written to as sig-<label>.md",
+	)
+
+	// issueOrg contains the name of the github organization of the repo the
+	// digests are filed in.
+	flags.StringVar(
+		&o.issueOrg,
+		"issue-org",
+		env.String("ISSUE_ORG", "kubernetes"),
+		"Name of the github organization of the repository the digests are filed in",
+	)
+
+	// issueRepo contains the name of the repository the digests are filed in.
+	flags.StringVar(
+		&o.issueRepo,
+		"issue-repo",
+		env.String("ISSUE_REPO", "sig-release"),
+		"Name of the repository the digests are filed in",
+	)
+
+	// slackWebhook is the URL of the Slack incoming webhook the filed digests
+	// are announced to.
+	flags.StringVar(
+		&o.slackWebhook,
+		"slack-webhook",
+		env.String("SLACK_WEBHOOK", ""),
+		"The URL of a Slack incoming webhook every digest is announced to with -nomock, in the Slack channel of its SIG if the webhook allows to choose the channel",
+	)
+
+	// nomock files and announces the digests instead of only writing them.
+	flags.BoolVar(
+		&o.nomock,
+		"nomock",
+		env.Bool("NOMOCK", false),
+		"File every digest as an issue, or update the open issue of the digest, and announce it to -slack-webhook, instead of only writing or printing them",
+	)
+
+	// auditLog is the path of the log of the API calls of the run.
+	flags.StringVar(
+		&o.auditLog,
+		"audit-log",
+		env.String("AUDIT_LOG", ""),
+		"The path of a JSON lines file recording every GitHub API call and Slack announcement of the run",
+	)
+
+	// auditActor is who the API calls are recorded as made by.
+	flags.StringVar(
+		&o.auditActor,
+		"audit-actor",
+		env.String("AUDIT_ACTOR", env.String("USER", "")),
+		"The actor recorded in the audit log. Defaults to $USER",
+	)
+
+	return flags
+}
+
+func (o *options) validate() error {
+	if o.releaseVersion == "" {
+		return errors.New("The release version must be set via -release-version or $RELEASE_VERSION")
+	}
+	if o.releaseNotes == "" {
+		return errors.New("The release notes must be set via -release-notes or $RELEASE_NOTES")
+	}
+	if o.nomock && o.githubToken == "" {
+		return errors.New("GitHub token must be set via -github-token or $GITHUB_TOKEN to file the digests")
+	}
+	return nil
+}
+
+func (o *options) loadReleaseNotes() (notes.ReleaseNoteList, error) {
+	content, err := ioutil.ReadFile(o.releaseNotes)
+	if err != nil {
+		return nil, err
+	}
+	releaseNotes := notes.ReleaseNoteList{}
+	if err := json.Unmarshal(content, &releaseNotes); err != nil {
+		return nil, fmt.Errorf("error parsing release notes %s: %v", o.releaseNotes, err)
+	}
+	return releaseNotes, nil
+}
+
+// findIssue returns the open issue with the given title, or nil if there is
+// none yet
+func (o *options) findIssue(ctx context.Context, client *github.Client, title string) (*github.Issue, error) {
+	opts := &github.IssueListByRepoOptions{
+		State:       "open",
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+	for {
+		issues, resp, err := client.Issues.ListByRepo(ctx, o.issueOrg, o.issueRepo, opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, issue := range issues {
+			if issue.GetTitle() == title && !issue.IsPullRequest() {
+				return issue, nil
+			}
+		}
+		if resp.NextPage == 0 {
+			return nil, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// fileIssue creates or updates the issue of a digest
+func (o *options) fileIssue(ctx context.Context, client *github.Client, title, body string) (*github.Issue, error) {
+	issue, err := o.findIssue(ctx, client, title)
+	if err != nil {
+		return nil, err
+	}
+	request := &github.IssueRequest{Title: &title, Body: &body}
+	if issue == nil {
+		issue, _, err = client.Issues.Create(ctx, o.issueOrg, o.issueRepo, request)
+	} else {
+		issue, _, err = client.Issues.Edit(ctx, o.issueOrg, o.issueRepo, issue.GetNumber(), request)
+	}
+	return issue, err
+}
+
+func run(logger log.Logger, args []string) error {
+	opts := &options{}
+	if err := opts.BindFlags().Parse(args); err != nil {
+		return err
+	}
+	if err := opts.validate(); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	releaseNotes, err := opts.loadReleaseNotes()
+	if err != nil {
+		return err
+	}
+	// the wording problems are listed in the digests
+	notes.NewLinter().Lint(releaseNotes)
+
+	renderOpts := []notes.RenderOption{}
+	var sigs *community.Groups
+	if opts.sigsYAML != "" {
+		sigs, err = community.Load(ctx, opts.sigsYAML)
+		if err != nil {
+			return err
+		}
+		renderOpts = append(renderOpts, notes.WithSIGs(sigs))
+	}
+
+	var audit *notes.AuditLog
+	if opts.auditLog != "" {
+		audit, err = notes.NewAuditLog(opts.auditLog, opts.auditActor)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			audit.Close()
+			level.Info(logger).Log("msg", "audit log written", "path", audit.Path())
+		}()
+	}
+
+	var client *github.Client
+	var slackClient *http.Client
+	if opts.nomock {
+		httpClient := oauth2.NewClient(ctx, oauth2.StaticTokenSource(
+			&oauth2.Token{AccessToken: opts.githubToken},
+		))
+		if audit != nil {
+			httpClient.Transport = audit.Wrap(httpClient.Transport)
+			slackClient = &http.Client{Transport: audit.Wrap(nil)}
+		}
+		client = github.NewClient(httpClient)
+	}
+	if opts.outputDir != "" {
+		if err := os.MkdirAll(opts.outputDir, 0755); err != nil {
+			return err
+		}
+	}
+
+	selected := map[string]bool{}
+	for _, sig := range flagutil.SplitList(opts.sigs) {
+		selected[sig] = true
+	}
+
+	for _, digest := range notes.CreateDigests(releaseNotes) {
+		if len(selected) > 0 && !selected[digest.SIG] {
+			continue
+		}
+
+		var b bytes.Buffer
+		if err := notes.RenderDigest(digest, opts.releaseVersion, &b, renderOpts...); err != nil {
+			return err
+		}
+
+		if opts.outputDir != "" {
+			path := filepath.Join(opts.outputDir, "sig-"+digest.SIG+".md")
+			if err := ioutil.WriteFile(path, b.Bytes(), 0644); err != nil {
+				return err
+			}
+			level.Info(logger).Log("msg", "digest written", "sig", digest.SIG, "path", path)
+		}
+
+		if opts.nomock {
+			title := notes.DigestTitle(digest.SIG, opts.releaseVersion)
+			issue, err := opts.fileIssue(ctx, client, title, b.String())
+			if err != nil {
+				return err
+			}
+			level.Info(logger).Log("msg", "digest filed", "sig", digest.SIG, "issue", issue.GetHTMLURL())
+
+			if opts.slackWebhook != "" {
+				channel := ""
+				if sigs != nil {
+					if group, ok := sigs.SIG(digest.SIG); ok {
+						channel = group.SlackChannel()
+					}
+				}
+				text := fmt.Sprintf("%s: %s", title, issue.GetHTMLURL())
+				if err := community.PostSlack(ctx, slackClient, opts.slackWebhook, channel, text); err != nil {
+					return err
+				}
+				level.Info(logger).Log("msg", "digest announced", "sig", digest.SIG, "channel", channel)
+			}
+		}
+
+		if opts.outputDir == "" && !opts.nomock {
+			os.Stdout.Write(b.Bytes())
+			os.Stdout.WriteString("\n")
+		}
+	}
+	return nil
+}
+
+func main() {
+	logger := level.NewInjector(
+		log.NewLogfmtLogger(log.NewSyncWriter(os.Stderr)),
+		level.DebugValue(),
+	)
+
+	if err := run(logger, os.Args[1:]); err != nil {
+		level.Error(logger).Log("msg", "error generating SIG digests", "err", err)
+		os.Exit(1)
+	}
+}
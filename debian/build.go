@@ -136,7 +136,7 @@ func (c cfg) run() error {
 			return nil
 		}
 		if f.IsDir() {
-			log.Printf(dstfile)
+			log.Print(dstfile)
 			return os.Mkdir(dstfile, f.Mode())
 		}
 		t, err := template.
@@ -0,0 +1,219 @@
+// Copyright 2019 The Kubernetes Authors All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package checklist renders the release cut checklist as a GitHub issue and
+// ticks its items by inspecting the actual state of the release.
+package checklist
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/blang/semver"
+	"github.com/google/go-github/github"
+	"github.com/pkg/errors"
+)
+
+// Item is a step of the release cut.
+type Item struct {
+	// ID identifies the item within the issue body, so that the state of
+	// items ticked by hand survives re-rendering
+	ID string
+
+	Title string
+
+	// Check inspects whether the step is done. Items without a check have to
+	// be ticked by hand.
+	Check func(ctx context.Context) (bool, error)
+}
+
+// Status maps item IDs to whether the item is done.
+type Status map[string]bool
+
+// Checks inspects the state of a release. The URLs default to the production
+// locations and can be overridden for testing.
+type Checks struct {
+	GitHub *github.Client
+	HTTP   *http.Client
+
+	// Org and Repo are the repository the release is cut from
+	Org  string
+	Repo string
+
+	// BucketURL is the URL of the bucket the builds are staged in
+	BucketURL string
+
+	// RegistryURL is the URL of the registry the images are promoted to
+	RegistryURL string
+}
+
+// NewChecks creates the checks against the production locations.
+func NewChecks(client *github.Client) *Checks {
+	return &Checks{
+		GitHub:      client,
+		HTTP:        http.DefaultClient,
+		Org:         "kubernetes",
+		Repo:        "kubernetes",
+		BucketURL:   "https://storage.googleapis.com/kubernetes-release",
+		RegistryURL: "https://k8s.gcr.io",
+	}
+}
+
+// Items returns the checklist of the release cut of the given version, e.g.
+// v1.15.0.
+func (c *Checks) Items(version string) ([]*Item, error) {
+	v, err := semver.Parse(strings.TrimPrefix(version, "v"))
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid release version %s", version)
+	}
+	branch := fmt.Sprintf("release-%d.%d", v.Major, v.Minor)
+
+	return []*Item{
+		{
+			ID:    "branch-created",
+			Title: fmt.Sprintf("Branch `%s` created", branch),
+			Check: func(ctx context.Context) (bool, error) { return c.refExists(ctx, "heads/"+branch) },
+		},
+		{
+			ID:    "tags-pushed",
+			Title: fmt.Sprintf("Tag `%s` pushed", version),
+			Check: func(ctx context.Context) (bool, error) { return c.refExists(ctx, "tags/"+version) },
+		},
+		{
+			ID:    "builds-staged",
+			Title: "Builds staged",
+			Check: func(ctx context.Context) (bool, error) {
+				return c.urlExists(ctx, fmt.Sprintf("%s/release/%s/kubernetes.tar.gz", c.BucketURL, version), "")
+			},
+		},
+		{
+			ID:    "images-promoted",
+			Title: "Images promoted",
+			Check: func(ctx context.Context) (bool, error) {
+				return c.urlExists(
+					ctx,
+					fmt.Sprintf("%s/v2/kube-apiserver/manifests/%s", c.RegistryURL, version),
+					"application/vnd.docker.distribution.manifest.v2+json",
+				)
+			},
+		},
+		{
+			ID:    "notes-published",
+			Title: "Release notes published",
+			Check: func(ctx context.Context) (bool, error) { return c.notesPublished(ctx, version) },
+		},
+		{
+			ID:    "announcement-sent",
+			Title: "Announcement sent",
+		},
+	}, nil
+}
+
+// refExists checks whether the git reference exists in the repository
+func (c *Checks) refExists(ctx context.Context, ref string) (bool, error) {
+	_, resp, err := c.GitHub.Git.GetRef(ctx, c.Org, c.Repo, ref)
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, errors.Wrapf(err, "error getting %s", ref)
+	}
+	return true, nil
+}
+
+// urlExists checks whether a HEAD request of the URL succeeds
+func (c *Checks) urlExists(ctx context.Context, url, accept string) (bool, error) {
+	req, err := http.NewRequest(http.MethodHead, url, nil)
+	if err != nil {
+		return false, err
+	}
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	resp, err := c.HTTP.Do(req.WithContext(ctx))
+	if err != nil {
+		return false, errors.Wrapf(err, "error checking %s", url)
+	}
+	resp.Body.Close()
+	return resp.StatusCode == http.StatusOK, nil
+}
+
+// notesPublished checks whether the GitHub release of the version has a
+// description
+func (c *Checks) notesPublished(ctx context.Context, version string) (bool, error) {
+	release, resp, err := c.GitHub.Repositories.GetReleaseByTag(ctx, c.Org, c.Repo, version)
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, errors.Wrapf(err, "error getting the release %s", version)
+	}
+	return strings.TrimSpace(release.GetBody()) != "", nil
+}
+
+// Refresh runs the checks of all items and ticks the ones which are done.
+// Items are never unticked, so that items ticked by hand are kept.
+func Refresh(ctx context.Context, items []*Item, status Status) error {
+	for _, item := range items {
+		if item.Check == nil || status[item.ID] {
+			continue
+		}
+		done, err := item.Check(ctx)
+		if err != nil {
+			return errors.Wrapf(err, "error checking %q", item.Title)
+		}
+		status[item.ID] = done
+	}
+	return nil
+}
+
+// itemRe matches a rendered item, capturing whether it is ticked and its ID
+var itemRe = regexp.MustCompile(`(?m)^- \[([ xX])\] .*<!-- (\S+) -->$`)
+
+// Render renders the checklist as markdown, which is used as the body of the
+// tracking issue.
+func Render(version string, items []*Item, status Status) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Release cut checklist for %s.\n\n", version)
+	b.WriteString("Items with an automated check are ticked when the check passes, the others have to be ticked by hand.\n\n")
+	for _, item := range items {
+		box := " "
+		if status[item.ID] {
+			box = "x"
+		}
+		manual := ""
+		if item.Check == nil {
+			manual = " (manual)"
+		}
+		fmt.Fprintf(&b, "- [%s] %s%s <!-- %s -->\n", box, item.Title, manual, item.ID)
+	}
+	return b.String()
+}
+
+// Parse returns the status of the items of a rendered checklist.
+func Parse(body string) Status {
+	status := Status{}
+	for _, match := range itemRe.FindAllStringSubmatch(strings.Replace(body, "\r\n", "\n", -1), -1) {
+		status[match[2]] = match[1] != " "
+	}
+	return status
+}
+
+// Title returns the title of the tracking issue of the version.
+func Title(version string) string {
+	return "Release cut checklist: " + version
+}
@@ -0,0 +1,73 @@
+package checklist
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/google/go-github/github"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderAndParse(t *testing.T) {
+	items := []*Item{
+		{ID: "a", Title: "First", Check: func(context.Context) (bool, error) { return true, nil }},
+		{ID: "b", Title: "Second"},
+	}
+
+	body := Render("v1.15.0", items, Status{"a": true})
+	require.Contains(t, body, "- [x] First <!-- a -->\n")
+	require.Contains(t, body, "- [ ] Second (manual) <!-- b -->\n")
+	require.Equal(t, Status{"a": true, "b": false}, Parse(body))
+
+	// boxes ticked by hand in the GitHub UI
+	require.Equal(t, Status{"a": false, "b": true}, Parse("- [ ] First <!-- a -->\r\n- [x] Second (manual) <!-- b -->\r\n"))
+}
+
+func TestRefresh(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/kubernetes/kubernetes/git/refs/heads/release-1.15", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"ref": "refs/heads/release-1.15"}`)
+	})
+	mux.HandleFunc("/repos/kubernetes/kubernetes/git/refs/tags/v1.15.0", func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	})
+	mux.HandleFunc("/repos/kubernetes/kubernetes/releases/tags/v1.15.0", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"body": ""}`)
+	})
+	mux.HandleFunc("/bucket/release/v1.15.0/kubernetes.tar.gz", func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodHead, r.Method)
+	})
+	mux.HandleFunc("/registry/v2/kube-apiserver/manifests/v1.15.0", func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+	checks := NewChecks(client)
+	checks.BucketURL = server.URL + "/bucket"
+	checks.RegistryURL = server.URL + "/registry"
+
+	items, err := checks.Items("v1.15.0")
+	require.NoError(t, err)
+
+	// the announcement has been ticked by hand
+	status := Status{"announcement-sent": true}
+	require.NoError(t, Refresh(context.Background(), items, status))
+	require.Equal(t, Status{
+		"branch-created":    true,
+		"tags-pushed":       false,
+		"builds-staged":     true,
+		"images-promoted":   false,
+		"notes-published":   false,
+		"announcement-sent": true,
+	}, status)
+
+	_, err = checks.Items("1.15")
+	require.Error(t, err)
+}
@@ -0,0 +1,178 @@
+// Copyright 2019 The Kubernetes Authors All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package community
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// SigsURL is the location of the canonical sigs.yaml in kubernetes/community.
+const SigsURL = "https://raw.githubusercontent.com/kubernetes/community/master/sigs.yaml"
+
+// Groups is the parsed content of a sigs.yaml file.
+type Groups struct {
+	SIGs          []*Group `json:"sigs"`
+	WorkingGroups []*Group `json:"workinggroups"`
+	UserGroups    []*Group `json:"usergroups"`
+	Committees    []*Group `json:"committees"`
+}
+
+// Group is a single SIG, working group, user group or committee.
+type Group struct {
+	// Dir is the directory of the group in kubernetes/community, e.g.
+	// "sig-api-machinery"
+	Dir string `json:"dir"`
+
+	// Name is the full name of the group, e.g. "API Machinery"
+	Name string `json:"name"`
+
+	// MissionStatement describes the purpose of the group
+	MissionStatement string `json:"mission_statement,omitempty"`
+
+	// Label is the suffix of the sig/ label used for the group, e.g.
+	// "api-machinery"
+	Label string `json:"label"`
+
+	Leadership Leadership `json:"leadership"`
+	Contact    Contact    `json:"contact"`
+}
+
+// Leadership lists the people leading a group.
+type Leadership struct {
+	Chairs         []*Person `json:"chairs,omitempty"`
+	TechnicalLeads []*Person `json:"tech_leads,omitempty"`
+	EmeritusLeads  []*Person `json:"emeritus_leads,omitempty"`
+}
+
+// Person is a member of a group's leadership.
+type Person struct {
+	GitHub  string `json:"github"`
+	Name    string `json:"name"`
+	Company string `json:"company,omitempty"`
+}
+
+// Contact contains the channels used to reach a group.
+type Contact struct {
+	Slack       string `json:"slack,omitempty"`
+	MailingList string `json:"mailing_list,omitempty"`
+}
+
+// Fetch downloads and parses the sigs.yaml located at the given URL.
+func Fetch(ctx context.Context, url string) (*Groups, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := http.DefaultClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, errors.Wrapf(err, "error downloading %s", url)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %q downloading %s", resp.Status, url)
+	}
+
+	return Parse(resp.Body)
+}
+
+// Load reads sigs.yaml from either a URL or a path on the local filesystem.
+func Load(ctx context.Context, location string) (*Groups, error) {
+	if strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://") {
+		return Fetch(ctx, location)
+	}
+
+	f, err := os.Open(location)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return Parse(f)
+}
+
+// Parse reads the content of a sigs.yaml file.
+func Parse(r io.Reader) (*Groups, error) {
+	content, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	tree, err := decodeYAML(string(content))
+	if err != nil {
+		return nil, errors.Wrap(err, "error parsing sigs.yaml")
+	}
+
+	// the decoded tree only contains maps, slices and strings, so a round
+	// trip through JSON lets us reuse the struct tags above
+	raw, err := json.Marshal(tree)
+	if err != nil {
+		return nil, err
+	}
+
+	groups := &Groups{}
+	if err := json.Unmarshal(raw, groups); err != nil {
+		return nil, errors.Wrap(err, "error decoding sigs.yaml")
+	}
+
+	return groups, nil
+}
+
+// SIG returns the SIG for a given label. The label may be given with or
+// without the "sig/" prefix.
+func (g *Groups) SIG(label string) (*Group, bool) {
+	label = strings.TrimPrefix(label, "sig/")
+	for _, sig := range g.SIGs {
+		if sig.Label == label || sig.Dir == "sig-"+label {
+			return sig, true
+		}
+	}
+	return nil, false
+}
+
+// Leads returns the chairs and technical leads of the group, without
+// duplicates.
+func (g *Group) Leads() []*Person {
+	seen := map[string]struct{}{}
+	leads := []*Person{}
+	for _, people := range [][]*Person{g.Leadership.Chairs, g.Leadership.TechnicalLeads} {
+		for _, person := range people {
+			if _, ok := seen[person.GitHub]; ok {
+				continue
+			}
+			seen[person.GitHub] = struct{}{}
+			leads = append(leads, person)
+		}
+	}
+	return leads
+}
+
+// SlackChannel returns the Slack channel of the group prefixed with "#".
+func (g *Group) SlackChannel() string {
+	if g.Contact.Slack == "" {
+		return ""
+	}
+	return "#" + g.Contact.Slack
+}
@@ -0,0 +1,146 @@
+package community
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const sigsYAML = `
+# This file is the source of truth for SIGs and WGs
+sigs:
+  - dir: sig-api-machinery
+    name: API Machinery
+    mission_statement: >
+      Covers all aspects of API server, API registration and discovery, generic
+      API CRUD semantics, admission control, encoding/decoding, conversion.
+    charter_link: charter.md
+    label: api-machinery
+    leadership:
+      chairs:
+        - name: Federico Bongiovanni
+          github: fedebongio
+          company: Google
+        - github: deads2k
+          name: David Eads
+          company: Red Hat
+      tech_leads:
+        - github: deads2k
+          name: David Eads
+          company: Red Hat
+        - github: lavalamp
+          name: Daniel Smith
+          company: Google
+    meetings:
+      - description: Regular SIG Meeting
+        day: Wednesday
+        time: "11:00"
+        tz: "PT (Pacific Time)"
+        url: https://zoom.us/my/apimachinery
+    contact:
+      slack: sig-api-machinery
+      mailing_list: https://groups.google.com/forum/#!forum/kubernetes-sig-api-machinery # the list
+      teams:
+      - name: sig-api-machinery-api-reviews
+        description: API Changes and Reviews
+    subprojects:
+      - name: component-base
+        owners: []
+  - dir: sig-cli
+    name: CLI
+    label: cli
+    leadership:
+      chairs:
+        - github: soltysh
+          name: Maciej Szulik
+    contact:
+      slack: sig-cli
+      mailing_list: 'https://groups.google.com/forum/#!forum/kubernetes-sig-cli'
+workinggroups:
+  - dir: wg-lts
+    name: LTS
+    label: lts
+    mission_statement: |
+      Keeps "long term support"
+      discussions going.
+usergroups: []
+committees:
+  - dir: committee-steering
+    name: Steering
+    label: steering
+`
+
+func TestParse(t *testing.T) {
+	groups, err := Parse(strings.NewReader(sigsYAML))
+	require.NoError(t, err)
+
+	require.Len(t, groups.SIGs, 2)
+	require.Len(t, groups.WorkingGroups, 1)
+	require.Len(t, groups.UserGroups, 0)
+	require.Len(t, groups.Committees, 1)
+
+	apiMachinery := groups.SIGs[0]
+	require.Equal(t, "sig-api-machinery", apiMachinery.Dir)
+	require.Equal(t, "API Machinery", apiMachinery.Name)
+	require.Equal(t, "api-machinery", apiMachinery.Label)
+	require.Equal(t,
+		"Covers all aspects of API server, API registration and discovery, generic "+
+			"API CRUD semantics, admission control, encoding/decoding, conversion.\n",
+		apiMachinery.MissionStatement,
+	)
+	require.Equal(t, "sig-api-machinery", apiMachinery.Contact.Slack)
+	require.Equal(t,
+		"https://groups.google.com/forum/#!forum/kubernetes-sig-api-machinery",
+		apiMachinery.Contact.MailingList,
+	)
+	require.Len(t, apiMachinery.Leadership.Chairs, 2)
+	require.Equal(t, "fedebongio", apiMachinery.Leadership.Chairs[0].GitHub)
+	require.Equal(t, "Google", apiMachinery.Leadership.Chairs[0].Company)
+
+	require.Equal(t,
+		"https://groups.google.com/forum/#!forum/kubernetes-sig-cli",
+		groups.SIGs[1].Contact.MailingList,
+	)
+	require.Equal(t, "Keeps \"long term support\"\ndiscussions going.\n", groups.WorkingGroups[0].MissionStatement)
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, content := range []string{
+		"sigs:\n  - dir: sig-cli\n      name: CLI\n",
+		"sigs: [sig-cli]\n",
+		"sigs:\n  - name: \"CLI\n",
+	} {
+		_, err := Parse(strings.NewReader(content))
+		require.Error(t, err, content)
+	}
+}
+
+func TestSIG(t *testing.T) {
+	groups, err := Parse(strings.NewReader(sigsYAML))
+	require.NoError(t, err)
+
+	for _, label := range []string{"cli", "sig/cli"} {
+		sig, ok := groups.SIG(label)
+		require.True(t, ok)
+		require.Equal(t, "CLI", sig.Name)
+		require.Equal(t, "#sig-cli", sig.SlackChannel())
+	}
+
+	_, ok := groups.SIG("lts")
+	require.False(t, ok)
+}
+
+func TestLeads(t *testing.T) {
+	groups, err := Parse(strings.NewReader(sigsYAML))
+	require.NoError(t, err)
+
+	sig, ok := groups.SIG("api-machinery")
+	require.True(t, ok)
+
+	leads := []string{}
+	for _, lead := range sig.Leads() {
+		leads = append(leads, lead.GitHub)
+	}
+	require.Equal(t, []string{"fedebongio", "deads2k", "lavalamp"}, leads)
+}
@@ -0,0 +1,61 @@
+// Copyright 2019 The Kubernetes Authors All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package community
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/pkg/errors"
+)
+
+// slackMessage is the payload of a Slack incoming webhook.
+type slackMessage struct {
+	Text    string `json:"text"`
+	Channel string `json:"channel,omitempty"`
+}
+
+// PostSlack posts the text to a Slack incoming webhook. The channel, e.g.
+// "#sig-node", overrides the channel of the webhook if it is not empty,
+// which only legacy webhooks allow. The client defaults to
+// http.DefaultClient.
+func PostSlack(ctx context.Context, client *http.Client, webhookURL, channel, text string) error {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	payload, err := json.Marshal(&slackMessage{Text: text, Channel: channel})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, webhookURL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return errors.Wrap(err, "error posting to Slack")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %q posting to Slack", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package community
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostSlack(t *testing.T) {
+	var posted slackMessage
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPost, r.Method)
+		require.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&posted))
+		if posted.Channel == "#archived" {
+			http.Error(w, "channel_is_archived", http.StatusNotFound)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	require.NoError(t, PostSlack(context.Background(), nil, server.URL, "#sig-node", "The digest"))
+	require.Equal(t, slackMessage{Text: "The digest", Channel: "#sig-node"}, posted)
+
+	err := PostSlack(context.Background(), nil, server.URL, "#archived", "The digest")
+	require.EqualError(t, err, `unexpected status "404 Not Found" posting to Slack`)
+}
@@ -0,0 +1,362 @@
+// Copyright 2019 The Kubernetes Authors All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package community
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// decodeYAML decodes the block style subset of YAML used by sigs.yaml into a
+// tree of map[string]interface{}, []interface{} and string values. Supported
+// are block mappings and sequences, plain and quoted scalars, literal (|) and
+// folded (>) block scalars, empty flow collections and comments. Anchors, tags
+// and non-empty flow collections are not supported.
+func decodeYAML(content string) (interface{}, error) {
+	p := &yamlParser{}
+	for i, text := range strings.Split(strings.Replace(content, "\r\n", "\n", -1), "\n") {
+		trimmed := strings.TrimLeft(text, " ")
+		p.lines = append(p.lines, yamlLine{
+			num:    i + 1,
+			indent: len(text) - len(trimmed),
+			text:   trimmed,
+			raw:    text,
+		})
+	}
+
+	if !p.next() {
+		return map[string]interface{}{}, nil
+	}
+	return p.parseNode(p.lines[p.pos].indent)
+}
+
+type yamlLine struct {
+	num    int
+	indent int
+	text   string
+	raw    string
+}
+
+type yamlParser struct {
+	lines []yamlLine
+	pos   int
+}
+
+// next advances to the next line holding content and reports whether there
+// is one
+func (p *yamlParser) next() bool {
+	for ; p.pos < len(p.lines); p.pos++ {
+		text := stripComment(p.lines[p.pos].text)
+		if text != "" && text != "---" {
+			p.lines[p.pos].text = text
+			return true
+		}
+	}
+	return false
+}
+
+func (p *yamlParser) errorf(format string, args ...interface{}) error {
+	num := len(p.lines)
+	if p.pos < len(p.lines) {
+		num = p.lines[p.pos].num
+	}
+	return fmt.Errorf("line %d: %s", num, fmt.Sprintf(format, args...))
+}
+
+func (p *yamlParser) parseNode(indent int) (interface{}, error) {
+	if isSequenceItem(p.lines[p.pos].text) {
+		return p.parseSequence(indent)
+	}
+	return p.parseMapping(indent)
+}
+
+func (p *yamlParser) parseSequence(indent int) (interface{}, error) {
+	result := []interface{}{}
+	for p.next() {
+		line := p.lines[p.pos]
+		if line.indent < indent {
+			break
+		}
+		if line.indent > indent {
+			return nil, p.errorf("unexpected indentation")
+		}
+		if !isSequenceItem(line.text) {
+			break
+		}
+
+		rest := strings.TrimLeft(strings.TrimPrefix(line.text, "-"), " ")
+		if rest == "" {
+			p.pos++
+			item, err := p.parseChild(indent)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, item)
+			continue
+		}
+
+		if isSequenceItem(rest) || isMappingEntry(rest) {
+			// the item is a nested collection starting on the same line as the
+			// dash, so we continue parsing as if it started on its own line
+			p.lines[p.pos].indent = line.indent + len(line.text) - len(rest)
+			p.lines[p.pos].text = rest
+			item, err := p.parseNode(p.lines[p.pos].indent)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, item)
+			continue
+		}
+
+		item, err := p.parseScalar(rest, indent)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, item)
+	}
+	return result, nil
+}
+
+func (p *yamlParser) parseMapping(indent int) (interface{}, error) {
+	result := map[string]interface{}{}
+	for p.next() {
+		line := p.lines[p.pos]
+		if line.indent < indent {
+			break
+		}
+		if line.indent > indent {
+			return nil, p.errorf("unexpected indentation")
+		}
+		if isSequenceItem(line.text) {
+			break
+		}
+
+		key, value, ok := splitMappingEntry(line.text)
+		if !ok {
+			return nil, p.errorf("expected a mapping entry, got %q", line.text)
+		}
+		key, err := unquote(key)
+		if err != nil {
+			return nil, p.errorf("%v", err)
+		}
+
+		var node interface{}
+		if value == "" {
+			p.pos++
+			node, err = p.parseChild(indent)
+		} else {
+			node, err = p.parseScalar(value, indent)
+		}
+		if err != nil {
+			return nil, err
+		}
+		result[key] = node
+	}
+	return result, nil
+}
+
+// parseChild parses the value of a mapping key or sequence item which starts
+// on the line following it
+func (p *yamlParser) parseChild(parentIndent int) (interface{}, error) {
+	if !p.next() {
+		return nil, nil
+	}
+	line := p.lines[p.pos]
+	// sequences are allowed at the same indentation as their mapping key
+	if line.indent > parentIndent ||
+		(line.indent == parentIndent && isSequenceItem(line.text) && !p.inSequence(parentIndent)) {
+		return p.parseNode(line.indent)
+	}
+	return nil, nil
+}
+
+// inSequence reports whether the nearest preceding line at the given
+// indentation is a sequence item
+func (p *yamlParser) inSequence(indent int) bool {
+	for i := p.pos - 1; i >= 0; i-- {
+		line := p.lines[i]
+		if line.indent == indent && stripComment(line.text) != "" {
+			return isSequenceItem(line.text)
+		}
+	}
+	return false
+}
+
+// parseScalar parses the value found on the current line and consumes it,
+// together with any continuation lines
+func (p *yamlParser) parseScalar(value string, parentIndent int) (interface{}, error) {
+	switch {
+	case value == "[]":
+		p.pos++
+		return []interface{}{}, nil
+	case value == "{}":
+		p.pos++
+		return map[string]interface{}{}, nil
+	case strings.HasPrefix(value, "|") || strings.HasPrefix(value, ">"):
+		return p.parseBlockScalar(value, parentIndent)
+	case strings.HasPrefix(value, "[") || strings.HasPrefix(value, "{"):
+		return nil, p.errorf("flow collections are not supported")
+	case strings.HasPrefix(value, "\"") || strings.HasPrefix(value, "'"):
+		p.pos++
+		s, err := unquote(value)
+		if err != nil {
+			return nil, p.errorf("%v", err)
+		}
+		return s, nil
+	}
+
+	// plain scalars may continue on more indented lines
+	parts := []string{value}
+	for p.pos++; p.pos < len(p.lines); p.pos++ {
+		line := p.lines[p.pos]
+		text := stripComment(line.text)
+		if text == "" {
+			continue
+		}
+		if line.indent <= parentIndent || isMappingEntry(text) || isSequenceItem(text) {
+			break
+		}
+		parts = append(parts, text)
+	}
+	return strings.Join(parts, " "), nil
+}
+
+func (p *yamlParser) parseBlockScalar(header string, parentIndent int) (interface{}, error) {
+	literal := strings.HasPrefix(header, "|")
+	chomp := strings.TrimSpace(header[1:])
+	if chomp != "" && chomp != "-" && chomp != "+" {
+		return nil, p.errorf("unsupported block scalar header %q", header)
+	}
+
+	lines := []string{}
+	indent := -1
+	for p.pos++; p.pos < len(p.lines); p.pos++ {
+		line := p.lines[p.pos]
+		if strings.TrimSpace(line.raw) == "" {
+			lines = append(lines, "")
+			continue
+		}
+		if line.indent <= parentIndent {
+			break
+		}
+		if indent < 0 {
+			indent = line.indent
+		}
+		if line.indent < indent {
+			break
+		}
+		lines = append(lines, line.raw[indent:])
+	}
+
+	// trailing empty lines are subject to chomping
+	trailing := 0
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+		trailing++
+	}
+
+	var text string
+	if literal {
+		text = strings.Join(lines, "\n")
+	} else {
+		var b strings.Builder
+		for i, line := range lines {
+			switch {
+			case i == 0:
+			case line == "" || lines[i-1] == "":
+				b.WriteString("\n")
+			case strings.HasPrefix(line, " ") || strings.HasPrefix(lines[i-1], " "):
+				b.WriteString("\n")
+			default:
+				b.WriteString(" ")
+			}
+			b.WriteString(line)
+		}
+		text = b.String()
+	}
+
+	switch chomp {
+	case "-":
+	case "+":
+		text += strings.Repeat("\n", trailing+1)
+	default:
+		if len(lines) > 0 {
+			text += "\n"
+		}
+	}
+	return text, nil
+}
+
+func isSequenceItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+func isMappingEntry(text string) bool {
+	_, _, ok := splitMappingEntry(text)
+	return ok
+}
+
+// splitMappingEntry splits "key: value" into its key and value, ignoring
+// colons inside of quotes or not followed by a space
+func splitMappingEntry(text string) (key, value string, ok bool) {
+	var quote rune
+	for i, r := range text {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case (r == '"' || r == '\'') && i == 0:
+			quote = r
+		case r == ':' && (i == len(text)-1 || text[i+1] == ' '):
+			return strings.TrimSpace(text[:i]), strings.TrimSpace(text[i+1:]), true
+		}
+	}
+	return "", "", false
+}
+
+// stripComment removes a trailing comment which is not part of a quoted
+// string
+func stripComment(text string) string {
+	var quote rune
+	for i, r := range text {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			if i == 0 || text[i-1] == ' ' {
+				quote = r
+			}
+		case r == '#' && (i == 0 || text[i-1] == ' '):
+			return strings.TrimSpace(text[:i])
+		}
+	}
+	return strings.TrimSpace(text)
+}
+
+func unquote(s string) (string, error) {
+	switch {
+	case len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"':
+		return strconv.Unquote(s)
+	case len(s) >= 2 && s[0] == '\'' && s[len(s)-1] == '\'':
+		return strings.Replace(s[1:len(s)-1], "''", "'", -1), nil
+	case strings.HasPrefix(s, "\"") || strings.HasPrefix(s, "'"):
+		return "", fmt.Errorf("unterminated quoted string %s", s)
+	}
+	return s, nil
+}
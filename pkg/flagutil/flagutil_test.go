@@ -0,0 +1,12 @@
+package flagutil
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitList(t *testing.T) {
+	require.Equal(t, []string{"node", "cli"}, SplitList(" node, ,cli,"))
+	require.Equal(t, []string{}, SplitList(""))
+}
@@ -0,0 +1,269 @@
+// Copyright 2019 The Kubernetes Authors All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package license audits the licenses of the vendored dependencies of a
+// release against a policy.
+package license
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Unknown is the license of dependencies whose license could not be
+// classified, or which have no license file.
+const Unknown = "Unknown"
+
+// Dependency is a vendored go module.
+type Dependency struct {
+	Path    string `json:"path"`
+	Version string `json:"version"`
+
+	// LicenseFile is the path of the license file relative to the vendor
+	// directory, empty if none was found
+	LicenseFile string `json:"license_file,omitempty"`
+
+	// License is the SPDX identifier of the license
+	License string `json:"license"`
+}
+
+// moduleRe matches the module lines of vendor/modules.txt, e.g.
+// "# github.com/pkg/errors v0.8.0" or, for replaced modules,
+// "# k8s.io/api v0.0.0 => ./staging/src/k8s.io/api"
+var moduleRe = regexp.MustCompile(`^# (\S+) (\S+)(?: => (\S+)(?: (\S+))?)?$`)
+
+// Dependencies lists the modules vendored in the go module at dir and
+// classifies their licenses. Modules replaced by local directories are part
+// of the repository itself and are skipped.
+func Dependencies(dir string) ([]*Dependency, error) {
+	vendor := filepath.Join(dir, "vendor")
+	file, err := os.Open(filepath.Join(vendor, "modules.txt"))
+	if err != nil {
+		return nil, errors.Wrap(err, "error reading the vendored modules")
+	}
+	defer file.Close()
+
+	deps := []*Dependency{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		match := moduleRe.FindStringSubmatch(scanner.Text())
+		if match == nil {
+			continue
+		}
+		dep := &Dependency{Path: match[1], Version: match[2], License: Unknown}
+		if match[3] != "" {
+			if match[4] == "" {
+				// replaced by a local directory
+				continue
+			}
+			dep.Version = match[4]
+		}
+
+		if licenseFile := findLicenseFile(vendor, dep.Path); licenseFile != "" {
+			text, err := ioutil.ReadFile(filepath.Join(vendor, licenseFile))
+			if err != nil {
+				return nil, err
+			}
+			dep.LicenseFile = filepath.ToSlash(licenseFile)
+			dep.License = Classify(string(text))
+		}
+		deps = append(deps, dep)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(deps, func(i, j int) bool { return deps[i].Path < deps[j].Path })
+	return deps, nil
+}
+
+var licenseFileNames = []string{
+	"LICENSE", "LICENSE.txt", "LICENSE.md", "LICENCE", "COPYING", "COPYING.txt",
+}
+
+// findLicenseFile returns the license file of the module, relative to the
+// vendor directory. Modules in a subdirectory of a repository often only
+// have the license at the root of the repository, so the parent directories
+// are searched as well.
+func findLicenseFile(vendor, module string) string {
+	for dir := module; dir != "." && dir != "/"; dir = filepath.Dir(dir) {
+		for _, name := range licenseFileNames {
+			path := filepath.Join(dir, name)
+			if info, err := os.Stat(filepath.Join(vendor, path)); err == nil && !info.IsDir() {
+				return path
+			}
+		}
+	}
+	return ""
+}
+
+// classifiers identify licenses by phrases of their text. They are tried in
+// order, so that more specific licenses come before the ones they contain
+// phrases of.
+var classifiers = []struct {
+	license string
+	phrases []string
+}{
+	{"AGPL-3.0", []string{"GNU AFFERO GENERAL PUBLIC LICENSE"}},
+	{"LGPL-3.0", []string{"GNU LESSER GENERAL PUBLIC LICENSE", "Version 3"}},
+	{"LGPL-2.1", []string{"GNU LESSER GENERAL PUBLIC LICENSE", "Version 2.1"}},
+	{"GPL-3.0", []string{"GNU GENERAL PUBLIC LICENSE", "Version 3"}},
+	{"GPL-2.0", []string{"GNU GENERAL PUBLIC LICENSE", "Version 2"}},
+	{"MPL-2.0", []string{"Mozilla Public License", "2.0"}},
+	{"Apache-2.0", []string{"Apache License", "Version 2.0"}},
+	{"BSD-3-Clause", []string{"Redistribution and use in source and binary forms", "Neither the name of"}},
+	{"BSD-3-Clause", []string{"Redistribution and use in source and binary forms", "names of its contributors may be used"}},
+	{"BSD-2-Clause", []string{"Redistribution and use in source and binary forms"}},
+	{"MIT", []string{"Permission is hereby granted, free of charge"}},
+	{"ISC", []string{"Permission to use, copy, modify, and/or distribute this software for any purpose"}},
+	{"ISC", []string{"Permission to use, copy, modify, and distribute this software for any purpose"}},
+}
+
+// Classify returns the SPDX identifier of the license text, or Unknown.
+func Classify(text string) string {
+	// licenses are wrapped differently across projects
+	text = strings.Join(strings.Fields(text), " ")
+	for _, classifier := range classifiers {
+		matches := true
+		for _, phrase := range classifier.phrases {
+			if !strings.Contains(text, phrase) {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			return classifier.license
+		}
+	}
+	return Unknown
+}
+
+// Policy lists the licenses dependencies may and may not have.
+type Policy struct {
+	// Allowed are the licenses which need no review
+	Allowed []string `json:"allowed"`
+
+	// Disallowed are the licenses which must not be shipped
+	Disallowed []string `json:"disallowed"`
+}
+
+// DefaultPolicy allows the licenses the CNCF allows without exception.
+var DefaultPolicy = &Policy{
+	Allowed: []string{
+		"Apache-2.0", "BSD-2-Clause", "BSD-3-Clause", "ISC", "MIT",
+	},
+	Disallowed: []string{
+		"AGPL-3.0", "GPL-2.0", "GPL-3.0", "LGPL-2.1", "LGPL-3.0",
+	},
+}
+
+// LoadPolicy reads a policy from a JSON file.
+func LoadPolicy(path string) (*Policy, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	policy := &Policy{}
+	if err := json.Unmarshal(content, policy); err != nil {
+		return nil, errors.Wrapf(err, "error parsing the license policy %s", path)
+	}
+	return policy, nil
+}
+
+// Finding is a dependency violating the policy.
+type Finding struct {
+	Dependency *Dependency
+	Reason     string
+}
+
+func (f *Finding) String() string {
+	return fmt.Sprintf("%s %s: %s", f.Dependency.Path, f.Dependency.Version, f.Reason)
+}
+
+// Evaluate returns the dependencies with a disallowed, unknown or unreviewed
+// license.
+func (p *Policy) Evaluate(deps []*Dependency) []*Finding {
+	findings := []*Finding{}
+	for _, dep := range deps {
+		reason := ""
+		switch {
+		case contains(p.Disallowed, dep.License):
+			reason = fmt.Sprintf("license %s is disallowed", dep.License)
+		case dep.LicenseFile == "":
+			reason = "no license file found"
+		case dep.License == Unknown:
+			reason = fmt.Sprintf("license of %s could not be classified", dep.LicenseFile)
+		case !contains(p.Allowed, dep.License):
+			reason = fmt.Sprintf("license %s needs review", dep.License)
+		default:
+			continue
+		}
+		findings = append(findings, &Finding{Dependency: dep, Reason: reason})
+	}
+	return findings
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Report renders the LICENSES report of the release as markdown.
+func Report(version string, deps []*Dependency, findings []*Finding) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Licenses of the dependencies of %s\n\n", version)
+
+	counts := map[string]int{}
+	for _, dep := range deps {
+		counts[dep.License]++
+	}
+	licenses := make([]string, 0, len(counts))
+	for license := range counts {
+		licenses = append(licenses, license)
+	}
+	sort.Strings(licenses)
+
+	b.WriteString("## Summary\n\n")
+	b.WriteString("| License | Dependencies |\n| ------- | ------------ |\n")
+	for _, license := range licenses {
+		fmt.Fprintf(&b, "| %s | %d |\n", license, counts[license])
+	}
+
+	if len(findings) > 0 {
+		b.WriteString("\n## Findings\n\n")
+		for _, finding := range findings {
+			fmt.Fprintf(&b, "- %s\n", finding)
+		}
+	}
+
+	b.WriteString("\n## Dependencies\n\n")
+	b.WriteString("| Module | Version | License |\n| ------ | ------- | ------- |\n")
+	for _, dep := range deps {
+		fmt.Fprintf(&b, "| %s | %s | %s |\n", dep.Path, dep.Version, dep.License)
+	}
+	return b.String()
+}
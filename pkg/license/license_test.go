@@ -0,0 +1,103 @@
+package license
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	apache = `
+                                 Apache License
+                           Version 2.0, January 2004
+                        http://www.apache.org/licenses/`
+	mit = `MIT License
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files`
+	bsd3 = `Redistribution and use in source and binary forms, with or without
+modification, are permitted provided that the following conditions are
+met:
+   * Neither the name of Google Inc. nor the names of its
+contributors may be used to endorse or promote products derived from`
+	gpl2 = `		    GNU GENERAL PUBLIC LICENSE
+		       Version 2, June 1991`
+)
+
+func TestClassify(t *testing.T) {
+	require.Equal(t, "Apache-2.0", Classify(apache))
+	require.Equal(t, "MIT", Classify(mit))
+	require.Equal(t, "BSD-3-Clause", Classify(bsd3))
+	require.Equal(t, "GPL-2.0", Classify(gpl2))
+	require.Equal(t, Unknown, Classify("All rights reserved."))
+}
+
+func writeFile(t *testing.T, path, content string) {
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, ioutil.WriteFile(path, []byte(content), 0644))
+}
+
+func TestDependencies(t *testing.T) {
+	dir, err := ioutil.TempDir("", "license")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	vendor := filepath.Join(dir, "vendor")
+	writeFile(t, filepath.Join(vendor, "modules.txt"), `# github.com/pkg/errors v0.8.0
+github.com/pkg/errors
+# golang.org/x/oauth2 v0.0.0-20190402181905-9f3314589c9a
+golang.org/x/oauth2
+golang.org/x/oauth2/internal
+# github.com/example/gpl v1.0.0
+github.com/example/gpl
+# github.com/example/unlicensed v1.0.0
+github.com/example/unlicensed
+# k8s.io/api v0.0.0 => ./staging/src/k8s.io/api
+k8s.io/api/core/v1
+# github.com/example/fork v1.0.0 => github.com/alice/fork v1.0.1
+github.com/example/fork
+`)
+	writeFile(t, filepath.Join(vendor, "github.com/pkg/errors/LICENSE"), bsd3)
+	writeFile(t, filepath.Join(vendor, "golang.org/x/oauth2/LICENSE"), bsd3)
+	writeFile(t, filepath.Join(vendor, "github.com/example/gpl/COPYING"), gpl2)
+	writeFile(t, filepath.Join(vendor, "github.com/example/unlicensed/main.go"), "package main")
+	writeFile(t, filepath.Join(vendor, "github.com/example/fork/LICENSE.md"), mit)
+
+	deps, err := Dependencies(dir)
+	require.NoError(t, err)
+	require.Equal(t, []*Dependency{
+		{Path: "github.com/example/fork", Version: "v1.0.1", LicenseFile: "github.com/example/fork/LICENSE.md", License: "MIT"},
+		{Path: "github.com/example/gpl", Version: "v1.0.0", LicenseFile: "github.com/example/gpl/COPYING", License: "GPL-2.0"},
+		{Path: "github.com/example/unlicensed", Version: "v1.0.0", License: Unknown},
+		{Path: "github.com/pkg/errors", Version: "v0.8.0", LicenseFile: "github.com/pkg/errors/LICENSE", License: "BSD-3-Clause"},
+		{Path: "golang.org/x/oauth2", Version: "v0.0.0-20190402181905-9f3314589c9a", LicenseFile: "golang.org/x/oauth2/LICENSE", License: "BSD-3-Clause"},
+	}, deps)
+
+	findings := DefaultPolicy.Evaluate(deps)
+	require.Len(t, findings, 2)
+	require.Equal(t, "github.com/example/gpl v1.0.0: license GPL-2.0 is disallowed", findings[0].String())
+	require.Equal(t, "github.com/example/unlicensed v1.0.0: no license file found", findings[1].String())
+
+	policy := &Policy{Allowed: []string{"BSD-3-Clause"}}
+	findings = policy.Evaluate(deps)
+	require.Len(t, findings, 3)
+	require.Equal(t, "github.com/example/fork v1.0.1: license MIT needs review", findings[0].String())
+
+	report := Report("v1.15.0", deps, DefaultPolicy.Evaluate(deps))
+	require.Contains(t, report, "# Licenses of the dependencies of v1.15.0\n")
+	require.Contains(t, report, "| BSD-3-Clause | 2 |\n")
+	require.Contains(t, report, "- github.com/example/gpl v1.0.0: license GPL-2.0 is disallowed\n")
+	require.Contains(t, report, "| github.com/pkg/errors | v0.8.0 | BSD-3-Clause |\n")
+}
+
+func TestDependenciesWithoutVendor(t *testing.T) {
+	dir, err := ioutil.TempDir("", "license")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	_, err = Dependencies(dir)
+	require.Error(t, err)
+}
@@ -0,0 +1,193 @@
+// Copyright 2019 The Kubernetes Authors All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package linkcheck verifies that the URLs in generated documents resolve.
+package linkcheck
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// urlRe matches http(s) URLs in markdown and HTML. A URL ends at whitespace,
+// quotes, angle brackets or a closing parenthesis, which covers markdown
+// links, autolinks and href attributes.
+var urlRe = regexp.MustCompile(`https?://[^\s"'<>()\[\]]+`)
+
+// Extract returns every distinct URL in the content, in order of appearance.
+func Extract(content []byte) []string {
+	urls := []string{}
+	seen := map[string]bool{}
+	for _, match := range urlRe.FindAll(content, -1) {
+		// trailing punctuation belongs to the surrounding sentence
+		url := strings.TrimRight(string(match), ".,;:!?")
+		if !seen[url] {
+			seen[url] = true
+			urls = append(urls, url)
+		}
+	}
+	return urls
+}
+
+// Result is the outcome of checking a single URL.
+type Result struct {
+	URL string
+
+	// StatusCode is the HTTP status of the last attempt, or zero if no
+	// response was received
+	StatusCode int
+
+	// Err is the error of the last attempt if no response was received
+	Err error
+}
+
+// Broken reports whether the URL does not resolve.
+func (r *Result) Broken() bool {
+	return r.Err != nil || r.StatusCode >= http.StatusBadRequest
+}
+
+func (r *Result) String() string {
+	if r.Err != nil {
+		return fmt.Sprintf("%s: %v", r.URL, r.Err)
+	}
+	return fmt.Sprintf("%s: %d %s", r.URL, r.StatusCode, http.StatusText(r.StatusCode))
+}
+
+// Checker checks URLs concurrently.
+type Checker struct {
+	// Client performs the requests. It defaults to a client with a 30 second
+	// timeout.
+	Client *http.Client
+
+	// Concurrency is the number of URLs checked at the same time.
+	Concurrency int
+
+	// Retries is the number of times a request is retried after a network
+	// error, a rate limit or a server error.
+	Retries int
+
+	// Backoff is the delay before the first retry, it doubles with every
+	// further retry.
+	Backoff time.Duration
+}
+
+// NewChecker creates a Checker with the default configuration.
+func NewChecker() *Checker {
+	return &Checker{
+		Client:      &http.Client{Timeout: 30 * time.Second},
+		Concurrency: 10,
+		Retries:     3,
+		Backoff:     time.Second,
+	}
+}
+
+// Check checks all URLs and returns a result for each of them, in the same
+// order.
+func (c *Checker) Check(ctx context.Context, urls []string) []*Result {
+	concurrency := c.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	results := make([]*Result, len(urls))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				results[i] = c.check(ctx, urls[i])
+			}
+		}()
+	}
+	for i := range urls {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	return results
+}
+
+// Broken returns the results of all URLs which do not resolve.
+func Broken(results []*Result) []*Result {
+	broken := []*Result{}
+	for _, result := range results {
+		if result.Broken() {
+			broken = append(broken, result)
+		}
+	}
+	return broken
+}
+
+// check checks a single URL, retrying transient failures
+func (c *Checker) check(ctx context.Context, url string) *Result {
+	result := &Result{URL: url}
+	backoff := c.Backoff
+	for attempt := 0; ; attempt++ {
+		result.StatusCode, result.Err = c.status(ctx, url)
+		if !transient(result) || attempt >= c.Retries {
+			return result
+		}
+
+		select {
+		case <-ctx.Done():
+			result.Err = ctx.Err()
+			return result
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// status requests the URL and returns the status code. HEAD is tried first
+// and GET is used if the server does not support HEAD.
+func (c *Checker) status(ctx context.Context, url string) (int, error) {
+	client := c.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	var code int
+	for _, method := range []string{http.MethodHead, http.MethodGet} {
+		req, err := http.NewRequest(method, url, nil)
+		if err != nil {
+			return 0, err
+		}
+		resp, err := client.Do(req.WithContext(ctx))
+		if err != nil {
+			return 0, err
+		}
+		resp.Body.Close()
+
+		code = resp.StatusCode
+		if code != http.StatusMethodNotAllowed && code != http.StatusNotImplemented {
+			break
+		}
+	}
+	return code, nil
+}
+
+// transient reports whether checking the URL again might succeed
+func transient(r *Result) bool {
+	return r.Err != nil ||
+		r.StatusCode == http.StatusTooManyRequests ||
+		r.StatusCode >= http.StatusInternalServerError
+}
@@ -0,0 +1,61 @@
+package linkcheck
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtract(t *testing.T) {
+	content := []byte(`- Fixes a bug ([#1](https://github.com/kubernetes/kubernetes/pull/1), [@alice](https://github.com/alice))
+- See https://kubernetes.io/docs/. Also <a href="https://dl.k8s.io/v1.15.0/kubernetes.tar.gz">download</a>
+- Again https://github.com/alice
+`)
+	require.Equal(t, []string{
+		"https://github.com/kubernetes/kubernetes/pull/1",
+		"https://github.com/alice",
+		"https://kubernetes.io/docs/",
+		"https://dl.k8s.io/v1.15.0/kubernetes.tar.gz",
+	}, Extract(content))
+}
+
+func TestCheck(t *testing.T) {
+	var flaky int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ok":
+		case "/missing":
+			w.WriteHeader(http.StatusNotFound)
+		case "/get-only":
+			if r.Method == http.MethodHead {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+			}
+		case "/flaky":
+			if atomic.AddInt32(&flaky, 1) < 3 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}
+		}
+	}))
+	defer server.Close()
+
+	checker := NewChecker()
+	checker.Backoff = 0
+	results := checker.Check(context.Background(), []string{
+		server.URL + "/ok",
+		server.URL + "/missing",
+		server.URL + "/get-only",
+		server.URL + "/flaky",
+	})
+
+	require.Len(t, results, 4)
+	require.Equal(t, http.StatusOK, results[0].StatusCode)
+	require.Equal(t, http.StatusNotFound, results[1].StatusCode)
+	require.Equal(t, http.StatusOK, results[2].StatusCode)
+	require.Equal(t, http.StatusOK, results[3].StatusCode)
+	require.Equal(t, []*Result{results[1]}, Broken(results))
+	require.Equal(t, server.URL+"/missing: 404 Not Found", results[1].String())
+}
@@ -0,0 +1,201 @@
+// Copyright 2019 The Kubernetes Authors All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package milestone verifies that the PRs and tracked enhancements of a
+// release carry the milestone of the release.
+package milestone
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/go-github/github"
+	"github.com/pkg/errors"
+
+	"k8s.io/release/pkg/mutation"
+)
+
+// Problem is a PR or issue which does not carry the expected milestone.
+type Problem struct {
+	// Org, Repo and Number identify the PR or issue
+	Org    string `json:"org"`
+	Repo   string `json:"repo"`
+	Number int    `json:"number"`
+
+	// URL is the URL of the PR or issue
+	URL string `json:"url"`
+
+	// Milestone is the current milestone, which is empty if there is none
+	Milestone string `json:"milestone"`
+
+	// Fixed indicates that the expected milestone has been applied
+	Fixed bool `json:"fixed"`
+}
+
+func (p *Problem) String() string {
+	milestone := p.Milestone
+	if milestone == "" {
+		milestone = "no milestone"
+	}
+	status := ""
+	if p.Fixed {
+		status = " (fixed)"
+	}
+	return fmt.Sprintf("%s/%s#%d has %s%s", p.Org, p.Repo, p.Number, milestone, status)
+}
+
+// Checker compares the milestones of PRs and issues against the milestone of
+// the release. In mock mode, which is the default, it only reports problems.
+// Otherwise it applies the expected milestone to every PR or issue lacking it.
+type Checker struct {
+	Client *github.Client
+
+	// Milestone is the title of the expected milestone, e.g. v1.15
+	Milestone string
+
+	// NoMock enables applying the milestone via the API
+	NoMock bool
+
+	// Mutations applies the milestones. Milestones which cannot be applied
+	// are left unfixed and listed in its report.
+	Mutations *mutation.Engine
+
+	// milestoneNumbers caches the number of the milestone per repository
+	milestoneNumbers map[string]int
+}
+
+// NewChecker creates a Checker in mock mode.
+func NewChecker(client *github.Client, milestone string) *Checker {
+	return &Checker{Client: client, Milestone: milestone, Mutations: mutation.NewEngine(client)}
+}
+
+// CheckPRs checks the milestones of the pull requests of the given repository.
+func (c *Checker) CheckPRs(ctx context.Context, org, repo string, prs []*github.PullRequest) ([]*Problem, error) {
+	problems := []*Problem{}
+	for _, pr := range prs {
+		if problem := c.check(org, repo, pr.GetNumber(), pr.GetHTMLURL(), pr.Milestone); problem != nil {
+			problems = append(problems, problem)
+		}
+	}
+	return problems, c.fix(ctx, problems)
+}
+
+// CheckTrackedIssues checks the milestones of the open issues with the given
+// label, e.g. the enhancements labelled tracked/yes in kubernetes/enhancements.
+// Closed issues are left alone, as they keep the milestone of the release they
+// have been completed in.
+func (c *Checker) CheckTrackedIssues(ctx context.Context, org, repo, label string) ([]*Problem, error) {
+	problems := []*Problem{}
+	opts := &github.IssueListByRepoOptions{
+		State:       "open",
+		Labels:      []string{label},
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+	for {
+		issues, resp, err := c.Client.Issues.ListByRepo(ctx, org, repo, opts)
+		if err != nil {
+			return problems, errors.Wrapf(err, "error listing issues of %s/%s labelled %s", org, repo, label)
+		}
+		for _, issue := range issues {
+			if issue.IsPullRequest() {
+				continue
+			}
+			if problem := c.check(org, repo, issue.GetNumber(), issue.GetHTMLURL(), issue.Milestone); problem != nil {
+				problems = append(problems, problem)
+			}
+		}
+		if resp.NextPage == 0 {
+			return problems, c.fix(ctx, problems)
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// check returns the problem of a PR or issue, or nil if it carries the
+// expected milestone
+func (c *Checker) check(org, repo string, number int, url string, milestone *github.Milestone) *Problem {
+	if milestone.GetTitle() == c.Milestone {
+		return nil
+	}
+	return &Problem{
+		Org:       org,
+		Repo:      repo,
+		Number:    number,
+		URL:       url,
+		Milestone: milestone.GetTitle(),
+	}
+}
+
+// fix applies the expected milestone to the PRs and issues of the problems
+// as a single batch of mutations, unless in mock mode
+func (c *Checker) fix(ctx context.Context, problems []*Problem) error {
+	if !c.NoMock || len(problems) == 0 {
+		return nil
+	}
+
+	mutations := make([]*mutation.Mutation, 0, len(problems))
+	for _, problem := range problems {
+		milestoneNumber, err := c.milestoneNumber(ctx, problem.Org, problem.Repo)
+		if err != nil {
+			return err
+		}
+		// PRs are issues as far as milestones are concerned
+		mutations = append(mutations, &mutation.Mutation{
+			Kind:      mutation.KindMilestone,
+			Org:       problem.Org,
+			Repo:      problem.Repo,
+			Number:    problem.Number,
+			Milestone: milestoneNumber,
+		})
+	}
+
+	results, err := c.Mutations.Apply(ctx, mutations)
+	for i, result := range results {
+		problems[i].Fixed = result.Status != mutation.StatusFailed
+	}
+	return err
+}
+
+// milestoneNumber looks up the number of the expected milestone in the
+// repository
+func (c *Checker) milestoneNumber(ctx context.Context, org, repo string) (int, error) {
+	key := org + "/" + repo
+	if number, ok := c.milestoneNumbers[key]; ok {
+		return number, nil
+	}
+
+	opts := &github.MilestoneListOptions{
+		State:       "all",
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+	for {
+		milestones, resp, err := c.Client.Issues.ListMilestones(ctx, org, repo, opts)
+		if err != nil {
+			return 0, errors.Wrapf(err, "error listing milestones of %s", key)
+		}
+		for _, milestone := range milestones {
+			if milestone.GetTitle() == c.Milestone {
+				if c.milestoneNumbers == nil {
+					c.milestoneNumbers = map[string]int{}
+				}
+				c.milestoneNumbers[key] = milestone.GetNumber()
+				return milestone.GetNumber(), nil
+			}
+		}
+		if resp.NextPage == 0 {
+			return 0, fmt.Errorf("milestone %s does not exist in %s", c.Milestone, key)
+		}
+		opts.Page = resp.NextPage
+	}
+}
@@ -0,0 +1,86 @@
+package milestone
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/github"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckPRs(t *testing.T) {
+	edited := map[string]int{}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/o/r/milestones", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"number": 1, "title": "v1.14"}, {"number": 2, "title": "v1.15"}]`)
+	})
+	mux.HandleFunc("/repos/o/r/issues/", func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPatch, r.Method)
+		var req github.IssueRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		edited[r.URL.Path] = req.GetMilestone()
+		fmt.Fprint(w, `{}`)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+
+	prs := []*github.PullRequest{
+		{Number: github.Int(1), Milestone: &github.Milestone{Title: github.String("v1.15")}},
+		{Number: github.Int(2), Milestone: &github.Milestone{Title: github.String("v1.14")}},
+		{Number: github.Int(3)},
+	}
+
+	checker := NewChecker(client, "v1.15")
+	problems, err := checker.CheckPRs(context.Background(), "o", "r", prs)
+	require.NoError(t, err)
+	require.Len(t, problems, 2)
+	require.Equal(t, "o/r#2 has v1.14", problems[0].String())
+	require.Equal(t, "o/r#3 has no milestone", problems[1].String())
+	require.Empty(t, edited)
+
+	checker.NoMock = true
+	checker.Mutations.Interval = 0
+	problems, err = checker.CheckPRs(context.Background(), "o", "r", prs)
+	require.NoError(t, err)
+	require.Len(t, problems, 2)
+	require.True(t, problems[0].Fixed)
+	require.Equal(t, "o/r#3 has no milestone (fixed)", problems[1].String())
+	require.Equal(t, map[string]int{"/repos/o/r/issues/2": 2, "/repos/o/r/issues/3": 2}, edited)
+}
+
+func TestCheckTrackedIssues(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/kubernetes/enhancements/issues", func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "tracked/yes", r.URL.Query().Get("labels"))
+		issues := []string{
+			`{"number": 10, "state": "open", "milestone": {"title": "v1.15"}}`,
+			`{"number": 11, "state": "open", "milestone": {"title": "v1.16"}}`,
+			`{"number": 12, "state": "open", "pull_request": {"url": "https://api.github.com/repos/kubernetes/enhancements/pulls/12"}}`,
+		}
+		// an enhancement completed in an earlier release
+		if r.URL.Query().Get("state") != "open" {
+			issues = append(issues, `{"number": 13, "state": "closed", "milestone": {"title": "v1.14"}}`)
+		}
+		fmt.Fprintf(w, "[%s]", strings.Join(issues, ","))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+
+	problems, err := NewChecker(client, "v1.15").CheckTrackedIssues(context.Background(), "kubernetes", "enhancements", "tracked/yes")
+	require.NoError(t, err)
+	require.Len(t, problems, 1)
+	require.Equal(t, "kubernetes/enhancements#11 has v1.16", problems[0].String())
+}
@@ -0,0 +1,208 @@
+// Copyright 2019 The Kubernetes Authors All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package mirror verifies that the artifacts of a release served by the
+// mirrors and the CDN match the canonical objects in the release bucket.
+package mirror
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Artifacts returns the paths of a representative sample of the artifacts of
+// the release version, relative to the root of the bucket.
+func Artifacts(version string) []string {
+	paths := []string{
+		"kubernetes.tar.gz",
+		"kubernetes-src.tar.gz",
+		"bin/linux/amd64/kubeadm",
+		"bin/linux/amd64/kubectl",
+		"bin/linux/amd64/kubelet",
+		"bin/linux/arm64/kubectl",
+		"bin/darwin/amd64/kubectl",
+		"bin/windows/amd64/kubectl.exe",
+	}
+	for i, path := range paths {
+		paths[i] = fmt.Sprintf("release/%s/%s", version, path)
+	}
+	return paths
+}
+
+// Digest is the size and checksum of an artifact as served by one location.
+type Digest struct {
+	URL    string
+	Size   int64
+	SHA256 string
+
+	// Err is the error if the artifact could not be fetched
+	Err error
+}
+
+// Result is the outcome of verifying a single artifact.
+type Result struct {
+	Path      string
+	Canonical *Digest
+	Mirrors   []*Digest
+}
+
+// Problems returns a description of every location not serving the canonical
+// artifact.
+func (r *Result) Problems() []string {
+	if r.Canonical.Err != nil {
+		return []string{fmt.Sprintf("%s: %v", r.Canonical.URL, r.Canonical.Err)}
+	}
+
+	problems := []string{}
+	for _, mirror := range r.Mirrors {
+		switch {
+		case mirror.Err != nil:
+			problems = append(problems, fmt.Sprintf("%s: %v", mirror.URL, mirror.Err))
+		case mirror.Size != r.Canonical.Size:
+			problems = append(problems, fmt.Sprintf(
+				"%s: size %d differs from %d of %s", mirror.URL, mirror.Size, r.Canonical.Size, r.Canonical.URL,
+			))
+		case mirror.SHA256 != r.Canonical.SHA256:
+			problems = append(problems, fmt.Sprintf(
+				"%s: sha256 %s differs from %s of %s", mirror.URL, mirror.SHA256, r.Canonical.SHA256, r.Canonical.URL,
+			))
+		}
+	}
+	return problems
+}
+
+// Consistent reports whether all mirrors serve the canonical artifact.
+func (r *Result) Consistent() bool {
+	return len(r.Problems()) == 0
+}
+
+// Verifier compares the artifacts served by mirrors to the canonical objects.
+type Verifier struct {
+	// Client performs the requests. It defaults to a client with a 5 minute
+	// timeout, as the artifacts are downloaded in full.
+	Client *http.Client
+
+	// CanonicalURL is the URL of the bucket holding the canonical objects
+	CanonicalURL string
+
+	// MirrorURLs are the URLs the artifacts are published under
+	MirrorURLs []string
+
+	// Concurrency is the number of artifacts verified at the same time.
+	Concurrency int
+}
+
+// NewVerifier creates a Verifier comparing dl.k8s.io to the release bucket.
+func NewVerifier() *Verifier {
+	return &Verifier{
+		Client:       &http.Client{Timeout: 5 * time.Minute},
+		CanonicalURL: "https://storage.googleapis.com/kubernetes-release",
+		MirrorURLs:   []string{"https://dl.k8s.io"},
+		Concurrency:  4,
+	}
+}
+
+// Verify verifies all artifacts and returns a result for each of them, in the
+// same order.
+func (v *Verifier) Verify(ctx context.Context, paths []string) []*Result {
+	concurrency := v.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	results := make([]*Result, len(paths))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				results[i] = v.verify(ctx, paths[i])
+			}
+		}()
+	}
+	for i := range paths {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	return results
+}
+
+// Inconsistent returns the results of all artifacts not served consistently.
+func Inconsistent(results []*Result) []*Result {
+	inconsistent := []*Result{}
+	for _, result := range results {
+		if !result.Consistent() {
+			inconsistent = append(inconsistent, result)
+		}
+	}
+	return inconsistent
+}
+
+// verify fetches a single artifact from the bucket and all mirrors
+func (v *Verifier) verify(ctx context.Context, path string) *Result {
+	result := &Result{Path: path, Canonical: v.digest(ctx, joinURL(v.CanonicalURL, path))}
+	if result.Canonical.Err != nil {
+		return result
+	}
+	for _, mirror := range v.MirrorURLs {
+		result.Mirrors = append(result.Mirrors, v.digest(ctx, joinURL(mirror, path)))
+	}
+	return result
+}
+
+// digest downloads the URL and computes the size and checksum of its content
+func (v *Verifier) digest(ctx context.Context, url string) *Digest {
+	client := v.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	digest := &Digest{URL: url}
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		digest.Err = err
+		return digest
+	}
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		digest.Err = err
+		return digest
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		digest.Err = fmt.Errorf("%d %s", resp.StatusCode, http.StatusText(resp.StatusCode))
+		return digest
+	}
+
+	hash := sha256.New()
+	digest.Size, digest.Err = io.Copy(hash, resp.Body)
+	digest.SHA256 = hex.EncodeToString(hash.Sum(nil))
+	return digest
+}
+
+func joinURL(base, path string) string {
+	return strings.TrimSuffix(base, "/") + "/" + strings.TrimPrefix(path, "/")
+}
@@ -0,0 +1,70 @@
+package mirror
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestArtifacts(t *testing.T) {
+	artifacts := Artifacts("v1.15.0")
+	require.Contains(t, artifacts, "release/v1.15.0/kubernetes.tar.gz")
+	require.Contains(t, artifacts, "release/v1.15.0/bin/linux/amd64/kubectl")
+}
+
+func TestVerify(t *testing.T) {
+	canonical := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/release/v1.15.0/a", "/release/v1.15.0/b", "/release/v1.15.0/c":
+			w.Write([]byte("content"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer canonical.Close()
+
+	mirror := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/release/v1.15.0/a":
+			w.Write([]byte("content"))
+		case "/release/v1.15.0/b":
+			// stale content of the same size
+			w.Write([]byte("CONTENT"))
+		case "/release/v1.15.0/c":
+			w.Write([]byte("partial"[:4]))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer mirror.Close()
+
+	verifier := NewVerifier()
+	verifier.CanonicalURL = canonical.URL + "/"
+	verifier.MirrorURLs = []string{mirror.URL}
+	results := verifier.Verify(context.Background(), []string{
+		"release/v1.15.0/a",
+		"release/v1.15.0/b",
+		"release/v1.15.0/c",
+		"release/v1.15.0/missing",
+	})
+
+	require.Len(t, results, 4)
+	require.True(t, results[0].Consistent())
+	require.Equal(t, int64(7), results[0].Canonical.Size)
+	require.Equal(t, "ed7002b439e9ac845f22357d822bac1444730fbdb6016d3ec9432297b9ec9f73", results[0].Canonical.SHA256)
+
+	require.Len(t, results[1].Problems(), 1)
+	require.Contains(t, results[1].Problems()[0], mirror.URL+"/release/v1.15.0/b: sha256")
+
+	require.Equal(t, []string{
+		mirror.URL + "/release/v1.15.0/c: size 4 differs from 7 of " + canonical.URL + "/release/v1.15.0/c",
+	}, results[2].Problems())
+
+	require.Equal(t, []string{canonical.URL + "/release/v1.15.0/missing: 404 Not Found"}, results[3].Problems())
+	require.Empty(t, results[3].Mirrors)
+
+	require.Equal(t, results[1:], Inconsistent(results))
+}
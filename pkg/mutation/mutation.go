@@ -0,0 +1,436 @@
+// Copyright 2019 The Kubernetes Authors All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package mutation applies write operations on GitHub issues and PRs in bulk,
+// retrying transient failures, pacing the writes to stay below the rate
+// limits, and recording which operations have been applied so that an
+// interrupted run can be resumed.
+package mutation
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/github"
+	"github.com/pkg/errors"
+)
+
+// Kind is the type of a Mutation.
+type Kind string
+
+const (
+	// KindMilestone sets the milestone of an issue or PR
+	KindMilestone Kind = "milestone"
+
+	// KindAddLabels adds labels to an issue or PR
+	KindAddLabels Kind = "add-labels"
+
+	// KindRemoveLabel removes a label from an issue or PR
+	KindRemoveLabel Kind = "remove-label"
+
+	// KindComment comments on an issue or PR
+	KindComment Kind = "comment"
+)
+
+// Mutation is a single write operation on an issue or PR.
+type Mutation struct {
+	Kind   Kind   `json:"kind"`
+	Org    string `json:"org"`
+	Repo   string `json:"repo"`
+	Number int    `json:"number"`
+
+	// Milestone is the number of the milestone for KindMilestone
+	Milestone int `json:"milestone,omitempty"`
+
+	// Labels are the labels for KindAddLabels, or the single label for
+	// KindRemoveLabel
+	Labels []string `json:"labels,omitempty"`
+
+	// Comment is the body of the comment for KindComment
+	Comment string `json:"comment,omitempty"`
+}
+
+// ID identifies the mutation in the resume file. Mutations with the same
+// target and payload have the same ID.
+func (m *Mutation) ID() string {
+	payload := ""
+	switch m.Kind {
+	case KindMilestone:
+		payload = fmt.Sprint(m.Milestone)
+	case KindAddLabels, KindRemoveLabel:
+		payload = strings.Join(m.Labels, ",")
+	case KindComment:
+		sum := sha256.Sum256([]byte(m.Comment))
+		payload = hex.EncodeToString(sum[:6])
+	}
+	return fmt.Sprintf("%s %s/%s#%d %s", m.Kind, m.Org, m.Repo, m.Number, payload)
+}
+
+// Status is the outcome of a mutation.
+type Status string
+
+const (
+	StatusApplied Status = "applied"
+	StatusFailed  Status = "failed"
+
+	// StatusSkipped marks mutations applied by a previous run according to
+	// the resume file
+	StatusSkipped Status = "skipped"
+)
+
+// Result is the outcome of applying a single mutation.
+type Result struct {
+	Mutation *Mutation `json:"mutation"`
+	Status   Status    `json:"status"`
+	Attempts int       `json:"attempts"`
+	Error    string    `json:"error,omitempty"`
+}
+
+// Report lists the outcome of all mutations applied by an Engine.
+type Report struct {
+	Applied []*Result `json:"applied"`
+	Failed  []*Result `json:"failed"`
+	Skipped []*Result `json:"skipped"`
+}
+
+// Write writes the report as JSON.
+func (r *Report) Write(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// Engine applies mutations in batches. GitHub asks integrations to wait at
+// least a second between writes, which trigger the abuse rate limit
+// otherwise.
+type Engine struct {
+	Client *github.Client
+
+	// Interval is the minimum delay between two writes
+	Interval time.Duration
+
+	// Retries is the number of times a mutation is retried after a network
+	// error, a rate limit or a server error
+	Retries int
+
+	// Backoff is the delay before the first retry, it doubles with every
+	// further retry. Rate limits are waited out as long as GitHub asks for.
+	Backoff time.Duration
+
+	// ResumeFile is the path of a file the IDs of applied mutations are
+	// appended to. Mutations listed in it are skipped, so that a failed run
+	// can be repeated without applying mutations twice.
+	ResumeFile string
+
+	mu        sync.Mutex
+	lastWrite time.Time
+	applied   map[string]bool
+	report    Report
+}
+
+// NewEngine creates an Engine with the default pacing and retries.
+func NewEngine(client *github.Client) *Engine {
+	return &Engine{
+		Client:   client,
+		Interval: time.Second,
+		Retries:  3,
+		Backoff:  2 * time.Second,
+	}
+}
+
+// batch is a group of mutations applied by a single write
+type batch struct {
+	mutations []*Mutation
+	results   []*Result
+}
+
+// batchKey returns the key of the batch the mutation belongs to. Equal
+// mutations are applied once, and all labels added to an issue or PR are
+// added at once.
+func batchKey(m *Mutation) string {
+	if m.Kind == KindAddLabels {
+		return fmt.Sprintf("%s %s/%s#%d", m.Kind, m.Org, m.Repo, m.Number)
+	}
+	return m.ID()
+}
+
+// write returns the mutation which applies all mutations of the batch
+func (b *batch) write() *Mutation {
+	m := b.mutations[0]
+	if len(b.mutations) == 1 || m.Kind != KindAddLabels {
+		return m
+	}
+
+	merged := *m
+	merged.Labels = nil
+	seen := map[string]bool{}
+	for _, m := range b.mutations {
+		for _, label := range m.Labels {
+			if !seen[label] {
+				seen[label] = true
+				merged.Labels = append(merged.Labels, label)
+			}
+		}
+	}
+	return &merged
+}
+
+// Apply applies the mutations in batches and returns their results in the
+// order of the mutations. Failed mutations do not stop the others from being
+// applied. An error is only returned if the resume file cannot be used or the
+// context is done, in which case the remaining mutations fail without being
+// attempted.
+func (e *Engine) Apply(ctx context.Context, mutations []*Mutation) ([]*Result, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if err := e.loadResumeFile(); err != nil {
+		return nil, err
+	}
+
+	results := make([]*Result, len(mutations))
+	batches := []*batch{}
+	batchesByKey := map[string]*batch{}
+	for i, m := range mutations {
+		results[i] = &Result{Mutation: m}
+		if e.applied[m.ID()] {
+			results[i].Status = StatusSkipped
+			e.report.Skipped = append(e.report.Skipped, results[i])
+			continue
+		}
+
+		key := batchKey(m)
+		b, ok := batchesByKey[key]
+		if !ok {
+			b = &batch{}
+			batchesByKey[key] = b
+			batches = append(batches, b)
+		}
+		b.mutations = append(b.mutations, m)
+		b.results = append(b.results, results[i])
+	}
+
+	for i, b := range batches {
+		attempts, err := e.apply(ctx, b.write())
+		if ctx.Err() != nil {
+			for _, b := range batches[i:] {
+				e.fail(b, attempts, ctx.Err())
+				attempts = 0
+			}
+			return results, ctx.Err()
+		}
+		if err != nil {
+			e.fail(b, attempts, err)
+			continue
+		}
+
+		for j, result := range b.results {
+			result.Status = StatusApplied
+			result.Attempts = attempts
+			e.report.Applied = append(e.report.Applied, result)
+			if err := e.recordApplied(b.mutations[j]); err != nil {
+				return results, err
+			}
+		}
+	}
+	return results, nil
+}
+
+// fail records the failure of the mutations of the batch
+func (e *Engine) fail(b *batch, attempts int, err error) {
+	for _, result := range b.results {
+		result.Status = StatusFailed
+		result.Attempts = attempts
+		result.Error = err.Error()
+		e.report.Failed = append(e.report.Failed, result)
+	}
+}
+
+// Report returns the outcome of all mutations applied so far.
+func (e *Engine) Report() *Report {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	report := e.report
+	return &report
+}
+
+// apply applies a single mutation, retrying transient failures, and returns
+// the number of attempts
+func (e *Engine) apply(ctx context.Context, m *Mutation) (int, error) {
+	backoff := e.Backoff
+	for attempts := 1; ; attempts++ {
+		if err := e.pace(ctx); err != nil {
+			return attempts - 1, err
+		}
+		resp, err := e.write(ctx, m)
+		if err == nil {
+			return attempts, nil
+		}
+
+		wait, transient := retryDelay(resp, err, backoff)
+		if !transient || attempts > e.Retries {
+			return attempts, err
+		}
+		select {
+		case <-ctx.Done():
+			return attempts, ctx.Err()
+		case <-time.After(wait):
+		}
+
+		// a comment may have been created even though its response got lost
+		// or reported a server error, and comments are not idempotent
+		if m.Kind == KindComment {
+			exists, err := e.commentExists(ctx, m)
+			if err != nil {
+				return attempts, err
+			}
+			if exists {
+				return attempts, nil
+			}
+		}
+		backoff *= 2
+	}
+}
+
+// commentExists checks whether the comment of the mutation is among the
+// latest comments of the issue or PR
+func (e *Engine) commentExists(ctx context.Context, m *Mutation) (bool, error) {
+	comments, _, err := e.Client.Issues.ListComments(ctx, m.Org, m.Repo, m.Number, &github.IssueListCommentsOptions{
+		Sort:        "created",
+		Direction:   "desc",
+		ListOptions: github.ListOptions{PerPage: 100},
+	})
+	if err != nil {
+		return false, errors.Wrapf(err, "error checking whether %s has been applied", m.ID())
+	}
+	for _, comment := range comments {
+		if comment.GetBody() == m.Comment {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// pace waits until the interval since the last write has passed
+func (e *Engine) pace(ctx context.Context) error {
+	wait := time.Until(e.lastWrite.Add(e.Interval))
+	if wait > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+	e.lastWrite = time.Now()
+	return nil
+}
+
+// write performs the API call of the mutation
+func (e *Engine) write(ctx context.Context, m *Mutation) (*github.Response, error) {
+	var resp *github.Response
+	var err error
+	switch m.Kind {
+	case KindMilestone:
+		_, resp, err = e.Client.Issues.Edit(ctx, m.Org, m.Repo, m.Number, &github.IssueRequest{Milestone: &m.Milestone})
+	case KindAddLabels:
+		_, resp, err = e.Client.Issues.AddLabelsToIssue(ctx, m.Org, m.Repo, m.Number, m.Labels)
+	case KindRemoveLabel:
+		if len(m.Labels) != 1 {
+			return nil, fmt.Errorf("%s requires exactly one label", m.Kind)
+		}
+		resp, err = e.Client.Issues.RemoveLabelForIssue(ctx, m.Org, m.Repo, m.Number, m.Labels[0])
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			// the label is gone already
+			return resp, nil
+		}
+	case KindComment:
+		_, resp, err = e.Client.Issues.CreateComment(ctx, m.Org, m.Repo, m.Number, &github.IssueComment{Body: &m.Comment})
+	default:
+		return nil, fmt.Errorf("%q is an unsupported mutation", m.Kind)
+	}
+	return resp, errors.Wrapf(err, "error applying %s", m.ID())
+}
+
+// retryDelay returns how long to wait before retrying a failed write, and
+// whether it is worth retrying at all
+func retryDelay(resp *github.Response, err error, backoff time.Duration) (time.Duration, bool) {
+	switch e := errors.Cause(err).(type) {
+	case *github.RateLimitError:
+		return time.Until(e.Rate.Reset.Time), true
+	case *github.AbuseRateLimitError:
+		if e.RetryAfter != nil {
+			return *e.RetryAfter, true
+		}
+		return backoff, true
+	}
+	if resp == nil {
+		// a network error
+		return backoff, true
+	}
+	return backoff, resp.StatusCode >= http.StatusInternalServerError
+}
+
+// loadResumeFile reads the IDs of the mutations applied by previous runs
+func (e *Engine) loadResumeFile() error {
+	if e.applied != nil {
+		return nil
+	}
+	e.applied = map[string]bool{}
+	if e.ResumeFile == "" {
+		return nil
+	}
+
+	file, err := os.Open(e.ResumeFile)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrap(err, "error reading the resume file")
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if id := strings.TrimSpace(scanner.Text()); id != "" {
+			e.applied[id] = true
+		}
+	}
+	return errors.Wrap(scanner.Err(), "error reading the resume file")
+}
+
+// recordApplied appends the ID of the applied mutation to the resume file
+func (e *Engine) recordApplied(m *Mutation) error {
+	e.applied[m.ID()] = true
+	if e.ResumeFile == "" {
+		return nil
+	}
+	file, err := os.OpenFile(e.ResumeFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return errors.Wrap(err, "error writing the resume file")
+	}
+	if _, err := fmt.Fprintln(file, m.ID()); err != nil {
+		file.Close()
+		return errors.Wrap(err, "error writing the resume file")
+	}
+	return errors.Wrap(file.Close(), "error writing the resume file")
+}
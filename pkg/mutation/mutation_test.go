@@ -0,0 +1,243 @@
+// Copyright 2019 The Kubernetes Authors All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mutation
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/github"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApply(t *testing.T) {
+	calls := map[string]int{}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/o/r/issues/1", func(w http.ResponseWriter, r *http.Request) {
+		calls[r.Method+" "+r.URL.Path]++
+		// the first attempt fails with a transient error
+		if calls[r.Method+" "+r.URL.Path] == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		fmt.Fprint(w, `{}`)
+	})
+	mux.HandleFunc("/repos/o/r/issues/2/labels", func(w http.ResponseWriter, r *http.Request) {
+		calls[r.Method+" "+r.URL.Path]++
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		fmt.Fprint(w, `{"message": "Validation Failed"}`)
+	})
+	mux.HandleFunc("/repos/o/r/issues/3/comments", func(w http.ResponseWriter, r *http.Request) {
+		calls[r.Method+" "+r.URL.Path]++
+		fmt.Fprint(w, `{}`)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+
+	dir, err := ioutil.TempDir("", "mutation")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	engine := NewEngine(client)
+	engine.Interval = 0
+	engine.Backoff = 0
+	engine.ResumeFile = filepath.Join(dir, "resume")
+
+	mutations := []*Mutation{
+		{Kind: KindMilestone, Org: "o", Repo: "r", Number: 1, Milestone: 2},
+		{Kind: KindAddLabels, Org: "o", Repo: "r", Number: 2, Labels: []string{"nope"}},
+		{Kind: KindComment, Org: "o", Repo: "r", Number: 3, Comment: "Please reword the release note."},
+	}
+	results, err := engine.Apply(context.Background(), mutations)
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+
+	require.Equal(t, StatusApplied, results[0].Status)
+	require.Equal(t, 2, results[0].Attempts)
+	require.Equal(t, StatusFailed, results[1].Status)
+	require.Equal(t, 1, results[1].Attempts)
+	require.Contains(t, results[1].Error, "Validation Failed")
+	require.Equal(t, StatusApplied, results[2].Status)
+
+	resume, err := ioutil.ReadFile(engine.ResumeFile)
+	require.NoError(t, err)
+	require.Equal(t, []string{mutations[0].ID(), mutations[2].ID()}, strings.Split(strings.TrimSpace(string(resume)), "\n"))
+
+	// a second run only retries the failed mutation
+	engine = NewEngine(client)
+	engine.Interval = 0
+	engine.ResumeFile = filepath.Join(dir, "resume")
+	results, err = engine.Apply(context.Background(), mutations)
+	require.NoError(t, err)
+	require.Equal(t, StatusSkipped, results[0].Status)
+	require.Equal(t, StatusFailed, results[1].Status)
+	require.Equal(t, StatusSkipped, results[2].Status)
+	require.Equal(t, map[string]int{
+		"PATCH /repos/o/r/issues/1":         2,
+		"POST /repos/o/r/issues/2/labels":   2,
+		"POST /repos/o/r/issues/3/comments": 1,
+	}, calls)
+
+	report := engine.Report()
+	require.Empty(t, report.Applied)
+	require.Len(t, report.Failed, 1)
+	require.Len(t, report.Skipped, 2)
+}
+
+func TestApplyGivesUp(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+
+	engine := NewEngine(client)
+	engine.Interval = 0
+	engine.Backoff = 0
+	results, err := engine.Apply(context.Background(), []*Mutation{
+		{Kind: KindRemoveLabel, Org: "o", Repo: "r", Number: 1, Labels: []string{"do-not-merge/hold"}},
+	})
+	require.NoError(t, err)
+	require.Equal(t, StatusFailed, results[0].Status)
+	require.Equal(t, 4, results[0].Attempts)
+	require.Equal(t, 4, attempts)
+}
+
+func TestApplyBatches(t *testing.T) {
+	bodies := []string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		bodies = append(bodies, r.Method+" "+r.URL.Path+" "+strings.TrimSpace(string(body)))
+		if r.Method == http.MethodPatch {
+			fmt.Fprint(w, `{}`)
+			return
+		}
+		fmt.Fprint(w, `[]`)
+	}))
+	defer server.Close()
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+
+	engine := NewEngine(client)
+	engine.Interval = 0
+	mutations := []*Mutation{
+		{Kind: KindAddLabels, Org: "o", Repo: "r", Number: 1, Labels: []string{"a"}},
+		{Kind: KindMilestone, Org: "o", Repo: "r", Number: 1, Milestone: 2},
+		{Kind: KindAddLabels, Org: "o", Repo: "r", Number: 1, Labels: []string{"b", "a"}},
+		{Kind: KindMilestone, Org: "o", Repo: "r", Number: 1, Milestone: 2},
+	}
+	results, err := engine.Apply(context.Background(), mutations)
+	require.NoError(t, err)
+	require.Len(t, results, 4)
+	for i, result := range results {
+		require.Equal(t, mutations[i], result.Mutation)
+		require.Equal(t, StatusApplied, result.Status)
+	}
+
+	// the labels are added at once, and the milestone is set once
+	require.Equal(t, []string{
+		`POST /repos/o/r/issues/1/labels ["a","b"]`,
+		`PATCH /repos/o/r/issues/1 {"milestone":2}`,
+	}, bodies)
+	require.Len(t, engine.Report().Applied, 4)
+}
+
+func TestApplyCommentOnce(t *testing.T) {
+	posts := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/o/r/issues/1/comments", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			fmt.Fprint(w, `[{"body": "Please reword the release note."}]`)
+			return
+		}
+		// the comment is created, but the connection drops before the
+		// response is sent
+		posts++
+		conn, _, err := w.(http.Hijacker).Hijack()
+		require.NoError(t, err)
+		conn.Close()
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+
+	engine := NewEngine(client)
+	engine.Interval = 0
+	engine.Backoff = 0
+	results, err := engine.Apply(context.Background(), []*Mutation{
+		{Kind: KindComment, Org: "o", Repo: "r", Number: 1, Comment: "Please reword the release note."},
+	})
+	require.NoError(t, err)
+	require.Equal(t, StatusApplied, results[0].Status)
+	require.Equal(t, 1, posts)
+}
+
+func TestApplyCommentOnceAfterServerError(t *testing.T) {
+	posts := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/o/r/issues/1/comments", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			fmt.Fprint(w, `[{"body": "Please reword the release note."}]`)
+			return
+		}
+		// the comment is stored, but the proxy in front of the API fails
+		posts++
+		w.WriteHeader(http.StatusBadGateway)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+
+	engine := NewEngine(client)
+	engine.Interval = 0
+	engine.Backoff = 0
+	results, err := engine.Apply(context.Background(), []*Mutation{
+		{Kind: KindComment, Org: "o", Repo: "r", Number: 1, Comment: "Please reword the release note."},
+	})
+	require.NoError(t, err)
+	require.Equal(t, StatusApplied, results[0].Status)
+	require.Equal(t, 1, posts)
+}
+
+func TestMutationID(t *testing.T) {
+	require.Equal(t, "milestone o/r#1 2", (&Mutation{Kind: KindMilestone, Org: "o", Repo: "r", Number: 1, Milestone: 2}).ID())
+	require.Equal(t, "add-labels o/r#1 a,b", (&Mutation{Kind: KindAddLabels, Org: "o", Repo: "r", Number: 1, Labels: []string{"a", "b"}}).ID())
+
+	a := &Mutation{Kind: KindComment, Org: "o", Repo: "r", Number: 1, Comment: "a"}
+	b := &Mutation{Kind: KindComment, Org: "o", Repo: "r", Number: 1, Comment: "b"}
+	require.NotEqual(t, a.ID(), b.ID())
+}
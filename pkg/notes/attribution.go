@@ -0,0 +1,107 @@
+// Copyright 2019 The Kubernetes Authors All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notes
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"regexp"
+)
+
+// Attribution selects how the authors of release notes are credited.
+type Attribution string
+
+const (
+	// AttributeHandles credits authors by their GitHub handle, e.g. @alice
+	AttributeHandles Attribution = "handles"
+
+	// AttributeNames credits authors by their display name, falling back to
+	// the handle for authors without one
+	AttributeNames Attribution = "names"
+
+	// AttributeNone strips the authors from the notes
+	AttributeNone Attribution = "none"
+)
+
+// authorLinkRe matches the author links of rendered notes, including the ones
+// of merged duplicates, e.g. "[@alice](https://github.com/alice)"
+var authorLinkRe = regexp.MustCompile(`(, )?\[@([\w-]+)\]\((https://github\.com/[\w-]+)\)`)
+
+// AttributionPolicy controls the attribution of release notes, e.g. to leave
+// out the GitHub handles of contributors in the notes of downstream
+// distributions.
+type AttributionPolicy struct {
+	Attribution Attribution
+
+	// Names maps GitHub handles to display names for AttributeNames
+	Names map[string]string
+}
+
+// LoadAuthorNames reads a JSON object mapping GitHub handles to display names
+// from a file.
+func LoadAuthorNames(path string) (map[string]string, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	names := map[string]string{}
+	if err := json.Unmarshal(content, &names); err != nil {
+		return nil, NewError(ErrParse, "error parsing author names %s: %v", path, err)
+	}
+	return names, nil
+}
+
+// Validate checks that the attribution is known.
+func (p *AttributionPolicy) Validate() error {
+	switch p.Attribution {
+	case AttributeHandles, AttributeNames, AttributeNone:
+		return nil
+	}
+	return NewError(ErrValidation, "%q is an unsupported attribution", p.Attribution)
+}
+
+// Apply rewrites the authors of the notes, both in their markdown and in the
+// fields of the notes, so that all output formats are consistent. It has to
+// run after the notes have been deduplicated, as merged notes keep the
+// author links of all their duplicates.
+func (p *AttributionPolicy) Apply(notes ReleaseNoteList) {
+	if p.Attribution == AttributeHandles {
+		return
+	}
+
+	for _, note := range notes {
+		note.Markdown = authorLinkRe.ReplaceAllStringFunc(note.Markdown, func(link string) string {
+			if p.Attribution == AttributeNone {
+				return ""
+			}
+			match := authorLinkRe.FindStringSubmatch(link)
+			name, ok := p.Names[match[2]]
+			if !ok {
+				return link
+			}
+			return match[1] + "[" + name + "](" + match[3] + ")"
+		})
+
+		switch p.Attribution {
+		case AttributeNone:
+			note.Author = ""
+			note.AuthorUrl = ""
+		case AttributeNames:
+			if name, ok := p.Names[note.Author]; ok {
+				note.Author = name
+			}
+		}
+	}
+}
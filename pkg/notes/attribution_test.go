@@ -0,0 +1,44 @@
+package notes
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func attributedNotes() ReleaseNoteList {
+	return ReleaseNoteList{
+		1: {
+			Author:    "alice",
+			AuthorUrl: "https://github.com/alice",
+			Markdown:  "Fix a bug ([#1](https://github.com/o/r/pull/1), [@alice](https://github.com/alice); [#2](https://github.com/o/r/pull/2), [@bob-k8s](https://github.com/bob-k8s)) Courtesy of SIG CLI",
+		},
+	}
+}
+
+func TestAttributionPolicy(t *testing.T) {
+	notes := attributedNotes()
+	(&AttributionPolicy{Attribution: AttributeHandles}).Apply(notes)
+	require.Equal(t, attributedNotes(), notes)
+
+	notes = attributedNotes()
+	(&AttributionPolicy{Attribution: AttributeNames, Names: map[string]string{"alice": "Alice Liddell"}}).Apply(notes)
+	require.Equal(t, "Fix a bug ([#1](https://github.com/o/r/pull/1), [Alice Liddell](https://github.com/alice); [#2](https://github.com/o/r/pull/2), [@bob-k8s](https://github.com/bob-k8s)) Courtesy of SIG CLI", notes[1].Markdown)
+	require.Equal(t, "Alice Liddell", notes[1].Author)
+	require.Equal(t, "https://github.com/alice", notes[1].AuthorUrl)
+
+	notes = attributedNotes()
+	(&AttributionPolicy{Attribution: AttributeNone}).Apply(notes)
+	require.Equal(t, "Fix a bug ([#1](https://github.com/o/r/pull/1); [#2](https://github.com/o/r/pull/2)) Courtesy of SIG CLI", notes[1].Markdown)
+	require.Empty(t, notes[1].Author)
+	require.Empty(t, notes[1].AuthorUrl)
+
+	// the fields are kept in the JSON output, blanked
+	content, err := json.Marshal(notes[1])
+	require.NoError(t, err)
+	require.Contains(t, string(content), `"author":"","author_url":""`)
+
+	require.True(t, errors.Is((&AttributionPolicy{Attribution: "initials"}).Validate(), ErrValidation))
+}
@@ -0,0 +1,172 @@
+// Copyright 2019 The Kubernetes Authors All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notes
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// AuditEntry is the record of a single API call.
+type AuditEntry struct {
+	Time   time.Time `json:"time"`
+	Actor  string    `json:"actor"`
+	Method string    `json:"method"`
+
+	// Target is the URL of the call, without credentials
+	Target string `json:"target"`
+
+	// Parameters is the JSON body of a mutating call
+	Parameters json.RawMessage `json:"parameters,omitempty"`
+
+	// Status is the HTTP status of the response, or zero if the call failed
+	// without a response
+	Status int `json:"status"`
+
+	// Error is the error of a call which failed without a response
+	Error string `json:"error,omitempty"`
+
+	Duration time.Duration `json:"duration_ns"`
+}
+
+// AuditLog records every call made through the transports it wraps as a line
+// of JSON to a file, for traceability of release automation.
+//
+// To use it, wrap the transport of the GitHub client:
+//
+//	audit, err := NewAuditLog("audit.jsonl", "alice")
+//	httpClient.Transport = audit.Wrap(httpClient.Transport)
+//	defer audit.Close()
+type AuditLog struct {
+	path  string
+	actor string
+
+	mu   sync.Mutex
+	file *os.File
+	enc  *json.Encoder
+}
+
+// NewAuditLog creates an AuditLog writing to the file at the given path, which
+// is truncated. All calls are attributed to the actor.
+func NewAuditLog(path, actor string) (*AuditLog, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	return &AuditLog{
+		path:  path,
+		actor: actor,
+		file:  file,
+		enc:   json.NewEncoder(file),
+	}, nil
+}
+
+// Wrap returns an http.RoundTripper which performs calls using the given
+// transport, which defaults to http.DefaultTransport, and records them.
+func (a *AuditLog) Wrap(transport http.RoundTripper) http.RoundTripper {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	return &auditTransport{log: a, transport: transport}
+}
+
+type auditTransport struct {
+	log       *AuditLog
+	transport http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *auditTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	entry := &AuditEntry{
+		Time:   time.Now().UTC(),
+		Actor:  t.log.actor,
+		Method: req.Method,
+		Target: sanitizeURL(req.URL),
+	}
+
+	if req.Body != nil && req.Method != http.MethodGet && req.Method != http.MethodHead {
+		body, r, err := requestBody(req)
+		if err != nil {
+			return nil, err
+		}
+		req = r
+		if json.Valid(body) {
+			entry.Parameters = body
+		}
+	}
+
+	resp, err := t.transport.RoundTrip(req)
+	entry.Duration = time.Since(entry.Time)
+	if err != nil {
+		entry.Error = err.Error()
+	} else {
+		entry.Status = resp.StatusCode
+	}
+
+	if writeErr := t.log.write(entry); writeErr != nil && err == nil {
+		// an unaudited call must not go unnoticed
+		resp.Body.Close()
+		return nil, writeErr
+	}
+	return resp, err
+}
+
+// requestBody returns the body of the request without modifying it. Unless
+// the request can provide a copy of its body, the body is read from a copy of
+// the request, which is returned to be sent instead.
+func requestBody(req *http.Request) ([]byte, *http.Request, error) {
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, nil, err
+		}
+		defer body.Close()
+		content, err := ioutil.ReadAll(body)
+		return content, req, err
+	}
+
+	content, err := ioutil.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, nil, err
+	}
+	r := new(http.Request)
+	*r = *req
+	r.Body = ioutil.NopCloser(bytes.NewReader(content))
+	return content, r, nil
+}
+
+func (a *AuditLog) write(entry *AuditEntry) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.enc.Encode(entry)
+}
+
+// Path returns the path of the audit log file.
+func (a *AuditLog) Path() string {
+	return a.path
+}
+
+// Close closes the audit log file.
+func (a *AuditLog) Close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.file.Close()
+}
@@ -0,0 +1,120 @@
+package notes
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditLog(t *testing.T) {
+	dir, err := ioutil.TempDir("", "audit-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "audit.jsonl")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		if r.Method == http.MethodPatch {
+			// the body is still passed on after being audited
+			require.Equal(t, `{"milestone":2}`, string(body))
+			w.WriteHeader(http.StatusUnprocessableEntity)
+		}
+	}))
+	defer server.Close()
+
+	audit, err := NewAuditLog(path, "alice")
+	require.NoError(t, err)
+	require.Equal(t, path, audit.Path())
+	client := &http.Client{Transport: audit.Wrap(nil)}
+
+	resp, err := client.Get(server.URL + "/repos/o/r/pulls/1?access_token=secret")
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	req, err := http.NewRequest(http.MethodPatch, server.URL+"/repos/o/r/issues/1", strings.NewReader(`{"milestone":2}`))
+	require.NoError(t, err)
+	resp, err = client.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	require.NoError(t, audit.Close())
+
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+
+	entries := []*AuditEntry{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		entry := &AuditEntry{}
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), entry))
+		entries = append(entries, entry)
+	}
+	require.Len(t, entries, 2)
+
+	require.Equal(t, "alice", entries[0].Actor)
+	require.Equal(t, http.MethodGet, entries[0].Method)
+	require.Equal(t, server.URL+"/repos/o/r/pulls/1", entries[0].Target)
+	require.Equal(t, http.StatusOK, entries[0].Status)
+	require.Empty(t, entries[0].Parameters)
+
+	require.Equal(t, http.MethodPatch, entries[1].Method)
+	require.Equal(t, `{"milestone":2}`, string(entries[1].Parameters))
+	require.Equal(t, http.StatusUnprocessableEntity, entries[1].Status)
+}
+
+func TestAuditLogRedactsSlackWebhooks(t *testing.T) {
+	u, err := url.Parse("https://hooks.slack.com/services/T000/B000/secret")
+	require.NoError(t, err)
+	require.Equal(t, "https://hooks.slack.com/services/redacted", sanitizeURL(u))
+}
+
+func TestAuditLogKeepsRequest(t *testing.T) {
+	dir, err := ioutil.TempDir("", "audit-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	bodies := []string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		bodies = append(bodies, string(body))
+	}))
+	defer server.Close()
+
+	audit, err := NewAuditLog(filepath.Join(dir, "audit.jsonl"), "alice")
+	require.NoError(t, err)
+	defer audit.Close()
+	transport := audit.Wrap(nil)
+
+	// a request providing a copy of its body
+	req, err := http.NewRequest(http.MethodPost, server.URL, strings.NewReader(`{"a":1}`))
+	require.NoError(t, err)
+	body := req.Body
+	resp, err := transport.RoundTrip(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.True(t, body == req.Body)
+
+	// a request without a copy of its body
+	req, err = http.NewRequest(http.MethodPost, server.URL, ioutil.NopCloser(strings.NewReader(`{"b":2}`)))
+	require.NoError(t, err)
+	require.Nil(t, req.GetBody)
+	body = req.Body
+	resp, err = transport.RoundTrip(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.True(t, body == req.Body)
+
+	require.Equal(t, []string{`{"a":1}`, `{"b":2}`}, bodies)
+}
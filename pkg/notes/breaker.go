@@ -0,0 +1,256 @@
+// Copyright 2019 The Kubernetes Authors All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notes
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// The states of the circuit of a dependency.
+const (
+	// CircuitClosed lets all calls through
+	CircuitClosed = "closed"
+
+	// CircuitOpen rejects all calls until the cooldown has passed
+	CircuitOpen = "open"
+
+	// CircuitHalfOpen lets a single trial call through after the cooldown,
+	// which closes the circuit if it succeeds and opens it again otherwise
+	CircuitHalfOpen = "half-open"
+)
+
+// DependencyHealth is the health of an external dependency during a run.
+type DependencyHealth struct {
+	Name  string `json:"name"`
+	State string `json:"state"`
+
+	Calls    int `json:"calls"`
+	Failures int `json:"failures"`
+
+	// Rejected is the number of calls rejected while the circuit was open
+	Rejected int `json:"rejected"`
+
+	LastError string `json:"last_error,omitempty"`
+}
+
+// circuit is the state of the circuit of a dependency
+type circuit struct {
+	DependencyHealth
+
+	consecutive int
+	openedAt    time.Time
+	trial       bool
+}
+
+// CircuitBreaker tracks the failures of the external dependencies of a run,
+// e.g. the GitHub API or a GCS bucket, and stops calling a dependency for a
+// while after repeated failures, so that a degraded service is not hammered
+// and the run fails fast with ErrUnavailable. The health of all dependencies
+// tells operators whether a failed run is caused by an outage.
+//
+// To use it, wrap the transport of every client of a dependency:
+//
+//	breaker := NewCircuitBreaker()
+//	httpClient.Transport = breaker.Wrap("github", httpClient.Transport)
+type CircuitBreaker struct {
+	// Threshold is the number of consecutive failures which opens the circuit
+	Threshold int
+
+	// Cooldown is how long an open circuit rejects calls
+	Cooldown time.Duration
+
+	mu           sync.Mutex
+	dependencies map[string]*circuit
+
+	// now is replaced in tests
+	now func() time.Time
+}
+
+// NewCircuitBreaker creates a CircuitBreaker opening the circuit after 5
+// consecutive failures for 30 seconds.
+func NewCircuitBreaker() *CircuitBreaker {
+	return &CircuitBreaker{
+		Threshold:    5,
+		Cooldown:     30 * time.Second,
+		dependencies: map[string]*circuit{},
+		now:          time.Now,
+	}
+}
+
+// Call calls fn unless the circuit of the dependency is open, and records
+// whether it failed. Calls rejected by an open circuit fail with an error of
+// the ErrUnavailable category.
+func (b *CircuitBreaker) Call(dependency string, fn func() error) error {
+	if err := b.acquire(dependency); err != nil {
+		return err
+	}
+	err := fn()
+	b.record(dependency, err)
+	return err
+}
+
+// acquire checks whether a call of the dependency may be made
+func (b *CircuitBreaker) acquire(dependency string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	d := b.dependency(dependency)
+	switch d.State {
+	case CircuitOpen:
+		if b.now().Sub(d.openedAt) < b.Cooldown {
+			d.Rejected++
+			return NewError(ErrUnavailable, "%s failed %d times in a row, not calling it for %s: %s", dependency, d.consecutive, b.Cooldown, d.LastError)
+		}
+		d.State = CircuitHalfOpen
+		d.trial = true
+	case CircuitHalfOpen:
+		// only a single trial call is made at a time
+		if d.trial {
+			d.Rejected++
+			return NewError(ErrUnavailable, "%s is being probed after %d failures in a row: %s", dependency, d.consecutive, d.LastError)
+		}
+		d.trial = true
+	}
+	d.Calls++
+	return nil
+}
+
+// record records the outcome of a call of the dependency
+func (b *CircuitBreaker) record(dependency string, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	d := b.dependency(dependency)
+	d.trial = false
+	if err == nil {
+		d.consecutive = 0
+		d.State = CircuitClosed
+		return
+	}
+
+	d.Failures++
+	d.consecutive++
+	d.LastError = err.Error()
+	if d.State == CircuitHalfOpen || d.consecutive >= b.Threshold {
+		d.State = CircuitOpen
+		d.openedAt = b.now()
+	}
+}
+
+// release ends a call of the dependency without recording its outcome
+func (b *CircuitBreaker) release(dependency string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.dependency(dependency).trial = false
+}
+
+func (b *CircuitBreaker) dependency(name string) *circuit {
+	d, ok := b.dependencies[name]
+	if !ok {
+		d = &circuit{DependencyHealth: DependencyHealth{Name: name, State: CircuitClosed}}
+		b.dependencies[name] = d
+	}
+	return d
+}
+
+// Health returns the health of all dependencies called so far, sorted by
+// name.
+func (b *CircuitBreaker) Health() []*DependencyHealth {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	health := make([]*DependencyHealth, 0, len(b.dependencies))
+	for _, d := range b.dependencies {
+		copied := d.DependencyHealth
+		health = append(health, &copied)
+	}
+	sort.Slice(health, func(i, j int) bool { return health[i].Name < health[j].Name })
+	return health
+}
+
+// Wrap returns an http.RoundTripper which performs requests of the dependency
+// using the given transport, which defaults to http.DefaultTransport, unless
+// its circuit is open. Errors and server errors count as failures, client
+// errors like 404 and validation errors of the wrapped transport do not.
+func (b *CircuitBreaker) Wrap(dependency string, transport http.RoundTripper) http.RoundTripper {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	return &breakerTransport{breaker: b, dependency: dependency, transport: transport}
+}
+
+type breakerTransport struct {
+	breaker    *CircuitBreaker
+	dependency string
+	transport  http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *breakerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.breaker.acquire(t.dependency); err != nil {
+		return nil, err
+	}
+
+	resp, err := t.transport.RoundTrip(req)
+	switch {
+	case err != nil && req.Context().Err() != nil:
+		// cancelled calls say nothing about the health of the dependency
+		t.breaker.release(t.dependency)
+	case Kind(err) == ErrValidation:
+		// neither do requests rejected before being sent, e.g. writes
+		// blocked by ReadOnly
+		t.breaker.release(t.dependency)
+	case err != nil:
+		t.breaker.record(t.dependency, err)
+	case resp.StatusCode >= http.StatusInternalServerError:
+		t.breaker.record(t.dependency, fmt.Errorf("%s %s: %s", req.Method, sanitizeURL(req.URL), resp.Status))
+	default:
+		t.breaker.record(t.dependency, nil)
+	}
+	return resp, err
+}
+
+// WrapStore returns a Store calling the given store as the dependency, unless
+// its circuit is open.
+func (b *CircuitBreaker) WrapStore(dependency string, store Store) Store {
+	return &breakerStore{breaker: b, dependency: dependency, store: store}
+}
+
+type breakerStore struct {
+	breaker    *CircuitBreaker
+	dependency string
+	store      Store
+}
+
+// Get implements Store.
+func (s *breakerStore) Get(ctx context.Context, key string) (value []byte, ok bool, err error) {
+	err = s.breaker.Call(s.dependency, func() error {
+		value, ok, err = s.store.Get(ctx, key)
+		return err
+	})
+	return value, ok, err
+}
+
+// Put implements Store.
+func (s *breakerStore) Put(ctx context.Context, key string, value []byte) error {
+	return s.breaker.Call(s.dependency, func() error {
+		return s.store.Put(ctx, key, value)
+	})
+}
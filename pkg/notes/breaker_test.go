@@ -0,0 +1,115 @@
+package notes
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	failing := true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		if failing {
+			http.Error(w, "unavailable", http.StatusBadGateway)
+		}
+	}))
+	defer server.Close()
+
+	now := time.Now()
+	breaker := NewCircuitBreaker()
+	breaker.Threshold = 2
+	breaker.now = func() time.Time { return now }
+	client := &http.Client{Transport: breaker.Wrap("github", nil)}
+
+	get := func(path string) error {
+		resp, err := client.Get(server.URL + path)
+		if err == nil {
+			resp.Body.Close()
+		}
+		return err
+	}
+
+	// client errors are no failures of the dependency
+	require.NoError(t, get("/missing"))
+	require.NoError(t, get("/"))
+	require.NoError(t, get("/"))
+	require.Equal(t, CircuitOpen, breaker.Health()[0].State)
+
+	// the open circuit rejects calls until the cooldown has passed
+	err := get("/")
+	require.Error(t, err)
+	require.True(t, errors.Is(err, ErrUnavailable))
+
+	// a failing trial opens the circuit again, a successful one closes it
+	now = now.Add(breaker.Cooldown)
+	require.NoError(t, get("/"))
+	require.Equal(t, CircuitOpen, breaker.Health()[0].State)
+	now = now.Add(breaker.Cooldown)
+	failing = false
+	require.NoError(t, get("/"))
+
+	require.Equal(t, []*DependencyHealth{{
+		Name:      "github",
+		State:     CircuitClosed,
+		Calls:     5,
+		Failures:  3,
+		Rejected:  1,
+		LastError: "GET " + server.URL + "/: 502 Bad Gateway",
+	}}, breaker.Health())
+}
+
+func TestCircuitBreakerReadOnly(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	breaker := NewCircuitBreaker()
+	breaker.Threshold = 1
+	client := &http.Client{Transport: breaker.Wrap("github", ReadOnly(nil))}
+
+	// writes blocked by the embargo do not open the circuit
+	_, err := client.Post(server.URL, "text/plain", nil)
+	require.Error(t, err)
+	resp, err := client.Get(server.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	health := breaker.Health()
+	require.Len(t, health, 1)
+	require.Equal(t, CircuitClosed, health[0].State)
+	require.Equal(t, 0, health[0].Failures)
+}
+
+func TestCircuitBreakerStore(t *testing.T) {
+	breaker := NewCircuitBreaker()
+	breaker.Threshold = 1
+	store := breaker.WrapStore("gcs", &failingStore{})
+
+	_, _, err := store.Get(context.Background(), "key")
+	require.EqualError(t, err, "bucket unavailable")
+	err = store.Put(context.Background(), "key", nil)
+	require.Equal(t, ErrUnavailable, Kind(err))
+
+	health := breaker.Health()
+	require.Len(t, health, 1)
+	require.Equal(t, 1, health[0].Calls)
+	require.Equal(t, 1, health[0].Rejected)
+}
+
+type failingStore struct{}
+
+func (s *failingStore) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	return nil, false, errors.New("bucket unavailable")
+}
+
+func (s *failingStore) Put(ctx context.Context, key string, value []byte) error {
+	return errors.New("bucket unavailable")
+}
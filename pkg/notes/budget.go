@@ -0,0 +1,201 @@
+// Copyright 2019 The Kubernetes Authors All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/google/go-github/github"
+)
+
+// UnauthenticatedRateLimit is the number of GitHub API requests per hour
+// allowed without a token.
+const UnauthenticatedRateLimit = 60
+
+// ErrQuotaExhausted is the reason of the InterruptedError returned alongside
+// the partial results if PRs have been skipped because the QuotaBudget does
+// not cover them, and the error of requests made beyond the budget.
+var ErrQuotaExhausted = &Error{Kind: ErrRateLimited, Err: errors.New("the API quota budget is exhausted")}
+
+// PullRequestRef identifies a PR to be fetched.
+type PullRequestRef struct {
+	Org    string
+	Repo   string
+	Number int
+}
+
+func (r PullRequestRef) String() string {
+	return fmt.Sprintf("%s/%s#%d", r.Org, r.Repo, r.Number)
+}
+
+// QuotaBudget limits the number of GitHub API requests of a run, so that a
+// run without a token, which is only allowed UnauthenticatedRateLimit
+// requests per hour, completes with the PRs it can afford instead of dying
+// halfway through with a rate limit error.
+//
+// The PRs are planned up front: the ones in the cache are free, the budget is
+// spent on the others in order, and the ones beyond it are skipped. To use it,
+// wrap the transport of the GitHub client below the cache and pass it with
+// WithQuotaBudget:
+//
+//	budget := NewQuotaBudget(UnauthenticatedRateLimit)
+//	budget.Cache = cache
+//	httpClient.Transport = cache.Wrap(budget.Wrap(httpClient.Transport))
+type QuotaBudget struct {
+	// Cache is the cache in front of the budget, which answers requests
+	// without spending it
+	Cache *ResponseCache
+
+	limit int
+
+	mu    sync.Mutex
+	spent int
+
+	// observed is the remaining rate limit as last reported by GitHub, or -1
+	observed int
+}
+
+// NewQuotaBudget creates a QuotaBudget allowing the given number of requests.
+func NewQuotaBudget(limit int) *QuotaBudget {
+	return &QuotaBudget{limit: limit, observed: -1}
+}
+
+// Remaining returns the number of requests which can still be made, which is
+// also bounded by the rate limit GitHub reports.
+func (b *QuotaBudget) Remaining() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.remaining()
+}
+
+func (b *QuotaBudget) remaining() int {
+	remaining := b.limit - b.spent
+	if b.observed >= 0 && b.observed < remaining {
+		remaining = b.observed
+	}
+	if remaining < 0 {
+		return 0
+	}
+	return remaining
+}
+
+// Spent returns the number of requests made.
+func (b *QuotaBudget) Spent() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.spent
+}
+
+// Plan returns the PRs which cannot be fetched within the remaining budget,
+// assuming that every PR not in the cache takes one request.
+func (b *QuotaBudget) Plan(ctx context.Context, client *github.Client, prs []PullRequestRef) []PullRequestRef {
+	allowance := b.Remaining()
+	skipped := []PullRequestRef{}
+	for _, pr := range prs {
+		if b.cached(ctx, client, pr) {
+			continue
+		}
+		if allowance > 0 {
+			allowance--
+			continue
+		}
+		skipped = append(skipped, pr)
+	}
+	return skipped
+}
+
+// cached reports whether the PR would be fetched from the cache
+func (b *QuotaBudget) cached(ctx context.Context, client *github.Client, pr PullRequestRef) bool {
+	if b.Cache == nil {
+		return false
+	}
+	u, err := client.BaseURL.Parse(fmt.Sprintf("repos/%v/%v/pulls/%d", pr.Org, pr.Repo, pr.Number))
+	if err != nil {
+		return false
+	}
+	return b.Cache.Contains(ctx, u)
+}
+
+// Wrap returns an http.RoundTripper which performs requests using the given
+// transport, which defaults to http.DefaultTransport, as long as the budget
+// allows and fails with ErrQuotaExhausted afterwards.
+func (b *QuotaBudget) Wrap(transport http.RoundTripper) http.RoundTripper {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	return &budgetTransport{budget: b, transport: transport}
+}
+
+type budgetTransport struct {
+	budget    *QuotaBudget
+	transport http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *budgetTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.budget.mu.Lock()
+	if t.budget.remaining() == 0 {
+		t.budget.mu.Unlock()
+		return nil, ErrQuotaExhausted
+	}
+	t.budget.spent++
+	t.budget.mu.Unlock()
+
+	resp, err := t.transport.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+	if remaining, err := strconv.Atoi(resp.Header.Get("X-RateLimit-Remaining")); err == nil {
+		t.budget.mu.Lock()
+		t.budget.observed = remaining
+		t.budget.mu.Unlock()
+	}
+	return resp, nil
+}
+
+// planBudget returns the PRs the quota budget of the options does not cover,
+// and warns about them up front
+func (c *githubApiConfig) planBudget(logger log.Logger, client *github.Client, prs []PullRequestRef) map[PullRequestRef]bool {
+	if c.budget == nil {
+		return nil
+	}
+	skipped := c.budget.Plan(c.ctx, client, prs)
+	if len(skipped) == 0 {
+		return nil
+	}
+
+	set := make(map[PullRequestRef]bool, len(skipped))
+	names := make([]string, 0, len(skipped))
+	for _, pr := range skipped {
+		set[pr] = true
+		names = append(names, pr.String())
+	}
+	level.Warn(logger).Log(
+		"msg", "the API quota budget does not cover all PRs, skipping the uncached ones beyond it",
+		"remaining", c.budget.Remaining(),
+		"planned", len(prs)-len(skipped),
+		"skipped", len(skipped),
+		"prs", strings.Join(names, ","),
+	)
+	return set
+}
@@ -0,0 +1,75 @@
+package notes
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"github.com/google/go-github/github"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuotaBudget(t *testing.T) {
+	var server *httptest.Server
+	mux := http.NewServeMux()
+	mux.HandleFunc("/search/issues", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"total_count": 3, "items": [
+			{"number": 1, "repository_url": "%[1]s/repos/o/r", "pull_request": {}},
+			{"number": 2, "repository_url": "%[1]s/repos/o/r", "pull_request": {}},
+			{"number": 3, "repository_url": "%[1]s/repos/o/r", "pull_request": {}}
+		]}`, server.URL)
+	})
+	mux.HandleFunc("/repos/o/r/pulls/", func(w http.ResponseWriter, r *http.Request) {
+		number := r.URL.Path[len("/repos/o/r/pulls/"):]
+		fmt.Fprintf(w, `{"number": %s, "body": %q, "base": {"repo": {"url": "%s/repos/o/r"}}}`,
+			number, "```release-note\nNote of PR "+number+"\n```", server.URL)
+	})
+	server = httptest.NewServer(mux)
+	defer server.Close()
+
+	// PR 2 has been fetched before
+	cache := NewResponseCache()
+	resp, err := (&http.Client{Transport: cache.Wrap(nil)}).Get(server.URL + "/repos/o/r/pulls/2")
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	// the search and PR 1 use up the budget, PR 2 is free and PR 3 is skipped
+	budget := NewQuotaBudget(2)
+	budget.Cache = cache
+	client := github.NewClient(&http.Client{Transport: cache.Wrap(budget.Wrap(nil))})
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+
+	notes, err := ListReleaseNotesFromSearch(client, log.NewNopLogger(), "repo:o/r", "v1.15.0", WithQuotaBudget(budget))
+	interrupted, ok := err.(*InterruptedError)
+	require.True(t, ok, "unexpected error %v", err)
+	require.Equal(t, ErrQuotaExhausted, interrupted.Err)
+	require.Equal(t, 2, interrupted.Processed)
+	require.Equal(t, 3, interrupted.Total)
+	require.Len(t, notes, 2)
+	require.Equal(t, "Note of PR 1", notes[1].Text)
+	require.Equal(t, "Note of PR 2", notes[2].Text)
+	require.Equal(t, 2, budget.Spent())
+	require.Zero(t, budget.Remaining())
+
+	// requests beyond the budget are refused
+	_, _, err = client.PullRequests.Get(context.Background(), "o", "r", 3)
+	require.Equal(t, ErrRateLimited, Kind(err))
+	require.Equal(t, 2, budget.Spent())
+}
+
+func TestQuotaBudgetObservesRateLimit(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Remaining", "3")
+	}))
+	defer server.Close()
+
+	budget := NewQuotaBudget(UnauthenticatedRateLimit)
+	resp, err := (&http.Client{Transport: budget.Wrap(nil)}).Get(server.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, 3, budget.Remaining())
+}
@@ -0,0 +1,186 @@
+// Copyright 2019 The Kubernetes Authors All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notes
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// ResponseCache caches successful GET responses in a Store, so that clients
+// sharing it fetch every resource only once. This is useful when generating
+// the release notes of several branches at once, whose commit ranges often
+// touch the same PRs and commits, and, with a persistent Store, across runs.
+//
+// To use it, wrap the transport of every GitHub client which should share it:
+//
+//	cache := NewResponseCache()
+//	httpClient.Transport = cache.Wrap(httpClient.Transport)
+type ResponseCache struct {
+	// MaxAge is the age after which a cached response is fetched again, as
+	// PRs change while a release is prepared. Zero means that responses never
+	// expire, which is only safe for the lifetime of a single run.
+	MaxAge time.Duration
+
+	// Identity separates the responses fetched with different credentials,
+	// e.g. the GitHub token, so that a shared store never serves responses
+	// only visible to one token, like those of private repositories, to
+	// another. Only a hash of it is kept in the store. Empty for clients
+	// without credentials.
+	Identity string
+
+	store Store
+
+	mu   sync.Mutex
+	hits int
+}
+
+// cachedResponse is a response as kept in the Store
+type cachedResponse struct {
+	Time     time.Time        `json:"time"`
+	Response RecordedResponse `json:"response"`
+}
+
+// NewResponseCache creates an empty ResponseCache kept in memory.
+func NewResponseCache() *ResponseCache {
+	return NewResponseCacheWithStore(NewMemoryStore())
+}
+
+// NewResponseCacheWithStore creates a ResponseCache kept in the store.
+func NewResponseCacheWithStore(store Store) *ResponseCache {
+	return &ResponseCache{store: store}
+}
+
+// Wrap returns an http.RoundTripper which answers GET requests from the cache
+// if possible, and performs all other requests using the given transport,
+// which defaults to http.DefaultTransport.
+func (c *ResponseCache) Wrap(transport http.RoundTripper) http.RoundTripper {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	return &cacheTransport{cache: c, transport: transport}
+}
+
+// Hits returns the number of requests answered from the cache.
+func (c *ResponseCache) Hits() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.hits
+}
+
+// Contains reports whether a GET request of the URL would be answered from
+// the cache.
+func (c *ResponseCache) Contains(ctx context.Context, u *url.URL) bool {
+	return c.lookup(ctx, u) != nil
+}
+
+// get returns the cached response of the request, if any.
+func (c *ResponseCache) get(req *http.Request) *RecordedResponse {
+	cached := c.lookup(req.Context(), req.URL)
+	if cached == nil {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.hits++
+	return cached
+}
+
+// lookup returns the cached response of the URL, if any. Errors of the store
+// are treated as cache misses, so that an unavailable store only makes the
+// run slower.
+func (c *ResponseCache) lookup(ctx context.Context, u *url.URL) *RecordedResponse {
+	value, ok, err := c.store.Get(ctx, c.key(u))
+	if err != nil || !ok {
+		return nil
+	}
+	cached := &cachedResponse{}
+	if err := json.Unmarshal(value, cached); err != nil {
+		return nil
+	}
+	if c.MaxAge > 0 && time.Since(cached.Time) > c.MaxAge {
+		return nil
+	}
+	return &cached.Response
+}
+
+func (c *ResponseCache) put(req *http.Request, resp *RecordedResponse) {
+	value, err := json.Marshal(&cachedResponse{Time: time.Now().UTC(), Response: *resp})
+	if err != nil {
+		return
+	}
+	c.store.Put(req.Context(), c.key(req.URL), value)
+}
+
+// key hashes the URL without credentials, and the identity of the
+// credentials if any
+func (c *ResponseCache) key(u *url.URL) string {
+	key := sanitizeURL(u)
+	if c.Identity != "" {
+		key = c.Identity + "\n" + key
+	}
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+type cacheTransport struct {
+	cache     *ResponseCache
+	transport http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *cacheTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet {
+		return t.transport.RoundTrip(req)
+	}
+
+	if cached := t.cache.get(req); cached != nil {
+		return cached.toResponse(req), nil
+	}
+
+	resp, err := t.transport.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return resp, err
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	header := http.Header{}
+	for _, key := range recordedHeaders {
+		if value, ok := resp.Header[key]; ok {
+			header[key] = value
+		}
+	}
+	t.cache.put(req, &RecordedResponse{
+		StatusCode: resp.StatusCode,
+		Header:     header,
+		Body:       string(body),
+	})
+	return resp, nil
+}
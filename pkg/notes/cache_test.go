@@ -0,0 +1,102 @@
+package notes
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestResponseCache(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(r.Method + " " + r.URL.Path))
+	}))
+	defer server.Close()
+
+	cache := NewResponseCache()
+	clients := []*http.Client{
+		{Transport: cache.Wrap(nil)},
+		{Transport: cache.Wrap(nil)},
+	}
+
+	do := func(client *http.Client, method, path string) string {
+		req, err := http.NewRequest(method, server.URL+path, strings.NewReader(""))
+		require.NoError(t, err)
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, err := ioutil.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return string(body)
+	}
+
+	for _, client := range clients {
+		require.Equal(t, "GET /pulls/1", do(client, http.MethodGet, "/pulls/1"))
+		require.Equal(t, "POST /pulls", do(client, http.MethodPost, "/pulls"))
+		do(client, http.MethodGet, "/missing")
+	}
+
+	// only the successful GET is answered from the cache
+	require.Equal(t, 5, requests)
+	require.Equal(t, 1, cache.Hits())
+}
+
+func TestResponseCacheWithStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cache-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("X-RateLimit-Remaining", "4999")
+		w.Write([]byte(r.URL.Path))
+	}))
+	defer server.Close()
+
+	get := func(cache *ResponseCache) {
+		resp, err := (&http.Client{Transport: cache.Wrap(nil)}).Get(server.URL + "/pulls/1")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, err := ioutil.ReadAll(resp.Body)
+		require.NoError(t, err)
+		require.Equal(t, "/pulls/1", string(body))
+	}
+
+	// a later run with the same store is answered from it
+	for run := 0; run < 2; run++ {
+		store, err := NewFileStore(dir)
+		require.NoError(t, err)
+		get(NewResponseCacheWithStore(store))
+	}
+	require.Equal(t, 1, requests)
+
+	// expired responses are fetched again
+	store, err := NewFileStore(dir)
+	require.NoError(t, err)
+	cache := NewResponseCacheWithStore(store)
+	cache.MaxAge = time.Nanosecond
+	time.Sleep(time.Millisecond)
+	get(cache)
+	require.Equal(t, 2, requests)
+	require.Zero(t, cache.Hits())
+
+	// the responses fetched with another token are not shared
+	cache = NewResponseCacheWithStore(store)
+	cache.Identity = "another-token"
+	get(cache)
+	require.Equal(t, 3, requests)
+	get(cache)
+	require.Equal(t, 3, requests)
+}
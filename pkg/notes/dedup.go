@@ -0,0 +1,236 @@
+// Copyright 2019 The Kubernetes Authors All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notes
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Merge describes release notes which have been merged into another one
+// because they describe the same change.
+type Merge struct {
+	// Into is the PR number of the note the others have been merged into
+	Into int `json:"into"`
+
+	// Merged are the PR numbers of the notes which have been merged
+	Merged []int `json:"merged"`
+
+	// Reasons explain why each of the merged notes is a duplicate, keyed by
+	// PR number
+	Reasons map[int]string `json:"reasons"`
+}
+
+// Deduplicator detects release notes which describe the same change and
+// merges them.
+type Deduplicator struct {
+	// Threshold is the similarity of the normalized texts of two notes, from
+	// 0 to 1, above which they are considered duplicates.
+	Threshold float64
+}
+
+// NewDeduplicator creates a Deduplicator with the default threshold.
+func NewDeduplicator() *Deduplicator {
+	return &Deduplicator{Threshold: 0.8}
+}
+
+// issueRefRe matches links to GitHub issues, e.g. KEP tracking issues
+var issueRefRe = regexp.MustCompile(`github\.com/[\w.-]+/[\w.-]+/issues/\d+`)
+
+// Deduplicate merges all notes which are duplicates of each other into the
+// note with the lowest PR number and removes the others from the list. Notes
+// are duplicates if their texts are similar or if they reference the same KEP
+// or issue. The merged note is attributed to all PRs and authors and carries
+// the union of their labels and documentation.
+func (d *Deduplicator) Deduplicate(notes ReleaseNoteList) []*Merge {
+	prs := make([]int, 0, len(notes))
+	for pr := range notes {
+		prs = append(prs, pr)
+	}
+	sort.Ints(prs)
+
+	// group the duplicates, every group is represented by its lowest PR
+	root := map[int]int{}
+	find := func(pr int) int {
+		for root[pr] != pr {
+			pr = root[pr]
+		}
+		return pr
+	}
+	for _, pr := range prs {
+		root[pr] = pr
+	}
+
+	reasons := map[int]string{}
+	words := map[int]map[string]bool{}
+	for _, pr := range prs {
+		words[pr] = wordSet(notes[pr].Text)
+	}
+	for i, a := range prs {
+		for _, b := range prs[i+1:] {
+			if find(a) == find(b) {
+				continue
+			}
+			reason := d.duplicateReason(notes[a], notes[b], words[a], words[b])
+			if reason == "" {
+				continue
+			}
+			// the group with the higher root is merged into the other one, and
+			// its root is a duplicate of the PR of the pair in the other group
+			into, merged, duplicateOf := find(a), find(b), a
+			if merged < into {
+				into, merged, duplicateOf = merged, into, b
+			}
+			root[merged] = into
+			reasons[merged] = fmt.Sprintf("duplicate of #%d: %s", duplicateOf, reason)
+		}
+	}
+
+	merges := []*Merge{}
+	byRoot := map[int]*Merge{}
+	for _, pr := range prs {
+		r := find(pr)
+		if r == pr {
+			continue
+		}
+		merge, ok := byRoot[r]
+		if !ok {
+			merge = &Merge{Into: r, Reasons: map[int]string{}}
+			byRoot[r] = merge
+			merges = append(merges, merge)
+		}
+		merge.Merged = append(merge.Merged, pr)
+		merge.Reasons[pr] = reasons[pr]
+	}
+
+	for _, merge := range merges {
+		mergeNotes(notes, merge)
+	}
+	return merges
+}
+
+// duplicateReason returns why the notes are duplicates, or an empty string if
+// they are not
+func (d *Deduplicator) duplicateReason(a, b *ReleaseNote, wordsA, wordsB map[string]bool) string {
+	if similarity := jaccard(wordsA, wordsB); similarity >= d.Threshold {
+		return fmt.Sprintf("%.0f%% similar text", similarity*100)
+	}
+
+	refs := references(a)
+	for ref := range references(b) {
+		if refs[ref] {
+			return "both reference " + ref
+		}
+	}
+	return ""
+}
+
+// references returns the KEPs and issues a note links to
+func references(note *ReleaseNote) map[string]bool {
+	refs := map[string]bool{}
+	for _, ref := range issueRefRe.FindAllString(note.Text, -1) {
+		refs["https://"+ref] = true
+	}
+	for _, doc := range note.Documentation {
+		if doc.Type == DocTypeKEP {
+			refs[doc.URL] = true
+		}
+		for _, ref := range issueRefRe.FindAllString(doc.URL, -1) {
+			refs["https://"+ref] = true
+		}
+	}
+	return refs
+}
+
+// wordSet returns the set of words of the normalized text, which is
+// lowercased and stripped of punctuation
+func wordSet(text string) map[string]bool {
+	set := map[string]bool{}
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		set[word] = true
+	}
+	return set
+}
+
+// jaccard returns the Jaccard index of two sets of words
+func jaccard(a, b map[string]bool) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	intersection := 0
+	for word := range a {
+		if b[word] {
+			intersection++
+		}
+	}
+	return float64(intersection) / float64(len(a)+len(b)-intersection)
+}
+
+// mergeNotes merges the notes of the merge into the note it is merged into
+// and removes them from the list
+func mergeNotes(notes ReleaseNoteList, merge *Merge) {
+	into := notes[merge.Into]
+	attributions := []string{attribution(into)}
+
+	seenDocs := map[string]bool{}
+	for _, doc := range into.Documentation {
+		seenDocs[doc.URL] = true
+	}
+
+	for _, pr := range merge.Merged {
+		note := notes[pr]
+		attributions = append(attributions, attribution(note))
+		into.SIGs = unionStrings(into.SIGs, note.SIGs)
+		into.Kinds = unionStrings(into.Kinds, note.Kinds)
+		into.Areas = unionStrings(into.Areas, note.Areas)
+		into.Feature = into.Feature || note.Feature
+		into.ActionRequired = into.ActionRequired || note.ActionRequired
+		for _, doc := range note.Documentation {
+			if !seenDocs[doc.URL] {
+				seenDocs[doc.URL] = true
+				into.Documentation = append(into.Documentation, doc)
+			}
+		}
+		delete(notes, pr)
+	}
+
+	into.Duplicate = !into.ActionRequired && !into.Feature && len(into.SIGs) > 1
+	into.Markdown = strings.Replace(
+		into.Markdown,
+		fmt.Sprintf("(%s)", attributions[0]),
+		fmt.Sprintf("(%s)", strings.Join(attributions, "; ")),
+		1,
+	)
+}
+
+// attribution returns the PR and author links of a note as rendered by
+// ReleaseNoteFromCommit
+func attribution(note *ReleaseNote) string {
+	return fmt.Sprintf("[#%d](%s), [@%s](%s)", note.PrNumber, note.PrUrl, note.Author, note.AuthorUrl)
+}
+
+func unionStrings(a, b []string) []string {
+	for _, s := range b {
+		if !HasString(a, s) {
+			a = append(a, s)
+		}
+	}
+	return a
+}
@@ -0,0 +1,76 @@
+package notes
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeduplicate(t *testing.T) {
+	note := func(pr int, author, text string, sigs ...string) *ReleaseNote {
+		n := &ReleaseNote{
+			Text:      text,
+			Author:    author,
+			AuthorUrl: "https://github.com/" + author,
+			PrNumber:  pr,
+			PrUrl:     fmt.Sprintf("https://github.com/kubernetes/kubernetes/pull/%d", pr),
+			SIGs:      sigs,
+		}
+		n.Markdown = fmt.Sprintf("%s (%s)", text, attribution(n))
+		return n
+	}
+
+	notes := ReleaseNoteList{
+		1: note(1, "alice", "Fixes a crash in the scheduler when a node is removed.", "scheduling"),
+		2: note(2, "bob", "Fixes a crash in the scheduler when a node is removed", "node"),
+		3: note(3, "carol", "Adds the --foo flag to kubectl."),
+		4: note(4, "dave", "Graduates topology aware routing to beta."),
+		5: note(5, "erin", "Enables the topology aware routing feature gate by default."),
+	}
+	kep := &Documentation{URL: "https://github.com/kubernetes/enhancements/issues/536", Type: DocTypeKEP}
+	notes[4].Documentation = []*Documentation{kep}
+	notes[5].Documentation = []*Documentation{kep}
+
+	merges := NewDeduplicator().Deduplicate(notes)
+
+	require.Equal(t, []*Merge{
+		{Into: 1, Merged: []int{2}, Reasons: map[int]string{2: "duplicate of #1: 100% similar text"}},
+		{Into: 4, Merged: []int{5}, Reasons: map[int]string{5: "duplicate of #4: both reference " + kep.URL}},
+	}, merges)
+
+	require.Len(t, notes, 3)
+	require.Equal(t, []string{"scheduling", "node"}, notes[1].SIGs)
+	require.True(t, notes[1].Duplicate)
+	require.Equal(t,
+		"Fixes a crash in the scheduler when a node is removed. ("+
+			"[#1](https://github.com/kubernetes/kubernetes/pull/1), [@alice](https://github.com/alice); "+
+			"[#2](https://github.com/kubernetes/kubernetes/pull/2), [@bob](https://github.com/bob))",
+		notes[1].Markdown,
+	)
+	require.Len(t, notes[4].Documentation, 1)
+
+	// the groups are merged into the lowest PR, even if a PR is found to be a
+	// duplicate of a later one only
+	notes = ReleaseNoteList{
+		1: note(1, "alice", "Graduates topology aware routing to beta."),
+		2: note(2, "bob", "Fixes the kube-proxy rules of services."),
+		3: note(3, "carol", "Enables topology aware routing in kube-proxy."),
+	}
+	notes[1].Documentation = []*Documentation{kep}
+	notes[3].Documentation = []*Documentation{kep}
+	issue := &Documentation{URL: "https://github.com/kubernetes/kubernetes/issues/42"}
+	notes[2].Documentation = []*Documentation{issue}
+	notes[3].Documentation = append(notes[3].Documentation, issue)
+
+	merges = NewDeduplicator().Deduplicate(notes)
+	require.Equal(t, []*Merge{{
+		Into:   1,
+		Merged: []int{2, 3},
+		Reasons: map[int]string{
+			2: "duplicate of #3: both reference " + issue.URL,
+			3: "duplicate of #1: both reference " + kep.URL,
+		},
+	}}, merges)
+	require.Len(t, notes, 1)
+}
@@ -0,0 +1,147 @@
+// Copyright 2019 The Kubernetes Authors All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notes
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Digest is the part of the release notes owned by a single SIG, together
+// with the problems the SIG has to fix before the notes are published, so
+// that every SIG only reviews what it owns.
+type Digest struct {
+	// SIG is the suffix of the sig/ label, e.g. "node"
+	SIG string `json:"sig"`
+
+	Notes    []*ReleaseNote   `json:"notes"`
+	Problems []*DigestProblem `json:"problems,omitempty"`
+}
+
+// DigestProblem is an outstanding problem of a release note.
+type DigestProblem struct {
+	PrNumber int    `json:"pr_number"`
+	PrUrl    string `json:"pr_url"`
+	Problem  string `json:"problem"`
+}
+
+// CreateDigests creates one Digest per SIG owning any of the notes, sorted by
+// SIG. Notes owned by several SIGs are part of each of their digests. The
+// problems are the wording warnings of the Linter and the features and
+// action required notes lacking documentation.
+func CreateDigests(releaseNotes ReleaseNoteList) []*Digest {
+	prs := make([]int, 0, len(releaseNotes))
+	for pr := range releaseNotes {
+		prs = append(prs, pr)
+	}
+	sort.Ints(prs)
+
+	digests := map[string]*Digest{}
+	for _, pr := range prs {
+		note := releaseNotes[pr]
+		problems := NoteProblems(note)
+		for _, sig := range note.SIGs {
+			digest, ok := digests[sig]
+			if !ok {
+				digest = &Digest{SIG: sig}
+				digests[sig] = digest
+			}
+			digest.Notes = append(digest.Notes, note)
+			digest.Problems = append(digest.Problems, problems...)
+		}
+	}
+
+	sigs := make([]string, 0, len(digests))
+	for sig := range digests {
+		sigs = append(sigs, sig)
+	}
+	sort.Strings(sigs)
+
+	result := make([]*Digest, 0, len(sigs))
+	for _, sig := range sigs {
+		result = append(result, digests[sig])
+	}
+	return result
+}
+
+// NoteProblems returns the outstanding problems of a note: the wording
+// warnings of the Linter and the lack of documentation of features and action
+// required notes.
+func NoteProblems(note *ReleaseNote) []*DigestProblem {
+	problems := []*DigestProblem{}
+	for _, warning := range note.Warnings {
+		problems = append(problems, &DigestProblem{PrNumber: note.PrNumber, PrUrl: note.PrUrl, Problem: warning})
+	}
+	if (note.Feature || note.ActionRequired) && len(note.Documentation) == 0 {
+		problems = append(problems, &DigestProblem{
+			PrNumber: note.PrNumber,
+			PrUrl:    note.PrUrl,
+			Problem:  "no documentation is linked, which features and action required notes should have",
+		})
+	}
+	return problems
+}
+
+// DigestTitle returns the title of the digest of a SIG, e.g. as title of the
+// issue it is filed as.
+func DigestTitle(sig, version string) string {
+	return fmt.Sprintf("Release notes of SIG %s for %s", prettySIG(sig), version)
+}
+
+// RenderDigest writes the digest of the release version in markdown format.
+// With WithSIGs, the full name, the leads and the Slack channel of the SIG
+// are included.
+func RenderDigest(digest *Digest, version string, w io.Writer, opts ...RenderOption) error {
+	c := &renderConfig{}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Release notes of SIG %s for %s\n\n", c.sigName(digest.SIG), version)
+	if c.sigs != nil {
+		if group, ok := c.sigs.SIG(digest.SIG); ok {
+			leads := []string{}
+			for _, lead := range group.Leads() {
+				leads = append(leads, "@"+lead.GitHub)
+			}
+			if len(leads) > 0 {
+				fmt.Fprintf(&b, "Leads: %s\n\n", strings.Join(leads, ", "))
+			}
+			if channel := group.SlackChannel(); channel != "" {
+				fmt.Fprintf(&b, "Slack: %s\n\n", channel)
+			}
+		}
+	}
+
+	fmt.Fprintf(&b, "The SIG owns %d of the release notes. Please review them and fix the wording in the descriptions of the PRs.\n\n", len(digest.Notes))
+	b.WriteString("## Notes\n\n")
+	for _, note := range digest.Notes {
+		fmt.Fprintf(&b, "- %s\n", note.Markdown)
+	}
+
+	if len(digest.Problems) > 0 {
+		b.WriteString("\n## Problems\n\n")
+		b.WriteString("These have to be fixed before the release notes are published:\n\n")
+		for _, problem := range digest.Problems {
+			fmt.Fprintf(&b, "- [#%d](%s): %s\n", problem.PrNumber, problem.PrUrl, problem.Problem)
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
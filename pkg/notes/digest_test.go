@@ -0,0 +1,90 @@
+// Copyright 2019 The Kubernetes Authors All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notes
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/release/pkg/community"
+)
+
+func TestCreateDigests(t *testing.T) {
+	releaseNotes := ReleaseNoteList{
+		3: {PrNumber: 3, PrUrl: "https://github.com/kubernetes/kubernetes/pull/3", Markdown: "Fixed the kubelet", SIGs: []string{"node"}, Warnings: []string{"note is not capitalized"}},
+		1: {PrNumber: 1, PrUrl: "https://github.com/kubernetes/kubernetes/pull/1", Markdown: "Added a flag", SIGs: []string{"cli", "node"}, Feature: true},
+		2: {PrNumber: 2, PrUrl: "https://github.com/kubernetes/kubernetes/pull/2", Markdown: "Removed a flag", SIGs: []string{"cli"}, ActionRequired: true, Documentation: []*Documentation{{URL: "https://kubernetes.io/docs"}}},
+		4: {PrNumber: 4, Markdown: "Without SIG"},
+	}
+
+	digests := CreateDigests(releaseNotes)
+	require.Len(t, digests, 2)
+
+	require.Equal(t, "cli", digests[0].SIG)
+	require.Len(t, digests[0].Notes, 2)
+	require.Equal(t, 1, digests[0].Notes[0].PrNumber)
+	require.Equal(t, 2, digests[0].Notes[1].PrNumber)
+	require.Len(t, digests[0].Problems, 1)
+	require.Equal(t, 1, digests[0].Problems[0].PrNumber)
+
+	require.Equal(t, "node", digests[1].SIG)
+	require.Len(t, digests[1].Notes, 2)
+	require.Len(t, digests[1].Problems, 2)
+	require.Equal(t, "note is not capitalized", digests[1].Problems[1].Problem)
+}
+
+func TestRenderDigest(t *testing.T) {
+	digest := &Digest{
+		SIG:   "node",
+		Notes: []*ReleaseNote{{PrNumber: 3, Markdown: "Fixed the kubelet"}},
+		Problems: []*DigestProblem{
+			{PrNumber: 3, PrUrl: "https://github.com/kubernetes/kubernetes/pull/3", Problem: "note is not capitalized"},
+		},
+	}
+	sigs := &community.Groups{SIGs: []*community.Group{{
+		Name:       "Node",
+		Label:      "node",
+		Leadership: community.Leadership{Chairs: []*community.Person{{GitHub: "alice"}}, TechnicalLeads: []*community.Person{{GitHub: "alice"}, {GitHub: "bob"}}},
+		Contact:    community.Contact{Slack: "sig-node"},
+	}}}
+
+	var b bytes.Buffer
+	require.NoError(t, RenderDigest(digest, "v1.16.0", &b, WithSIGs(sigs)))
+	require.Equal(t, strings.Join([]string{
+		"# Release notes of SIG Node for v1.16.0",
+		"",
+		"Leads: @alice, @bob",
+		"",
+		"Slack: #sig-node",
+		"",
+		"The SIG owns 1 of the release notes. Please review them and fix the wording in the descriptions of the PRs.",
+		"",
+		"## Notes",
+		"",
+		"- Fixed the kubelet",
+		"",
+		"## Problems",
+		"",
+		"These have to be fixed before the release notes are published:",
+		"",
+		"- [#3](https://github.com/kubernetes/kubernetes/pull/3): note is not capitalized",
+		"",
+	}, "\n"), b.String())
+
+	require.Equal(t, "Release notes of SIG Node for v1.16.0", DigestTitle("node", "v1.16.0"))
+}
@@ -5,6 +5,8 @@ import (
 	"io"
 	"sort"
 	"strings"
+
+	"k8s.io/release/pkg/community"
 )
 
 // Document represents the underlying structure of a release notes document.
@@ -16,6 +18,10 @@ type Document struct {
 	SIGs           map[string][]string `json:"sigs"`
 	BugFixes       []string            `json:"bug_fixes"`
 	Uncategorized  []string            `json:"uncategorized"`
+
+	// Categories are the notes bucketed by a Taxonomy, see
+	// CreateDocumentByKind
+	Categories []*CategorizedNotes `json:"categories,omitempty"`
 }
 
 // CreateDocument assembles an organized document from an unorganized set of
@@ -86,9 +92,43 @@ func CreateDocument(notes ReleaseNoteList) (*Document, error) {
 	return doc, nil
 }
 
+// RenderOption is a type which allows for the expression of rendering
+// configuration via the "functional option" pattern.
+type RenderOption func(*renderConfig)
+
+// renderConfig is a configuration struct that is used to express optional
+// configuration for rendering a Document
+type renderConfig struct {
+	sigs *community.Groups
+}
+
+// WithSIGs allows the caller to provide the SIG metadata parsed from
+// kubernetes/community's sigs.yaml. If set, the full SIG names are rendered
+// instead of names derived from the sig/ labels.
+func WithSIGs(sigs *community.Groups) RenderOption {
+	return func(c *renderConfig) {
+		c.sigs = sigs
+	}
+}
+
+// sigName returns the name of a SIG as it should be printed in documents
+func (c *renderConfig) sigName(sig string) string {
+	if c.sigs != nil {
+		if group, ok := c.sigs.SIG(sig); ok && group.Name != "" {
+			return group.Name
+		}
+	}
+	return prettySIG(sig)
+}
+
 // RenderMarkdown accepts a Document and writes a version of that document to
 // supplied io.Writer in markdown format.
-func RenderMarkdown(doc *Document, w io.Writer) error {
+func RenderMarkdown(doc *Document, w io.Writer, opts ...RenderOption) error {
+	c := &renderConfig{}
+	for _, opt := range opts {
+		opt(c)
+	}
+
 	// we always want to render the document with SIGs in alphabetical order
 	sortedSIGs := []string{}
 	for sig := range doc.SIGs {
@@ -129,6 +169,19 @@ func RenderMarkdown(doc *Document, w io.Writer) error {
 		write("\n\n")
 	}
 
+	// the sections of a document organized by kind
+	if len(doc.Categories) > 0 {
+		write("## Changes by Kind\n\n")
+		for _, category := range doc.Categories {
+			write("### " + category.Name + "\n\n")
+			for _, note := range category.Notes {
+				writeNote(note)
+			}
+			write("\n")
+		}
+		write("\n")
+	}
+
 	// the "New Feautres" section
 	if len(doc.NewFeatures) > 0 {
 		write("## New Features\n\n")
@@ -164,7 +217,7 @@ func RenderMarkdown(doc *Document, w io.Writer) error {
 	if len(sortedSIGs) > 0 {
 		write("## Notes from Individual SIGs\n\n")
 		for _, sig := range sortedSIGs {
-			write("### SIG " + prettySIG(sig) + "\n\n")
+			write("### SIG " + c.sigName(sig) + "\n\n")
 			for _, note := range doc.SIGs[sig] {
 				writeNote(note)
 			}
@@ -1,9 +1,13 @@
 package notes
 
 import (
+	"bytes"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/require"
+
+	"k8s.io/release/pkg/community"
 )
 
 func TestPrettySIG(t *testing.T) {
@@ -21,3 +25,22 @@ func TestPrettySIG(t *testing.T) {
 		require.Equal(t, expected, (prettySIG(input)))
 	}
 }
+
+func TestRenderMarkdownWithSIGs(t *testing.T) {
+	sigs, err := community.Parse(strings.NewReader(
+		"sigs:\n  - dir: sig-node\n    name: Node and Kubelet\n    label: node\n",
+	))
+	require.NoError(t, err)
+
+	doc := &Document{
+		SIGs: map[string][]string{
+			"node": {"A note"},
+			"cli":  {"Another note"},
+		},
+	}
+
+	b := &bytes.Buffer{}
+	require.NoError(t, RenderMarkdown(doc, b, WithSIGs(sigs)))
+	require.Contains(t, b.String(), "### SIG Node and Kubelet\n\n- A note\n")
+	require.Contains(t, b.String(), "### SIG CLI\n\n- Another note\n")
+}
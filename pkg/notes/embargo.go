@@ -0,0 +1,119 @@
+// Copyright 2019 The Kubernetes Authors All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notes
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// ReadOnly returns an http.RoundTripper which performs GET and HEAD requests
+// using the given transport, which defaults to http.DefaultTransport, and
+// rejects all other requests. It hard-blocks writes while a security release
+// is under embargo.
+func ReadOnly(transport http.RoundTripper) http.RoundTripper {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	return &readOnlyTransport{transport: transport}
+}
+
+type readOnlyTransport struct {
+	transport http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *readOnlyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, NewError(ErrValidation, "%s %s is blocked while the release is embargoed", req.Method, sanitizeURL(req.URL))
+	}
+	return t.transport.RoundTrip(req)
+}
+
+// Advisory is a vulnerability fixed by a security release. Advisories are
+// kept out of the release notes until the embargo is lifted.
+type Advisory struct {
+	// CVE is the identifier of the vulnerability, e.g. CVE-2019-11247
+	CVE string `json:"cve"`
+
+	Title    string `json:"title"`
+	Severity string `json:"severity"`
+
+	// URL is the public announcement of the vulnerability
+	URL string `json:"url"`
+
+	// PRs are the PRs fixing the vulnerability
+	PRs []int `json:"prs"`
+}
+
+// LoadAdvisories reads a JSON list of advisories from a file.
+func LoadAdvisories(path string) ([]*Advisory, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	advisories := []*Advisory{}
+	if err := json.Unmarshal(content, &advisories); err != nil {
+		return nil, NewError(ErrParse, "error parsing advisories %s: %v", path, err)
+	}
+	return advisories, nil
+}
+
+// InjectAdvisories prefixes the release notes of the PRs fixing a
+// vulnerability with its details and marks them as requiring action, so that
+// they are listed first. Every PR of an advisory must be in the notes.
+func InjectAdvisories(notes ReleaseNoteList, advisories []*Advisory) error {
+	missing := []string{}
+	for _, advisory := range advisories {
+		for _, pr := range advisory.PRs {
+			note, ok := notes[pr]
+			if !ok {
+				missing = append(missing, fmt.Sprintf("#%d (%s)", pr, advisory.CVE))
+				continue
+			}
+			if HasString(note.CVEs, advisory.CVE) {
+				continue
+			}
+
+			details := advisory.CVE
+			if advisory.Severity != "" {
+				details = fmt.Sprintf("%s, %s severity", details, strings.ToLower(advisory.Severity))
+			}
+			prefix := fmt.Sprintf("%s (%s): ", advisory.Title, details)
+			linked := prefix
+			if advisory.URL != "" {
+				linked = fmt.Sprintf("%s ([%s](%s)): ", advisory.Title, details, advisory.URL)
+			}
+
+			note.Text = prefix + note.Text
+			note.Markdown = linked + note.Markdown
+			note.CVEs = append(note.CVEs, advisory.CVE)
+			note.ActionRequired = true
+		}
+	}
+
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return NewError(ErrNotFound, "the release notes lack the PRs fixing %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package notes
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadOnly(t *testing.T) {
+	writes := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			writes++
+		}
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: ReadOnly(nil)}
+	resp, err := client.Get(server.URL + "/repos/o/r/pulls/1")
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	_, err = client.Post(server.URL+"/gists?access_token=secret", "application/json", strings.NewReader("{}"))
+	require.Error(t, err)
+	require.True(t, errors.Is(err, ErrValidation))
+	require.Contains(t, err.Error(), "POST "+server.URL+"/gists is blocked while the release is embargoed")
+	require.Zero(t, writes)
+}
+
+func TestInjectAdvisories(t *testing.T) {
+	releaseNotes := ReleaseNoteList{
+		1: {PrNumber: 1, Text: "Fix a bug", Markdown: "Fix a bug ([#1](url))"},
+		2: {PrNumber: 2, Text: "Fix another bug", Markdown: "Fix another bug ([#2](url))"},
+	}
+	advisories := []*Advisory{{
+		CVE:      "CVE-2019-11247",
+		Title:    "API server allows access to custom resources via the wrong scope",
+		Severity: "High",
+		URL:      "https://github.com/kubernetes/kubernetes/issues/80983",
+		PRs:      []int{1},
+	}}
+
+	require.NoError(t, InjectAdvisories(releaseNotes, advisories))
+	require.Equal(t, "API server allows access to custom resources via the wrong scope (CVE-2019-11247, high severity): Fix a bug", releaseNotes[1].Text)
+	require.Equal(t, "API server allows access to custom resources via the wrong scope ([CVE-2019-11247, high severity](https://github.com/kubernetes/kubernetes/issues/80983)): Fix a bug ([#1](url))", releaseNotes[1].Markdown)
+	require.Equal(t, []string{"CVE-2019-11247"}, releaseNotes[1].CVEs)
+	require.True(t, releaseNotes[1].ActionRequired)
+	require.False(t, releaseNotes[2].ActionRequired)
+
+	// injecting again does not duplicate the details
+	text := releaseNotes[1].Text
+	require.NoError(t, InjectAdvisories(releaseNotes, advisories))
+	require.Equal(t, text, releaseNotes[1].Text)
+
+	err := InjectAdvisories(releaseNotes, []*Advisory{{CVE: "CVE-2019-1002101", PRs: []int{3}}})
+	require.True(t, errors.Is(err, ErrNotFound))
+	require.Equal(t, "the release notes lack the PRs fixing #3 (CVE-2019-1002101)", err.Error())
+}
@@ -0,0 +1,185 @@
+// Copyright 2019 The Kubernetes Authors All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notes
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/google/go-github/github"
+)
+
+// The categories of errors which can occur while generating release notes.
+// Errors returned by this package match one of them using errors.Is if their
+// cause is known.
+var (
+	// ErrNotFound indicates that a commit, PR or other GitHub resource does
+	// not exist
+	ErrNotFound = errors.New("not found")
+
+	// ErrRateLimited indicates that the GitHub API rate limit is exhausted
+	ErrRateLimited = errors.New("rate limited")
+
+	// ErrParse indicates that a commit message or PR body could not be parsed
+	ErrParse = errors.New("parse error")
+
+	// ErrValidation indicates invalid input or configuration
+	ErrValidation = errors.New("validation error")
+
+	// ErrUnavailable indicates that an external dependency, e.g. the GitHub
+	// API, failed repeatedly and is not called for a while
+	ErrUnavailable = errors.New("unavailable")
+)
+
+// Error is an error of a known category.
+type Error struct {
+	// Kind is the category of the error, e.g. ErrNotFound
+	Kind error
+
+	// Err is the underlying error
+	Err error
+}
+
+// NewError creates an Error of the given category from a message.
+func NewError(kind error, format string, args ...interface{}) *Error {
+	return &Error{Kind: kind, Err: fmt.Errorf(format, args...)}
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether the error belongs to the given category.
+func (e *Error) Is(target error) bool {
+	return target == e.Kind
+}
+
+// Kind returns the category of the error, or nil if it is unknown. The error
+// chain is followed through both Unwrap and the Cause method used by
+// github.com/pkg/errors.
+func Kind(err error) error {
+	for err != nil {
+		switch e := err.(type) {
+		case *Error:
+			return e.Kind
+		case *github.RateLimitError, *github.AbuseRateLimitError:
+			return ErrRateLimited
+		case *github.ErrorResponse:
+			if e.Response != nil && e.Response.StatusCode == http.StatusNotFound {
+				return ErrNotFound
+			}
+			return nil
+		case interface{ Unwrap() error }:
+			err = e.Unwrap()
+		case interface{ Cause() error }:
+			err = e.Cause()
+		default:
+			return nil
+		}
+	}
+	return nil
+}
+
+// classify wraps the error into an Error of its category, so that the
+// category can be matched with errors.Is even if the error has been wrapped
+// by github.com/pkg/errors, which does not support Unwrap
+func classify(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := err.(*Error); ok {
+		return err
+	}
+	kind := Kind(err)
+	if kind == nil {
+		return err
+	}
+	return &Error{Kind: kind, Err: err}
+}
+
+// CommitError is the error which occurred while processing a single commit.
+type CommitError struct {
+	// SHA is the SHA of the commit which could not be processed. It is the
+	// reference of the PR, e.g. kubernetes/kubernetes#123, if the PR of a
+	// search result could not be fetched.
+	SHA string
+
+	// Err is the underlying error
+	Err error
+}
+
+func (e *CommitError) Error() string {
+	return fmt.Sprintf("commit %s: %v", e.SHA, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *CommitError) Unwrap() error {
+	return e.Err
+}
+
+// CommitErrors is the list of errors of all commits which could not be
+// processed. It is returned alongside the results of the commits which have
+// been processed successfully.
+type CommitErrors []*CommitError
+
+func (e CommitErrors) Error() string {
+	if len(e) == 1 {
+		return e[0].Error()
+	}
+
+	messages := make([]string, 0, len(e))
+	for _, err := range e {
+		messages = append(messages, err.Error())
+	}
+	return fmt.Sprintf("%d commits could not be processed: %s", len(e), strings.Join(messages, "; "))
+}
+
+// InterruptedError is returned alongside the partial results if processing
+// stopped early because the context has been cancelled or the overall
+// deadline has passed.
+type InterruptedError struct {
+	// Processed is the number of commits processed before the interruption
+	Processed int
+
+	// Total is the number of commits which should have been processed
+	Total int
+
+	// Err is the reason of the interruption, e.g. context.DeadlineExceeded
+	Err error
+
+	// Failed are the commits which could not be processed before the
+	// interruption
+	Failed CommitErrors
+}
+
+func (e *InterruptedError) Error() string {
+	message := fmt.Sprintf("interrupted after processing %d of %d commits: %v", e.Processed, e.Total, e.Err)
+	if len(e.Failed) > 0 {
+		message += fmt.Sprintf(", %d of them could not be processed", len(e.Failed))
+	}
+	return message
+}
+
+// Unwrap returns the reason of the interruption.
+func (e *InterruptedError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,201 @@
+package notes
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"github.com/google/go-github/github"
+	pkgerrors "github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommitErrors(t *testing.T) {
+	errs := CommitErrors{
+		{SHA: "abc", Err: errors.New("not found")},
+	}
+	require.Equal(t, "commit abc: not found", errs.Error())
+
+	errs = append(errs, &CommitError{SHA: "def", Err: errors.New("no matches")})
+	require.Equal(t,
+		"2 commits could not be processed: commit abc: not found; commit def: no matches",
+		errs.Error(),
+	)
+}
+
+func TestErrorKinds(t *testing.T) {
+	response := func(code int) *http.Response {
+		req, _ := http.NewRequest(http.MethodGet, "https://api.github.com/", nil)
+		return &http.Response{StatusCode: code, Request: req}
+	}
+	notFound := &github.ErrorResponse{Response: response(http.StatusNotFound)}
+	serverError := &github.ErrorResponse{Response: response(http.StatusInternalServerError)}
+	_, parseErr := NoteTextFromString("no note in here")
+
+	for _, tc := range []struct {
+		err  error
+		kind error
+	}{
+		{notFound, ErrNotFound},
+		{pkgerrors.Wrap(notFound, "getting PR"), ErrNotFound},
+		{&github.RateLimitError{Response: response(http.StatusForbidden)}, ErrRateLimited},
+		{&github.AbuseRateLimitError{Response: response(http.StatusForbidden)}, ErrRateLimited},
+		{parseErr, ErrParse},
+		{pkgerrors.Wrapf(parseErr, "commit %s", "abc"), ErrParse},
+		{&CommitError{SHA: "abc", Err: classify(pkgerrors.Wrap(notFound, "getting PR"))}, ErrNotFound},
+		{NewError(ErrValidation, "%q is invalid", "foo"), ErrValidation},
+		{serverError, nil},
+		{errors.New("unknown"), nil},
+	} {
+		require.Equal(t, tc.kind, Kind(tc.err), tc.err.Error())
+
+		if tc.kind != nil {
+			require.True(t, errors.Is(classify(tc.err), tc.kind), tc.err.Error())
+		}
+		for _, kind := range []error{ErrNotFound, ErrRateLimited, ErrParse, ErrValidation} {
+			if kind != tc.kind {
+				require.False(t, errors.Is(classify(tc.err), kind), tc.err.Error())
+			}
+		}
+	}
+
+	var e *Error
+	require.True(t, errors.As(&CommitError{SHA: "abc", Err: parseErr}, &e))
+	require.Equal(t, ErrParse, e.Kind)
+}
+
+func TestListReleaseNotesPartial(t *testing.T) {
+	var server *httptest.Server
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/o/r/git/commits/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"committer": {"date": "2019-06-01T00:00:00Z"}}`)
+	})
+	mux.HandleFunc("/repos/o/r/commits", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[
+			{"sha": "sha1", "commit": {"message": "Merge pull request #1 from a/b", "committer": {"date": "2019-06-01T00:00:00Z"}}},
+			{"sha": "sha2", "commit": {"message": "Merge pull request #2 from a/c", "committer": {"date": "2019-06-01T00:00:00Z"}}}
+		]`)
+	})
+	mux.HandleFunc("/repos/o/r/pulls/1", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"message": "Server Error"}`, http.StatusInternalServerError)
+	})
+	mux.HandleFunc("/repos/o/r/pulls/2", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{
+			"number": 2,
+			"body": "`+"```release-note\\nAdds a flag to kubectl.\\n```"+`",
+			"merge_commit_sha": "sha2",
+			"user": {"login": "alice"},
+			"base": {"repo": {"url": "%s/repos/o/r"}}
+		}`, server.URL)
+	})
+	server = httptest.NewServer(mux)
+	defer server.Close()
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+
+	// the notes of the other commits are returned with the failed commits
+	notes, err := ListReleaseNotes(client, log.NewNopLogger(), "master", "start", "end", "", "v1.15.0", WithOrg("o"), WithRepo("r"))
+	require.Error(t, err)
+	commitErrs, ok := err.(CommitErrors)
+	require.True(t, ok)
+	require.Len(t, commitErrs, 1)
+	require.Equal(t, "sha1", commitErrs[0].SHA)
+	require.Len(t, notes, 1)
+	require.Equal(t, "Adds a flag to kubectl.", notes[2].Text)
+
+	// the first failed commit aborts the run with WithFailFast
+	notes, err = ListReleaseNotes(client, log.NewNopLogger(), "master", "start", "end", "", "v1.15.0", WithOrg("o"), WithRepo("r"), WithFailFast(true))
+	require.Error(t, err)
+	commitErr, ok := err.(*CommitError)
+	require.True(t, ok)
+	require.Equal(t, "sha1", commitErr.SHA)
+	require.Nil(t, notes)
+}
+
+func TestListReleaseNotesInterrupted(t *testing.T) {
+	var server *httptest.Server
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/o/r/git/commits/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"committer": {"date": "2019-06-01T00:00:00Z"}}`)
+	})
+	mux.HandleFunc("/repos/o/r/commits", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[
+			{"sha": "sha1", "commit": {"message": "Merge pull request #1 from a/b", "committer": {"date": "2019-06-01T00:00:00Z"}}},
+			{"sha": "sha2", "commit": {"message": "Merge pull request #2 from a/c", "committer": {"date": "2019-06-01T00:00:00Z"}}},
+			{"sha": "sha3", "commit": {"message": "Merge pull request #3 from a/d", "committer": {"date": "2019-06-01T00:00:00Z"}}}
+		]`)
+	})
+	mux.HandleFunc("/repos/o/r/pulls/1", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"message": "Server Error"}`, http.StatusInternalServerError)
+	})
+	mux.HandleFunc("/repos/o/r/pulls/2", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{
+			"number": 2,
+			"body": "`+"```release-note\\nAdds a flag to kubectl.\\n```"+`",
+			"merge_commit_sha": "sha2",
+			"user": {"login": "alice"},
+			"base": {"repo": {"url": "%s/repos/o/r"}}
+		}`, server.URL)
+	})
+	mux.HandleFunc("/repos/o/r/pulls/3", func(w http.ResponseWriter, r *http.Request) {
+		t.Error("PR 3 is fetched after the interruption")
+	})
+	server = httptest.NewServer(mux)
+	defer server.Close()
+
+	// the run is cancelled once PR 2 has been fetched
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	client := github.NewClient(&http.Client{Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		resp, err := http.DefaultTransport.RoundTrip(req)
+		if err == nil && req.URL.Path == "/repos/o/r/pulls/2" {
+			body, _ := ioutil.ReadAll(resp.Body)
+			resp.Body.Close()
+			resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+			cancel()
+		}
+		return resp, err
+	})})
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+
+	// the note of PR 2 is still returned together with the failed commits
+	notes, err := ListReleaseNotes(client, log.NewNopLogger(), "master", "start", "end", "", "v1.15.0",
+		WithOrg("o"), WithRepo("r"), WithContext(ctx))
+	interrupted, ok := err.(*InterruptedError)
+	require.True(t, ok, "%v", err)
+	require.Equal(t, context.Canceled, interrupted.Err)
+	require.Equal(t, 2, interrupted.Processed)
+	require.Len(t, interrupted.Failed, 1)
+	require.Equal(t, "sha1", interrupted.Failed[0].SHA)
+	require.Len(t, notes, 1)
+	require.Equal(t, "Adds a flag to kubectl.", notes[2].Text)
+	require.Equal(t, "sha2", notes[2].Commit)
+
+	// the failed commits are kept when the quota budget skips PRs, too. The
+	// budget is not spent by the client, it covers PRs 1 and 2.
+	client = github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+	notes, err = ListReleaseNotes(client, log.NewNopLogger(), "master", "start", "end", "", "v1.15.0",
+		WithOrg("o"), WithRepo("r"), WithQuotaBudget(NewQuotaBudget(2)))
+	interrupted, ok = err.(*InterruptedError)
+	require.True(t, ok, "%v", err)
+	require.Equal(t, ErrQuotaExhausted, interrupted.Err)
+	require.Len(t, interrupted.Failed, 1)
+	require.Equal(t, "sha1", interrupted.Failed[0].SHA)
+	require.Len(t, notes, 1)
+	require.Equal(t, "Adds a flag to kubectl.", notes[2].Text)
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
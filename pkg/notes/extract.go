@@ -0,0 +1,253 @@
+// Copyright 2019 The Kubernetes Authors All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notes
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// The fields of a release note extracted from the description of a PR.
+const (
+	// FieldNote is the text of the release note
+	FieldNote = "note"
+
+	// FieldDocumentation is the list of documentation links, one per line
+	FieldDocumentation = "documentation"
+)
+
+// ExtractionRule extracts a field of a release note from the description of
+// a PR.
+type ExtractionRule struct {
+	// Field is the extracted field, FieldNote or FieldDocumentation
+	Field string `json:"field"`
+
+	// Section restricts the rule to the markdown section below the heading
+	// with this title, e.g. "Does this PR introduce a user-facing change?".
+	// Empty means the whole description.
+	Section string `json:"section,omitempty"`
+
+	// Pattern is a regular expression with a named group called like the
+	// field, which captures its value
+	Pattern string `json:"pattern"`
+
+	compileOnce sync.Once
+	re          *regexp.Regexp
+	err         error
+}
+
+// compile returns the compiled pattern. It is compiled on first use and
+// shared afterwards, as compiled expressions are safe for concurrent use.
+func (r *ExtractionRule) compile() (*regexp.Regexp, error) {
+	r.compileOnce.Do(func() {
+		r.re, r.err = regexp.Compile(r.Pattern)
+	})
+	return r.re, r.err
+}
+
+// ExtractionRules are the rules extracting release notes from the
+// descriptions of PRs, which differ between the PR templates of projects.
+// The first matching rule of every field wins.
+type ExtractionRules struct {
+	Rules []*ExtractionRule `json:"rules"`
+}
+
+// DefaultExtractionRules extract the release notes from the PR template of
+// kubernetes/kubernetes.
+var DefaultExtractionRules = &ExtractionRules{
+	Rules: []*ExtractionRule{
+		// (?s) is needed for '.' to be matching on newlines, by default that's disabled
+		// we need to match ungreedy 'U', because after the notes a `docs` block can occur
+		{Field: FieldNote, Pattern: "(?sU)```release-note\\r\\n(?P<note>.+)\\r\\n```"},
+		{Field: FieldNote, Pattern: "(?sU)```dev-release-note\\r\\n(?P<note>.+)"},
+		{Field: FieldNote, Pattern: "(?sU)```\\r\\n(?P<note>.+)\\r\\n```"},
+		{Field: FieldNote, Pattern: "(?sU)```release-note\n(?P<note>.+)\n```"},
+		{Field: FieldDocumentation, Pattern: "(?s)```docs[\\r]?\\n(?P<documentation>.+)[\\r]?\\n```"},
+	},
+}
+
+// LoadExtractionRules reads and validates the rules of a JSON file.
+func LoadExtractionRules(path string) (*ExtractionRules, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "error reading extraction rules")
+	}
+	rules := &ExtractionRules{}
+	if err := json.Unmarshal(content, rules); err != nil {
+		return nil, NewError(ErrParse, "error parsing extraction rules %s: %v", path, err)
+	}
+	if err := rules.Validate(); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// Validate checks that every rule extracts a known field with a valid
+// pattern, and that a note can be extracted at all.
+func (r *ExtractionRules) Validate() error {
+	hasNote := false
+	for i, rule := range r.Rules {
+		if rule.Field != FieldNote && rule.Field != FieldDocumentation {
+			return NewError(ErrValidation, "extraction rule %d has the unknown field %q", i+1, rule.Field)
+		}
+		re, err := rule.compile()
+		if err != nil {
+			return NewError(ErrValidation, "extraction rule %d has an invalid pattern: %v", i+1, err)
+		}
+		if subexpIndex(re, rule.Field) < 0 {
+			return NewError(ErrValidation, "the pattern of extraction rule %d lacks the group (?P<%s>...)", i+1, rule.Field)
+		}
+		hasNote = hasNote || rule.Field == FieldNote
+	}
+	if !hasNote {
+		return NewError(ErrValidation, "the extraction rules lack a rule for the %s field", FieldNote)
+	}
+	return nil
+}
+
+// Extract returns the value of the field captured by the first matching
+// rule.
+func (r *ExtractionRules) Extract(body, field string) (string, bool) {
+	for _, rule := range r.Rules {
+		if rule.Field != field {
+			continue
+		}
+		text := body
+		if rule.Section != "" {
+			var ok bool
+			if text, ok = sectionText(body, rule.Section); !ok {
+				continue
+			}
+		}
+		re, err := rule.compile()
+		if err != nil {
+			continue
+		}
+		match := re.FindStringSubmatch(text)
+		if match == nil {
+			continue
+		}
+		if i := subexpIndex(re, field); i >= 0 {
+			return match[i], true
+		}
+	}
+	return "", false
+}
+
+// subexpIndex returns the index of the named group, or -1
+func subexpIndex(re *regexp.Regexp, name string) int {
+	for i, n := range re.SubexpNames() {
+		if n == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// headingRe matches a markdown heading, capturing its level and title
+var headingRe = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+
+// sectionText returns the text below the heading with the given title, up to
+// the next heading of the same or a higher level
+func sectionText(body, title string) (string, bool) {
+	lines := strings.Split(body, "\n")
+	level := 0
+	start := -1
+	for i, line := range lines {
+		match := headingRe.FindStringSubmatch(strings.TrimRight(line, "\r"))
+		if match == nil {
+			continue
+		}
+		if start >= 0 && len(match[1]) <= level {
+			return strings.Join(lines[start:i], "\n"), true
+		}
+		if start < 0 && strings.EqualFold(match[2], strings.TrimSpace(title)) {
+			level = len(match[1])
+			start = i + 1
+		}
+	}
+	if start < 0 {
+		return "", false
+	}
+	return strings.Join(lines[start:], "\n"), true
+}
+
+// NoteText returns the text of the release note in the description of a PR.
+func (r *ExtractionRules) NoteText(body string) (string, error) {
+	note, ok := r.Extract(body, FieldNote)
+	if !ok {
+		return "", NewError(ErrParse, "no matches found when parsing note text from commit string")
+	}
+	note = strings.ReplaceAll(note, "#", "&#35;")
+	note = strings.ReplaceAll(note, "\r", "")
+	note = stripActionRequired(note)
+	note = stripStar(note)
+	return note, nil
+}
+
+// Documentation returns the documentation links in the description of a PR.
+func (r *ExtractionRules) Documentation(body string) []*Documentation {
+	text, ok := r.Extract(body, FieldDocumentation)
+	if !ok {
+		// Nothing found, but we don't require it
+		return nil
+	}
+
+	result := []*Documentation{}
+	text = stripStar(text)
+	text = stripDash(text)
+
+	scanner := bufio.NewScanner(strings.NewReader(text))
+	for scanner.Scan() {
+		const httpPrefix = "http"
+		s := strings.SplitN(scanner.Text(), httpPrefix, 2)
+		if len(s) != 2 {
+			continue
+		}
+		description := strings.TrimRight(strings.TrimSpace(s[0]), " :-")
+		urlString := httpPrefix + strings.TrimSpace(s[1])
+
+		// Validate the URL
+		parsedURL, err := url.Parse(urlString)
+		if err != nil {
+			continue
+		}
+
+		result = append(result, &Documentation{
+			Description: description,
+			URL:         urlString,
+			Type:        classifyURL(parsedURL),
+		})
+	}
+
+	return result
+}
+
+// WithExtractionRules allows the caller to override the rules extracting the
+// release notes from the descriptions of PRs. PRs are then considered if a
+// note can be extracted, instead of by the markers of the kubernetes PR
+// template.
+func WithExtractionRules(rules *ExtractionRules) GithubApiOption {
+	return func(c *githubApiConfig) {
+		c.extractionRules = rules
+	}
+}
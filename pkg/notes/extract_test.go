@@ -0,0 +1,115 @@
+// Copyright 2019 The Kubernetes Authors All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notes
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+// downstreamBody is a PR description following a template which lacks the
+// release-note block of the kubernetes template
+const downstreamBody = `## What this PR does
+
+Refactors the scheduler.
+
+## Changelog
+
+Added the --foo flag to the scheduler.
+
+## Docs
+
+- Usage: https://example.com/docs/foo
+`
+
+func TestExtractionRules(t *testing.T) {
+	rules := &ExtractionRules{Rules: []*ExtractionRule{
+		{Field: FieldNote, Section: "Changelog", Pattern: `(?s)\s*(?P<note>\S.*?)\s*$`},
+		{Field: FieldDocumentation, Section: "docs", Pattern: `(?s)(?P<documentation>.+)`},
+	}}
+	require.NoError(t, rules.Validate())
+
+	text, err := rules.NoteText(downstreamBody)
+	require.NoError(t, err)
+	require.Equal(t, "Added the --foo flag to the scheduler.", text)
+
+	docs := rules.Documentation(downstreamBody)
+	require.Len(t, docs, 1)
+	require.Equal(t, "Usage", docs[0].Description)
+	require.Equal(t, "https://example.com/docs/foo", docs[0].URL)
+
+	_, err = rules.NoteText("## Other\n\nNothing")
+	require.Equal(t, ErrParse, Kind(err))
+
+	// the default rules do not match the downstream template
+	_, err = DefaultExtractionRules.NoteText(downstreamBody)
+	require.Error(t, err)
+	require.NoError(t, DefaultExtractionRules.Validate())
+}
+
+func TestExtractionRulesValidate(t *testing.T) {
+	for _, rules := range []*ExtractionRules{
+		{Rules: []*ExtractionRule{{Field: "title", Pattern: `(?P<title>.+)`}}},
+		{Rules: []*ExtractionRule{{Field: FieldNote, Pattern: `(`}}},
+		{Rules: []*ExtractionRule{{Field: FieldNote, Pattern: `(?P<text>.+)`}}},
+		{Rules: []*ExtractionRule{{Field: FieldDocumentation, Pattern: `(?P<documentation>.+)`}}},
+	} {
+		err := rules.Validate()
+		require.Error(t, err)
+		require.Equal(t, ErrValidation, Kind(err))
+	}
+}
+
+func TestLoadExtractionRules(t *testing.T) {
+	dir, err := ioutil.TempDir("", "extract")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "rules.json")
+	require.NoError(t, ioutil.WriteFile(path, []byte(`{"rules": [{"field": "note", "section": "Changelog", "pattern": "(?s)\\s*(?P<note>\\S.*?)\\s*$"}]}`), 0644))
+	rules, err := LoadExtractionRules(path)
+	require.NoError(t, err)
+	text, err := rules.NoteText(downstreamBody)
+	require.NoError(t, err)
+	require.Equal(t, "Added the --foo flag to the scheduler.", text)
+
+	require.NoError(t, ioutil.WriteFile(path, []byte(`{"rules": []}`), 0644))
+	_, err = LoadExtractionRules(path)
+	require.Equal(t, ErrValidation, Kind(err))
+
+	// a file which cannot be read is not a validation error
+	_, err = LoadExtractionRules(filepath.Join(dir, "missing.json"))
+	require.True(t, os.IsNotExist(errors.Cause(err)))
+	require.NotEqual(t, ErrValidation, Kind(err))
+}
+
+func TestSectionText(t *testing.T) {
+	body := "# Title\n\n## A\n\na\n\n### A.1\n\na1\n\n## B\n\nb\n"
+	text, ok := sectionText(body, "a")
+	require.True(t, ok)
+	require.Equal(t, "\na\n\n### A.1\n\na1\n", text)
+
+	text, ok = sectionText(body, "B")
+	require.True(t, ok)
+	require.Equal(t, "\nb\n", text)
+
+	_, ok = sectionText(body, "C")
+	require.False(t, ok)
+}
@@ -0,0 +1,212 @@
+// Copyright 2019 The Kubernetes Authors All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notes
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"html/template"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Renderer renders release notes in an output format.
+type Renderer interface {
+	Render(w io.Writer, releaseNotes ReleaseNoteList) error
+}
+
+// RendererFunc adapts a function to a Renderer.
+type RendererFunc func(w io.Writer, releaseNotes ReleaseNoteList) error
+
+// Render implements Renderer.
+func (f RendererFunc) Render(w io.Writer, releaseNotes ReleaseNoteList) error {
+	return f(w, releaseNotes)
+}
+
+// Format is an output format of the release notes.
+type Format struct {
+	Name string
+
+	// Extension is the file extension of the format, including the dot
+	Extension string
+
+	Renderer Renderer
+
+	// DocumentFields are the fields the renderer organizes the notes by, e.g.
+	// into sections, rather than renders. Output policies keep them.
+	DocumentFields []string
+}
+
+// MarkdownDocumentFields are the fields the markdown Document is organized
+// by.
+var MarkdownDocumentFields = []string{"action_required", "feature", "duplicate", "sigs", "kinds", "category"}
+
+// FormatRegistry maps the names of output formats to their formats, so that
+// the notes collected once can be rendered in several formats.
+type FormatRegistry map[string]*Format
+
+// NewFormatRegistry creates a registry of the formats supported out of the
+// box: markdown, json, yaml, csv, html and the index of SearchIndex. The
+// markdown notes are organized by SIG, use Register to replace it with a
+// configured MarkdownRenderer.
+func NewFormatRegistry() FormatRegistry {
+	r := FormatRegistry{}
+	r.Register(&Format{
+		Name:           "markdown",
+		Extension:      ".md",
+		Renderer:       MarkdownRenderer(nil),
+		DocumentFields: MarkdownDocumentFields,
+	})
+	r.Register(&Format{Name: "json", Extension: ".json", Renderer: RendererFunc(renderJSON)})
+	r.Register(&Format{Name: "yaml", Extension: ".yaml", Renderer: RendererFunc(renderYAML)})
+	r.Register(&Format{Name: "csv", Extension: ".csv", Renderer: RendererFunc(renderCSV)})
+	r.Register(&Format{Name: "html", Extension: ".html", Renderer: RendererFunc(renderHTML)})
+	r.Register(&Format{Name: "index", Extension: ".index.json", Renderer: RendererFunc(renderIndex)})
+	return r
+}
+
+// Register adds a format to the registry, replacing any format of the same
+// name.
+func (r FormatRegistry) Register(format *Format) {
+	r[format.Name] = format
+}
+
+// Lookup returns the format of the given name.
+func (r FormatRegistry) Lookup(name string) (*Format, error) {
+	format, ok := r[name]
+	if !ok {
+		return nil, NewError(ErrValidation, "%q is an unsupported format (options: %s)", name, strings.Join(r.Names(), ", "))
+	}
+	return format, nil
+}
+
+// Names returns the sorted names of the registered formats.
+func (r FormatRegistry) Names() []string {
+	names := make([]string, 0, len(r))
+	for name := range r {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// MarkdownRenderer renders the notes as a markdown Document, organized by
+// SIG, or by kind if a taxonomy is given.
+func MarkdownRenderer(taxonomy *Taxonomy, opts ...RenderOption) Renderer {
+	return RendererFunc(func(w io.Writer, releaseNotes ReleaseNoteList) error {
+		var doc *Document
+		var err error
+		if taxonomy != nil {
+			doc, err = CreateDocumentByKind(releaseNotes, taxonomy)
+		} else {
+			doc, err = CreateDocument(releaseNotes)
+		}
+		if err != nil {
+			return err
+		}
+		return RenderMarkdown(doc, w, opts...)
+	})
+}
+
+func renderJSON(w io.Writer, releaseNotes ReleaseNoteList) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(releaseNotes)
+}
+
+// sortedNotes returns the notes sorted by PR number
+func sortedNotes(releaseNotes ReleaseNoteList) []*ReleaseNote {
+	prs := make([]int, 0, len(releaseNotes))
+	for pr := range releaseNotes {
+		prs = append(prs, pr)
+	}
+	sort.Ints(prs)
+
+	sorted := make([]*ReleaseNote, 0, len(prs))
+	for _, pr := range prs {
+		sorted = append(sorted, releaseNotes[pr])
+	}
+	return sorted
+}
+
+// htmlTemplate renders a standalone page with a table of the notes
+var htmlTemplate = template.Must(template.New("notes").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Release Notes</title>
+</head>
+<body>
+<table>
+<thead>
+<tr><th>PR</th><th>Author</th><th>SIGs</th><th>Kinds</th><th>Note</th></tr>
+</thead>
+<tbody>
+{{- range . }}
+<tr>
+<td>{{ if .PrUrl }}<a href="{{ .PrUrl }}">#{{ .PrNumber }}</a>{{ else }}#{{ .PrNumber }}{{ end }}</td>
+<td>{{ if .AuthorUrl }}<a href="{{ .AuthorUrl }}">{{ .Author }}</a>{{ else }}{{ .Author }}{{ end }}</td>
+<td>{{ range $i, $sig := .SIGs }}{{ if $i }}, {{ end }}{{ $sig }}{{ end }}</td>
+<td>{{ range $i, $kind := .Kinds }}{{ if $i }}, {{ end }}{{ $kind }}{{ end }}</td>
+<td>{{ if .ActionRequired }}<strong>Action required:</strong> {{ end }}{{ .Text }}
+{{- range .Documentation }} <a href="{{ .URL }}">{{ if .Description }}{{ .Description }}{{ else }}{{ .URL }}{{ end }}</a>{{ end }}</td>
+</tr>
+{{- end }}
+</tbody>
+</table>
+</body>
+</html>
+`))
+
+// renderHTML renders one table row per note, sorted by PR number. The texts
+// are escaped, so the notes cannot inject markup.
+func renderHTML(w io.Writer, releaseNotes ReleaseNoteList) error {
+	return htmlTemplate.Execute(w, sortedNotes(releaseNotes))
+}
+
+// csvHeader are the columns of the csv format
+var csvHeader = []string{
+	"pr_number", "pr_url", "author", "sigs", "kinds", "areas",
+	"category", "action_required", "release_version", "text",
+}
+
+// renderCSV renders one row per note, sorted by PR number. Lists are joined
+// by spaces.
+func renderCSV(w io.Writer, releaseNotes ReleaseNoteList) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+	for _, note := range sortedNotes(releaseNotes) {
+		if err := cw.Write([]string{
+			strconv.Itoa(note.PrNumber),
+			note.PrUrl,
+			note.Author,
+			strings.Join(note.SIGs, " "),
+			strings.Join(note.Kinds, " "),
+			strings.Join(note.Areas, " "),
+			note.Category,
+			strconv.FormatBool(note.ActionRequired),
+			note.ReleaseVersion,
+			note.Text,
+		}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
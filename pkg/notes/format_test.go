@@ -0,0 +1,108 @@
+// Copyright 2019 The Kubernetes Authors All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notes
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatRegistry(t *testing.T) {
+	registry := NewFormatRegistry()
+	require.Equal(t, []string{"csv", "html", "index", "json", "markdown", "yaml"}, registry.Names())
+
+	_, err := registry.Lookup("xml")
+	require.Error(t, err)
+	require.Equal(t, ErrValidation, Kind(err))
+	require.Contains(t, err.Error(), "options: csv, html, index, json, markdown, yaml")
+
+	registry.Register(&Format{Name: "text", Extension: ".txt", Renderer: RendererFunc(renderJSON)})
+	require.Equal(t, []string{"csv", "html", "index", "json", "markdown", "text", "yaml"}, registry.Names())
+}
+
+func TestRenderFormats(t *testing.T) {
+	releaseNotes := ReleaseNoteList{
+		2: {Text: "Fixed a crash, again", Markdown: "Fixed a crash, again", PrNumber: 2, PrUrl: "https://github.com/kubernetes/kubernetes/pull/2", Author: "bob", SIGs: []string{"node"}, Kinds: []string{"bug"}},
+		1: {Text: "Added a <flag>", Markdown: "Added a <flag>", PrNumber: 1, PrUrl: "https://github.com/kubernetes/kubernetes/pull/1", Author: "alice", SIGs: []string{"cli", "node"}, ActionRequired: true, Documentation: []*Documentation{{URL: "https://kep.k8s.io/1", Type: DocTypeKEP}}},
+	}
+	registry := NewFormatRegistry()
+
+	// all formats render the same notes
+	outputs := map[string]string{}
+	for _, name := range registry.Names() {
+		format, err := registry.Lookup(name)
+		require.NoError(t, err)
+		var b bytes.Buffer
+		require.NoError(t, format.Renderer.Render(&b, releaseNotes))
+		outputs[name] = b.String()
+	}
+
+	require.Equal(t, strings.Join([]string{
+		"pr_number,pr_url,author,sigs,kinds,areas,category,action_required,release_version,text",
+		"1,https://github.com/kubernetes/kubernetes/pull/1,alice,cli node,,,,true,,Added a <flag>",
+		`2,https://github.com/kubernetes/kubernetes/pull/2,bob,node,bug,,,false,,"Fixed a crash, again"`,
+		"",
+	}, "\n"), outputs["csv"])
+
+	decoded := ReleaseNoteList{}
+	require.NoError(t, json.Unmarshal([]byte(outputs["json"]), &decoded))
+	require.Equal(t, releaseNotes, decoded)
+
+	index := NewSearchIndex()
+	require.NoError(t, json.Unmarshal([]byte(outputs["index"]), index))
+	require.Len(t, index.Search("crash"), 1)
+
+	require.Equal(t, strings.Join([]string{
+		"1:",
+		"  commit: \"\"",
+		"  text: \"Added a <flag>\"",
+		"  markdown: \"Added a <flag>\"",
+		"  documentation:",
+		"    - url: https://kep.k8s.io/1",
+		"      type: KEP",
+		"  author: alice",
+		"  author_url: \"\"",
+		"  pr_url: https://github.com/kubernetes/kubernetes/pull/1",
+		"  pr_number: 1",
+		"  sigs:",
+		"    - cli",
+		"    - node",
+		"  action_required: true",
+		"2:",
+		"  commit: \"\"",
+		"  text: \"Fixed a crash, again\"",
+		"  markdown: \"Fixed a crash, again\"",
+		"  author: bob",
+		"  author_url: \"\"",
+		"  pr_url: https://github.com/kubernetes/kubernetes/pull/2",
+		"  pr_number: 2",
+		"  kinds:",
+		"    - bug",
+		"  sigs:",
+		"    - node",
+		"",
+	}, "\n"), outputs["yaml"])
+
+	require.Contains(t, outputs["html"], `<a href="https://github.com/kubernetes/kubernetes/pull/1">#1</a>`)
+	require.Contains(t, outputs["html"], "<strong>Action required:</strong> Added a &lt;flag&gt;")
+	require.Contains(t, outputs["html"], "<td>cli, node</td>")
+
+	require.Contains(t, outputs["markdown"], "Added a <flag>")
+	require.Contains(t, outputs["markdown"], "Fixed a crash, again")
+}
@@ -0,0 +1,209 @@
+// Copyright 2019 The Kubernetes Authors All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notes
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/google/go-github/github"
+	"github.com/pkg/errors"
+)
+
+// GitCommit is a commit of a local repository which merged a PR.
+type GitCommit struct {
+	SHA      string
+	Subject  string
+	PrNumber int
+
+	// Time is the committer date, which is when the PR was merged
+	Time time.Time
+}
+
+// runGit runs git in the directory and returns its output
+func runGit(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", errors.Wrapf(err, "git %s: %s", strings.Join(args, " "), strings.TrimSpace(stderr.String()))
+	}
+	return string(out), nil
+}
+
+// PrepareGitRepo makes sure that a clone of the GitHub repository at path
+// contains the commits start and end. The repository is cloned if path does
+// not exist yet, and fetched if it lacks one of the commits.
+func PrepareGitRepo(logger log.Logger, path, start, end string, opts ...GithubApiOption) error {
+	c := configFromOpts(opts...)
+
+	if _, err := os.Stat(filepath.Join(path, ".git")); os.IsNotExist(err) {
+		url := fmt.Sprintf("https://github.com/%s/%s.git", c.org, c.repo)
+		level.Info(logger).Log("msg", "cloning repository. this might take a while...", "url", url, "path", path)
+		if _, err := runGit(".", "clone", "--no-checkout", url, path); err != nil {
+			return err
+		}
+	}
+
+	for _, sha := range []string{start, end} {
+		if _, err := runGit(path, "rev-parse", "--verify", "--quiet", sha+"^{commit}"); err == nil {
+			continue
+		}
+		level.Info(logger).Log("msg", "fetching repository", "path", path, "missing", sha)
+		if _, err := runGit(path, "fetch", "origin"); err != nil {
+			return err
+		}
+		if _, err := runGit(path, "rev-parse", "--verify", "--quiet", sha+"^{commit}"); err != nil {
+			return NewError(ErrNotFound, "commit %s not found in %s", sha, path)
+		}
+	}
+	return nil
+}
+
+// ListGitCommits lists the commits of the local repository at path which
+// merged a PR between start (exclusive) and end (inclusive). Only the first
+// parent history of end is walked, so that the commits of the merged PRs
+// themselves are skipped. PR numbers are parsed from the subjects of both
+// merge commits and squashed commits.
+func ListGitCommits(path, start, end string) ([]*GitCommit, error) {
+	out, err := runGit(path, "log", "--first-parent", "--reverse", "--format=%H %ct %s", start+".."+end)
+	if err != nil {
+		return nil, err
+	}
+
+	commits := []*GitCommit{}
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		parts := strings.SplitN(line, " ", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		number, err := getPRNumberFromCommitMessage(parts[2])
+		if err != nil {
+			// a direct push, which has no PR
+			continue
+		}
+		seconds, err := strconv.ParseInt(parts[1], 10, 64)
+		if err != nil {
+			return nil, NewError(ErrParse, "invalid committer date of %s: %s", parts[0], parts[1])
+		}
+		commits = append(commits, &GitCommit{
+			SHA:      parts[0],
+			Subject:  parts[2],
+			PrNumber: number,
+			Time:     time.Unix(seconds, 0).UTC(),
+		})
+	}
+	return commits, nil
+}
+
+// ListReleaseNotesFromGit produces a list of fully contextualized release
+// notes like ListReleaseNotes, but walks the commit range in the local
+// repository at path instead of listing the commits with the GitHub API. Only
+// the PRs are fetched, which makes large ranges much faster and spares the
+// rate limit. If some of the PRs cannot be processed, the notes of all other
+// PRs are returned together with a CommitErrors error.
+func ListReleaseNotesFromGit(
+	client *github.Client,
+	logger log.Logger,
+	path,
+	start,
+	end,
+	relVer string,
+	opts ...GithubApiOption,
+) (ReleaseNoteList, error) {
+	c := configFromOpts(opts...)
+
+	commits, err := ListGitCommits(path, start, end)
+	if err != nil {
+		return nil, err
+	}
+	level.Info(logger).Log("msg", "found merged PRs in the local repository", "count", len(commits))
+
+	prs := []PullRequestRef{}
+	for _, commit := range commits {
+		if c.window.Contains(commit.Time) {
+			prs = append(prs, PullRequestRef{Org: c.org, Repo: c.repo, Number: commit.PrNumber})
+		}
+	}
+	skipped := c.planBudget(logger, client, prs)
+
+	commitErrs := CommitErrors{}
+	notes := make(ReleaseNoteList)
+	for i, commit := range commits {
+		if err := c.interrupted(); err != nil {
+			return notes, &InterruptedError{Processed: i, Total: len(commits), Err: err, Failed: commitErrs}
+		}
+		if !c.window.Contains(commit.Time) {
+			continue
+		}
+		if skipped[PullRequestRef{Org: c.org, Repo: c.repo, Number: commit.PrNumber}] {
+			continue
+		}
+
+		ctx, cancel := c.requestContext()
+		pr, _, err := client.PullRequests.Get(ctx, c.org, c.repo, commit.PrNumber)
+		cancel()
+		var note *ReleaseNote
+		if err == nil {
+			note, err = ReleaseNoteFromPR(pr, relVer, opts...)
+		}
+		if Kind(err) == ErrParse {
+			level.Debug(logger).Log("msg", "skipping PR without release note", "pr", commit.PrNumber)
+			continue
+		}
+		if err != nil {
+			level.Error(logger).Log(
+				"err", err,
+				"msg", "error getting the release note of PR while listing release notes",
+				"pr", commit.PrNumber,
+				"sha", commit.SHA,
+			)
+			commitErr := &CommitError{SHA: commit.SHA, Err: classify(err)}
+			if c.failFast {
+				return nil, commitErr
+			}
+			commitErrs = append(commitErrs, commitErr)
+			continue
+		}
+
+		if strings.TrimSpace(note.Text) == "NONE" {
+			continue
+		}
+		note.Commit = commit.SHA
+		notes[note.PrNumber] = note
+	}
+
+	if len(skipped) > 0 {
+		return notes, &InterruptedError{
+			Processed: len(commits) - len(skipped),
+			Total:     len(commits),
+			Err:       ErrQuotaExhausted,
+			Failed:    commitErrs,
+		}
+	}
+	if len(commitErrs) > 0 {
+		return notes, commitErrs
+	}
+	return notes, nil
+}
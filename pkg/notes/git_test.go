@@ -0,0 +1,105 @@
+package notes
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/google/go-github/github"
+	"github.com/stretchr/testify/require"
+)
+
+// newGitRepo creates a repository with a merged PR, a squashed PR and a
+// direct push after its first commit, and returns its path and the SHAs of
+// the first and last commit
+func newGitRepo(t *testing.T) (path, start, end string) {
+	path, err := ioutil.TempDir("", "git-")
+	require.NoError(t, err)
+
+	git := func(args ...string) string {
+		args = append([]string{"-c", "user.name=alice", "-c", "user.email=alice@example.com"}, args...)
+		out, err := runGit(path, args...)
+		require.NoError(t, err)
+		return strings.TrimSpace(out)
+	}
+	git("init", "-q")
+	git("commit", "-q", "--allow-empty", "-m", "Initial commit")
+	start = git("rev-parse", "HEAD")
+	git("checkout", "-q", "-b", "feature")
+	git("commit", "-q", "--allow-empty", "-m", "Add a flag")
+	git("checkout", "-q", "-")
+	git("merge", "-q", "--no-ff", "feature", "-m", "Merge pull request #1 from alice/feature")
+	git("commit", "-q", "--allow-empty", "-m", "Fix a bug (#2)")
+	git("commit", "-q", "--allow-empty", "-m", "Update the docs")
+	end = git("rev-parse", "HEAD")
+	return path, start, end
+}
+
+func TestListGitCommits(t *testing.T) {
+	path, start, end := newGitRepo(t)
+	defer os.RemoveAll(path)
+
+	require.NoError(t, PrepareGitRepo(log.NewNopLogger(), path, start, end))
+
+	commits, err := ListGitCommits(path, start, end)
+	require.NoError(t, err)
+	require.Len(t, commits, 2)
+	require.Equal(t, 1, commits[0].PrNumber)
+	require.Equal(t, "Merge pull request #1 from alice/feature", commits[0].Subject)
+	require.Equal(t, 2, commits[1].PrNumber)
+	require.Equal(t, "Fix a bug (#2)", commits[1].Subject)
+
+	// the repository has no remote to fetch a missing commit from
+	require.Error(t, PrepareGitRepo(log.NewNopLogger(), path, start, "0000000000000000000000000000000000000000"))
+}
+
+func TestListReleaseNotesFromGit(t *testing.T) {
+	path, start, end := newGitRepo(t)
+	defer os.RemoveAll(path)
+
+	mux := http.NewServeMux()
+	pr := func(number int, body string) string {
+		return fmt.Sprintf(`{
+			"number": %d,
+			"body": %q,
+			"user": {"login": "alice"},
+			"labels": [{"name": "sig/cli"}]
+		}`, number, body)
+	}
+	mux.HandleFunc("/repos/o/r/pulls/1", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, pr(1, "```release-note\nAdds a flag to kubectl.\n```"))
+	})
+	mux.HandleFunc("/repos/o/r/pulls/2", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, pr(2, "```release-note\nNONE\n```"))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+
+	notes, err := ListReleaseNotesFromGit(client, log.NewNopLogger(), path, start, end, "v1.15.0", WithOrg("o"), WithRepo("r"))
+	require.NoError(t, err)
+	require.Len(t, notes, 1)
+	require.Equal(t, "Adds a flag to kubectl.", notes[1].Text)
+	require.Equal(t, "https://github.com/o/r/pull/1", notes[1].PrUrl)
+	require.Equal(t, "v1.15.0", notes[1].ReleaseVersion)
+
+	commits, err := ListGitCommits(path, start, end)
+	require.NoError(t, err)
+	require.Equal(t, commits[0].SHA, notes[1].Commit)
+	require.False(t, commits[0].Time.IsZero())
+
+	// the PRs merged outside of the window are not fetched
+	window := TimeWindow{Since: commits[1].Time.Add(time.Second)}
+	notes, err = ListReleaseNotesFromGit(client, log.NewNopLogger(), path, start, end, "v1.15.0", WithOrg("o"), WithRepo("r"), WithTimeWindow(window))
+	require.NoError(t, err)
+	require.Empty(t, notes)
+}
@@ -0,0 +1,184 @@
+// Copyright 2019 The Kubernetes Authors All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notes
+
+import (
+	"encoding/json"
+	"html"
+	"io"
+	"io/ioutil"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/blang/semver"
+)
+
+// IndexDocument is a release note as kept in a SearchIndex.
+type IndexDocument struct {
+	Release  string   `json:"release"`
+	PrNumber int      `json:"pr_number"`
+	PrUrl    string   `json:"pr_url"`
+	Text     string   `json:"text"`
+	SIGs     []string `json:"sigs,omitempty"`
+}
+
+// SearchIndex is a static inverted index over the release notes of one or
+// more releases, for queries like "which release changed flag X". It is
+// serialized as plain JSON, so that it can also be loaded by a static site.
+type SearchIndex struct {
+	Documents []*IndexDocument `json:"documents"`
+
+	// Terms maps every term to the positions of the documents containing it
+	// in Documents
+	Terms map[string][]int `json:"terms"`
+}
+
+// termRe matches the terms of a text. Dashes, underscores and dots are kept
+// within terms, so that flags, feature gates and fields are single terms.
+var termRe = regexp.MustCompile(`[\p{L}\p{N}][\p{L}\p{N}_.-]*[\p{L}\p{N}]|[\p{L}\p{N}]`)
+
+// Terms returns the lower cased terms of a text. HTML entities, like the
+// escaped hashes of the notes, are unescaped first, so that they do not
+// become terms of their own.
+func Terms(text string) []string {
+	return termRe.FindAllString(strings.ToLower(html.UnescapeString(text)), -1)
+}
+
+// NewSearchIndex creates an empty SearchIndex.
+func NewSearchIndex() *SearchIndex {
+	return &SearchIndex{Documents: []*IndexDocument{}, Terms: map[string][]int{}}
+}
+
+// LoadSearchIndex reads a SearchIndex written by Write.
+func LoadSearchIndex(path string) (*SearchIndex, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	index := NewSearchIndex()
+	if err := json.Unmarshal(content, index); err != nil {
+		return nil, NewError(ErrParse, "error parsing search index %s: %v", path, err)
+	}
+	return index, nil
+}
+
+// Add indexes the release notes, replacing the documents of the same PRs in
+// the same releases.
+func (i *SearchIndex) Add(releaseNotes ReleaseNoteList) {
+	type key struct {
+		release string
+		pr      int
+	}
+	added := map[key]bool{}
+	for _, note := range releaseNotes {
+		added[key{note.ReleaseVersion, note.PrNumber}] = true
+	}
+
+	documents := []*IndexDocument{}
+	for _, doc := range i.Documents {
+		if !added[key{doc.Release, doc.PrNumber}] {
+			documents = append(documents, doc)
+		}
+	}
+	for _, note := range releaseNotes {
+		documents = append(documents, &IndexDocument{
+			Release:  note.ReleaseVersion,
+			PrNumber: note.PrNumber,
+			PrUrl:    note.PrUrl,
+			Text:     html.UnescapeString(note.Text),
+			SIGs:     note.SIGs,
+		})
+	}
+	i.Documents = documents
+	i.reindex()
+}
+
+// reindex sorts the documents, newest release first, and rebuilds the terms
+func (i *SearchIndex) reindex() {
+	sort.SliceStable(i.Documents, func(a, b int) bool {
+		da, db := i.Documents[a], i.Documents[b]
+		if da.Release != db.Release {
+			return releaseLess(db.Release, da.Release)
+		}
+		return da.PrNumber < db.PrNumber
+	})
+
+	i.Terms = map[string][]int{}
+	for pos, doc := range i.Documents {
+		seen := map[string]bool{}
+		for _, term := range Terms(doc.Text) {
+			if !seen[term] {
+				seen[term] = true
+				i.Terms[term] = append(i.Terms[term], pos)
+			}
+		}
+	}
+}
+
+// releaseLess orders releases by semantic version, and releases which are no
+// semantic versions by name before them
+func releaseLess(a, b string) bool {
+	va, errA := semver.Parse(strings.TrimPrefix(a, "v"))
+	vb, errB := semver.Parse(strings.TrimPrefix(b, "v"))
+	switch {
+	case errA == nil && errB == nil:
+		return va.LT(vb)
+	case errA != nil && errB != nil:
+		return a < b
+	}
+	return errA != nil
+}
+
+// Search returns the documents containing all terms of the query, newest
+// release first.
+func (i *SearchIndex) Search(query string) []*IndexDocument {
+	terms := Terms(query)
+	if len(terms) == 0 {
+		return nil
+	}
+
+	counts := map[int]int{}
+	for _, term := range terms {
+		for _, pos := range i.Terms[term] {
+			counts[pos]++
+		}
+	}
+	matches := []int{}
+	for pos, count := range counts {
+		if count == len(terms) {
+			matches = append(matches, pos)
+		}
+	}
+	sort.Ints(matches)
+
+	documents := make([]*IndexDocument, 0, len(matches))
+	for _, pos := range matches {
+		documents = append(documents, i.Documents[pos])
+	}
+	return documents
+}
+
+// Write writes the index as JSON.
+func (i *SearchIndex) Write(w io.Writer) error {
+	return json.NewEncoder(w).Encode(i)
+}
+
+// renderIndex renders the search index of the notes of a single release
+func renderIndex(w io.Writer, releaseNotes ReleaseNoteList) error {
+	index := NewSearchIndex()
+	index.Add(releaseNotes)
+	return index.Write(w)
+}
@@ -0,0 +1,71 @@
+package notes
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTerms(t *testing.T) {
+	require.Equal(t,
+		[]string{"kubelet", "adds", "the", "cpu-manager-policy", "flag", "spec.tolerations", "a"},
+		Terms("kubelet: Adds the `--cpu-manager-policy` flag (spec.tolerations), a."),
+	)
+	// escaped hashes are not terms
+	require.Equal(t, []string{"fixes", "1234"}, Terms("Fixes &#35;1234"))
+}
+
+func TestSearchIndex(t *testing.T) {
+	index := NewSearchIndex()
+	index.Add(ReleaseNoteList{
+		1: {PrNumber: 1, ReleaseVersion: "v1.15.0", Text: "Adds the --cpu-manager-policy flag to the kubelet"},
+		2: {PrNumber: 2, ReleaseVersion: "v1.15.0", Text: "Fixes a crash of the scheduler"},
+	})
+	index.Add(ReleaseNoteList{
+		3: {PrNumber: 3, ReleaseVersion: "v1.16.0", Text: "The --cpu-manager-policy flag of the kubelet is GA", SIGs: []string{"node"}},
+	})
+
+	// newest release first
+	matches := index.Search("kubelet --cpu-manager-policy")
+	require.Len(t, matches, 2)
+	require.Equal(t, 3, matches[0].PrNumber)
+	require.Equal(t, "v1.16.0", matches[0].Release)
+	require.Equal(t, 1, matches[1].PrNumber)
+
+	// all terms have to match
+	require.Empty(t, index.Search("kubelet crash"))
+	require.Empty(t, index.Search(""))
+
+	// the notes of a release are replaced when it is indexed again
+	index.Add(ReleaseNoteList{
+		2: {PrNumber: 2, ReleaseVersion: "v1.15.0", Text: "Fixes a panic of the scheduler"},
+	})
+	require.Len(t, index.Documents, 3)
+	require.Empty(t, index.Search("crash"))
+	require.Len(t, index.Search("panic"), 1)
+
+	// the documents keep the unescaped text
+	index.Add(ReleaseNoteList{
+		4: {PrNumber: 4, ReleaseVersion: "v1.16.0", Text: "Reverts &#35;1234"},
+	})
+	require.Empty(t, index.Search("35"))
+	matches = index.Search("1234")
+	require.Len(t, matches, 1)
+	require.Equal(t, "Reverts #1234", matches[0].Text)
+
+	// the index survives a round trip
+	dir, err := ioutil.TempDir("", "index-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	var b bytes.Buffer
+	require.NoError(t, index.Write(&b))
+	path := filepath.Join(dir, "index.json")
+	require.NoError(t, ioutil.WriteFile(path, b.Bytes(), 0644))
+	loaded, err := LoadSearchIndex(path)
+	require.NoError(t, err)
+	require.Equal(t, index, loaded)
+}
@@ -0,0 +1,244 @@
+// Copyright 2019 The Kubernetes Authors All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notes
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// SpellChecker finds misspelled words in a text.
+type SpellChecker interface {
+	// Misspellings returns the misspelled words of the text, mapped to their
+	// suggested correction.
+	Misspellings(text string) map[string]string
+}
+
+// CommonMisspellings is a SpellChecker which knows a list of words commonly
+// misspelled in release notes, similar to github.com/client9/misspell.
+type CommonMisspellings map[string]string
+
+// DefaultMisspellings are the misspellings found by the default linter.
+var DefaultMisspellings = CommonMisspellings{
+	"accomodate":    "accommodate",
+	"acheive":       "achieve",
+	"adress":        "address",
+	"agressive":     "aggressive",
+	"alway":         "always",
+	"arguement":     "argument",
+	"authenticaion": "authentication",
+	"availabe":      "available",
+	"begining":      "beginning",
+	"compatability": "compatibility",
+	"compatibilty":  "compatibility",
+	"configuraton":  "configuration",
+	"contaier":      "container",
+	"continous":     "continuous",
+	"correclty":     "correctly",
+	"defualt":       "default",
+	"dependancy":    "dependency",
+	"dependancies":  "dependencies",
+	"deprecatd":     "deprecated",
+	"existant":      "existent",
+	"enviroment":    "environment",
+	"explicitely":   "explicitly",
+	"fucntion":      "function",
+	"garantee":      "guarantee",
+	"immediatly":    "immediately",
+	"independant":   "independent",
+	"initialise":    "initialize",
+	"lenght":        "length",
+	"namepsace":     "namespace",
+	"neccessary":    "necessary",
+	"occured":       "occurred",
+	"occurence":     "occurrence",
+	"paramter":      "parameter",
+	"persistant":    "persistent",
+	"preemptable":   "preemptible",
+	"priviledged":   "privileged",
+	"recieve":       "receive",
+	"recieved":      "received",
+	"reponse":       "response",
+	"retreive":      "retrieve",
+	"seperate":      "separate",
+	"seperately":    "separately",
+	"succesful":     "successful",
+	"successfull":   "successful",
+	"supress":       "suppress",
+	"teh":           "the",
+	"untill":        "until",
+	"wich":          "which",
+}
+
+// Misspellings implements SpellChecker.
+func (c CommonMisspellings) Misspellings(text string) map[string]string {
+	result := map[string]string{}
+	for _, word := range strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r)
+	}) {
+		if correction, ok := c[strings.ToLower(word)]; ok {
+			result[word] = correction
+		}
+	}
+	return result
+}
+
+// Linter checks the wording of release notes before they are published.
+type Linter struct {
+	// SpellChecker finds misspelled words. Spell checking is disabled if it
+	// is nil.
+	SpellChecker SpellChecker
+
+	// MaxLineLength is the maximum length of a line of a note. The length is
+	// not checked if it is zero.
+	MaxLineLength int
+
+	// MinWords and MaxWords limit the number of words of a note. A limit is
+	// not checked if it is zero.
+	MinWords int
+	MaxWords int
+
+	// Summarizer proposes a shorter version of notes with more than MaxWords
+	// words. No proposal is made if it is nil.
+	Summarizer Summarizer
+}
+
+// NewLinter creates a Linter with the default configuration.
+func NewLinter() *Linter {
+	return &Linter{
+		SpellChecker:  DefaultMisspellings,
+		MaxLineLength: 500,
+		Summarizer:    &ExtractiveSummarizer{},
+	}
+}
+
+var (
+	// codeSpanRe matches inline code, which is excluded from linting
+	codeSpanRe = regexp.MustCompile("`[^`]*`")
+
+	// thisPRRe matches references to the PR itself, which make no sense
+	// once the note is part of the changelog
+	thisPRRe = regexp.MustCompile(`(?i)\bthis (PR|pull request)\b`)
+)
+
+// Lint checks all notes and sets their Warnings. Besides checking every note
+// on its own, it warns about notes ending in a different punctuation than
+// the majority of notes.
+func (l *Linter) Lint(notes ReleaseNoteList) {
+	withPeriod := 0
+	for _, note := range notes {
+		note.Warnings = l.LintText(note.Text)
+		if endsWithPeriod(note.Text) {
+			withPeriod++
+		}
+	}
+
+	// a tie is resolved in favor of no trailing periods
+	majorityWithPeriod := withPeriod*2 > len(notes)
+	for _, note := range notes {
+		if endsWithPeriod(note.Text) == majorityWithPeriod {
+			continue
+		}
+		if majorityWithPeriod {
+			note.Warnings = append(note.Warnings, "note does not end with a period like most other notes")
+		} else {
+			note.Warnings = append(note.Warnings, "note ends with a period unlike most other notes")
+		}
+	}
+}
+
+// LintText returns the warnings for a single note text.
+func (l *Linter) LintText(text string) []string {
+	warnings := []string{}
+	prose := codeSpanRe.ReplaceAllString(text, "")
+
+	if first := firstWord(text); first != "" && isLowercaseWord(first) {
+		warnings = append(warnings, fmt.Sprintf("note should start with a capital letter, found %q", first))
+	}
+
+	if match := thisPRRe.FindString(prose); match != "" {
+		warnings = append(warnings, fmt.Sprintf("note refers to %q, describe the change instead", match))
+	}
+
+	words := len(strings.Fields(text))
+	if l.MinWords > 0 && words < l.MinWords {
+		warnings = append(warnings, fmt.Sprintf("note has %d words, fewer than %d", words, l.MinWords))
+	}
+	if l.MaxWords > 0 && words > l.MaxWords {
+		warning := fmt.Sprintf("note has %d words, more than %d", words, l.MaxWords)
+		if l.Summarizer != nil {
+			if summary := l.Summarizer.Summarize(text, l.MaxWords); summary != "" {
+				warning += fmt.Sprintf(", consider %q", summary)
+			}
+		}
+		warnings = append(warnings, warning)
+	}
+
+	if l.MaxLineLength > 0 {
+		for i, line := range strings.Split(text, "\n") {
+			if len(line) > l.MaxLineLength {
+				warnings = append(warnings, fmt.Sprintf(
+					"line %d is %d characters long, more than %d",
+					i+1, len(line), l.MaxLineLength,
+				))
+			}
+		}
+	}
+
+	if l.SpellChecker != nil {
+		misspellings := l.SpellChecker.Misspellings(prose)
+		words := make([]string, 0, len(misspellings))
+		for word := range misspellings {
+			words = append(words, word)
+		}
+		sort.Strings(words)
+		for _, word := range words {
+			warnings = append(warnings, fmt.Sprintf("%q is a misspelling of %q", word, misspellings[word]))
+		}
+	}
+
+	return warnings
+}
+
+func firstWord(text string) string {
+	fields := strings.Fields(text)
+	if len(fields) == 0 {
+		return ""
+	}
+	return fields[0]
+}
+
+// isLowercaseWord reports whether the word is an ordinary lowercase word.
+// Identifiers like component names, flags or paths are allowed to start a
+// note in lowercase.
+func isLowercaseWord(word string) bool {
+	word = strings.TrimRight(word, ",:;.")
+	if strings.HasPrefix(word, "kube") {
+		return false
+	}
+	for _, r := range word {
+		if !unicode.IsLower(r) {
+			return false
+		}
+	}
+	return word != ""
+}
+
+func endsWithPeriod(text string) bool {
+	return strings.HasSuffix(strings.TrimSpace(text), ".")
+}
@@ -0,0 +1,76 @@
+package notes
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLintText(t *testing.T) {
+	linter := NewLinter()
+	linter.MaxLineLength = 40
+
+	for _, tc := range []struct {
+		text     string
+		warnings []string
+	}{
+		{"Fixes a crash in the scheduler", []string{}},
+		{"kubeadm: fixes a crash", []string{}},
+		{"`--foo` is deprecated", []string{}},
+		{
+			"fixes a crash",
+			[]string{`note should start with a capital letter, found "fixes"`},
+		},
+		{
+			"This PR adds a flag",
+			[]string{`note refers to "This PR", describe the change instead`},
+		},
+		{
+			"Removes the dependancy on `recieve`",
+			[]string{`"dependancy" is a misspelling of "dependency"`},
+		},
+		{
+			"Adds a flag which can be used to configure the scheduler",
+			[]string{"line 1 is 56 characters long, more than 40"},
+		},
+	} {
+		require.Equal(t, tc.warnings, linter.LintText(tc.text), tc.text)
+	}
+}
+
+func TestLintTrailingPeriods(t *testing.T) {
+	notes := ReleaseNoteList{
+		1: {Text: "Adds a flag."},
+		2: {Text: "Removes a flag."},
+		3: {Text: "Fixes a bug"},
+	}
+	NewLinter().Lint(notes)
+
+	require.Empty(t, notes[1].Warnings)
+	require.Empty(t, notes[2].Warnings)
+	require.Equal(t, []string{"note does not end with a period like most other notes"}, notes[3].Warnings)
+}
+
+func TestLintWordCount(t *testing.T) {
+	linter := NewLinter()
+	linter.MinWords = 3
+	linter.MaxWords = 12
+
+	require.Equal(t, []string{"note has 2 words, fewer than 3"}, linter.LintText("Fixes bugs"))
+	require.Equal(t,
+		[]string{`note has 14 words, more than 12, consider "Adds a scheduler flag. The scheduler flag configures the scheduler."`},
+		linter.LintText("Adds a scheduler flag. It was requested often. The scheduler flag configures the scheduler."),
+	)
+}
+
+func TestExtractiveSummarizer(t *testing.T) {
+	s := &ExtractiveSummarizer{}
+
+	require.Equal(t, "", s.Summarize("", 10))
+	require.Equal(t, "Adds a flag.", s.Summarize("Adds a flag.", 10))
+	require.Equal(t, "Adds a new flag …", s.Summarize("Adds a new flag to the scheduler.", 4))
+	require.Equal(t,
+		"Kubelet supports swap. Swap support in the kubelet is alpha.",
+		s.Summarize("Kubelet supports swap. Thanks to everyone involved. Swap support in the kubelet is alpha.", 11),
+	)
+}
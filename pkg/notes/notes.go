@@ -15,13 +15,13 @@
 package notes
 
 import (
-	"bufio"
 	"context"
 	"fmt"
 	"net/url"
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/go-kit/kit/log"
 	"github.com/go-kit/kit/log/level"
@@ -79,6 +79,17 @@ type ReleaseNote struct {
 	// Tags each note with a release version if specified
 	// If not specified, omitted
 	ReleaseVersion string `json:"release_version,omitempty"`
+
+	// Warnings are wording issues found by the Linter, which should be fixed
+	// before the changelog is cut
+	Warnings []string `json:"warnings,omitempty"`
+
+	// CVEs are the vulnerabilities fixed by the PR, injected from security
+	// advisories when the release is published
+	CVEs []string `json:"cves,omitempty"`
+
+	// Category is the changelog category assigned by a Taxonomy
+	Category string `json:"category,omitempty"`
 }
 
 type Documentation struct {
@@ -115,10 +126,17 @@ type GithubApiOption func(*githubApiConfig)
 // githubApiConfig is a configuration struct that is used to express optional
 // configuration for GitHub API requests
 type githubApiConfig struct {
-	ctx    context.Context
-	org    string
-	repo   string
-	branch string
+	ctx            context.Context
+	org            string
+	repo           string
+	branch         string
+	failFast       bool
+	requestTimeout time.Duration
+	deadline       time.Time
+	window         TimeWindow
+
+	extractionRules *ExtractionRules
+	budget          *QuotaBudget
 }
 
 // WithContext allows the caller to inject a context into GitHub API requests
@@ -152,8 +170,51 @@ func WithBranch(branch string) GithubApiOption {
 	}
 }
 
+// WithRequestTimeout allows the caller to limit the duration of every single
+// GitHub API request. A request which times out fails the processing of the
+// commit it was made for.
+func WithRequestTimeout(timeout time.Duration) GithubApiOption {
+	return func(c *githubApiConfig) {
+		c.requestTimeout = timeout
+	}
+}
+
+// WithOverallDeadline allows the caller to set a point in time after which no
+// more GitHub API requests are made. Listing release notes stops once the
+// deadline has passed and returns the notes gathered so far together with an
+// InterruptedError.
+func WithOverallDeadline(deadline time.Time) GithubApiOption {
+	return func(c *githubApiConfig) {
+		c.deadline = deadline
+	}
+}
+
+// WithFailFast allows the caller to abort listing release notes at the first
+// commit which cannot be processed. By default, the errors of all failing
+// commits are collected and returned as CommitErrors alongside the release
+// notes of the remaining commits.
+func WithFailFast(failFast bool) GithubApiOption {
+	return func(c *githubApiConfig) {
+		c.failFast = failFast
+	}
+}
+
+// WithQuotaBudget allows the caller to limit the number of GitHub API requests,
+// e.g. when running without a token. The PRs the budget does not cover are
+// skipped, and the results of the others are returned together with an
+// InterruptedError caused by ErrQuotaExhausted.
+func WithQuotaBudget(budget *QuotaBudget) GithubApiOption {
+	return func(c *githubApiConfig) {
+		c.budget = budget
+	}
+}
+
 // ListReleaseNotes produces a list of fully contextualized release notes
 // starting from a given commit SHA and ending at starting a given commit SHA.
+// If some of the commits cannot be processed, the notes of all other commits
+// are returned together with a CommitErrors error. If listing the commits is
+// interrupted, the notes of the commits listed so far are returned together
+// with an InterruptedError, which includes the commits which failed.
 func ListReleaseNotes(
 	client *github.Client,
 	logger log.Logger,
@@ -164,30 +225,47 @@ func ListReleaseNotes(
 	relVer string,
 	opts ...GithubApiOption,
 ) (ReleaseNoteList, error) {
-	commits, err := ListCommitsWithNotes(client, logger, branch, start, end, opts...)
-	if err != nil {
-		return nil, err
+	c := configFromOpts(opts...)
+
+	// the notes of the commits listed before an interruption are still
+	// returned, from the PRs fetched while listing them
+	commits, prs, err := listCommitsWithNotes(client, logger, branch, start, end, opts...)
+	interrupted, partial := err.(*InterruptedError)
+	var commitErrs CommitErrors
+	switch e := err.(type) {
+	case nil:
+	case *InterruptedError:
+		commitErrs = e.Failed
+	case CommitErrors:
+		commitErrs = e
+	default:
+		return nil, classify(err)
 	}
 
 	dedupeCache := map[string]struct{}{}
 	notes := make(ReleaseNoteList)
 	for _, commit := range commits {
-
 		if requiredAuthor != "" {
 			if commit.GetAuthor().GetLogin() != requiredAuthor {
 				continue
 			}
 		}
 
-		note, err := ReleaseNoteFromCommit(commit, client, relVer, opts...)
+		note, err := ReleaseNoteFromPR(prs[commit.GetSHA()], relVer, opts...)
 		if err != nil {
 			level.Error(logger).Log(
 				"err", err,
 				"msg", "error getting the release note from commit while listing release notes",
 				"sha", commit.GetSHA(),
 			)
+			commitErr := &CommitError{SHA: commit.GetSHA(), Err: classify(err)}
+			if c.failFast {
+				return nil, commitErr
+			}
+			commitErrs = append(commitErrs, commitErr)
 			continue
 		}
+		note.Commit = commit.GetSHA()
 
 		if strings.TrimSpace(note.Text) == "NONE" {
 			continue
@@ -199,6 +277,13 @@ func ListReleaseNotes(
 		}
 	}
 
+	if partial {
+		interrupted.Failed = commitErrs
+		return notes, interrupted
+	}
+	if len(commitErrs) > 0 {
+		return notes, commitErrs
+	}
 	return notes, nil
 }
 
@@ -206,75 +291,13 @@ func ListReleaseNotes(
 // may contain the commit message, the PR description, etc.
 // This is generally the content inside the ```release-note ``` stanza.
 func NoteTextFromString(s string) (string, error) {
-	exps := []*regexp.Regexp{
-		// (?s) is needed for '.' to be matching on newlines, by default that's disabled
-		// we need to match ungreedy 'U', because after the notes a `docs` block can occur
-		regexp.MustCompile("(?sU)```release-note\\r\\n(?P<note>.+)\\r\\n```"),
-		regexp.MustCompile("(?sU)```dev-release-note\\r\\n(?P<note>.+)"),
-		regexp.MustCompile("(?sU)```\\r\\n(?P<note>.+)\\r\\n```"),
-		regexp.MustCompile("(?sU)```release-note\n(?P<note>.+)\n```"),
-	}
-
-	for _, exp := range exps {
-		match := exp.FindStringSubmatch(s)
-		if len(match) == 0 {
-			continue
-		}
-		result := map[string]string{}
-		for i, name := range exp.SubexpNames() {
-			if i != 0 && name != "" {
-				result[name] = match[i]
-			}
-		}
-
-		note := strings.ReplaceAll(result["note"], "#", "&#35;")
-		note = strings.ReplaceAll(note, "\r", "")
-		note = stripActionRequired(note)
-		note = stripStar(note)
-		return note, nil
-	}
-
-	return "", errors.New("no matches found when parsing note text from commit string")
+	return DefaultExtractionRules.NoteText(s)
 }
 
+// DocumentationFromString returns the documentation links of the ```docs ```
+// stanza of the given string.
 func DocumentationFromString(s string) []*Documentation {
-	regex := regexp.MustCompile("(?s)```docs[\\r]?\\n(?P<text>.+)[\\r]?\\n```")
-	match := regex.FindStringSubmatch(s)
-
-	if len(match) < 1 {
-		// Nothing found, but we don't require it
-		return nil
-	}
-
-	result := []*Documentation{}
-	text := match[1]
-	text = stripStar(text)
-	text = stripDash(text)
-
-	scanner := bufio.NewScanner(strings.NewReader(text))
-	for scanner.Scan() {
-		const httpPrefix = "http"
-		s := strings.SplitN(scanner.Text(), httpPrefix, 2)
-		if len(s) != 2 {
-			continue
-		}
-		description := strings.TrimRight(strings.TrimSpace(s[0]), " :-")
-		urlString := httpPrefix + strings.TrimSpace(s[1])
-
-		// Validate the URL
-		parsedURL, err := url.Parse(urlString)
-		if err != nil {
-			continue
-		}
-
-		result = append(result, &Documentation{
-			Description: description,
-			URL:         urlString,
-			Type:        classifyURL(parsedURL),
-		})
-	}
-
-	return result
+	return DefaultExtractionRules.Documentation(s)
 }
 
 // classifyURL returns the correct DocType for the given url
@@ -297,19 +320,30 @@ func classifyURL(url *url.URL) DocType {
 // ReleaseNoteFromCommit produces a full contextualized release note given a
 // GitHub commit API resource.
 func ReleaseNoteFromCommit(commit *github.RepositoryCommit, client *github.Client, relVer string, opts ...GithubApiOption) (*ReleaseNote, error) {
-	c := configFromOpts(opts...)
-
 	pr, err := PRFromCommit(client, commit, opts...)
 	if err != nil {
 		return nil, errors.Wrapf(err, "error parsing release note from commit %s", commit.GetSHA())
 	}
 
+	note, err := ReleaseNoteFromPR(pr, relVer, opts...)
+	if err != nil {
+		return nil, err
+	}
+	note.Commit = commit.GetSHA()
+	return note, nil
+}
+
+// ReleaseNoteFromPR produces a full contextualized release note given a pull
+// request. The commit of the note is the merge commit of the PR.
+func ReleaseNoteFromPR(pr *github.PullRequest, relVer string, opts ...GithubApiOption) (*ReleaseNote, error) {
+	c := configFromOpts(opts...)
+
 	prBody := pr.GetBody()
-	text, err := NoteTextFromString(prBody)
+	text, err := c.extractionRules.NoteText(prBody)
 	if err != nil {
 		return nil, err
 	}
-	documentation := DocumentationFromString(prBody)
+	documentation := c.extractionRules.Documentation(prBody)
 
 	author := pr.GetUser().GetLogin()
 	authorUrl := fmt.Sprintf("https://github.com/%s", author)
@@ -333,7 +367,7 @@ func ReleaseNoteFromCommit(commit *github.RepositoryCommit, client *github.Clien
 	}
 
 	return &ReleaseNote{
-		Commit:         commit.GetSHA(),
+		Commit:         pr.GetMergeCommitSHA(),
 		Text:           text,
 		Markdown:       markdown,
 		Documentation:  documentation,
@@ -358,12 +392,16 @@ func ListCommits(client *github.Client, branch, start, end string, opts ...Githu
 
 	c.branch = branch
 
-	startCommit, _, err := client.Git.GetCommit(c.ctx, c.org, c.repo, start)
+	ctx, cancel := c.requestContext()
+	startCommit, _, err := client.Git.GetCommit(ctx, c.org, c.repo, start)
+	cancel()
 	if err != nil {
 		return nil, err
 	}
 
-	endCommit, _, err := client.Git.GetCommit(c.ctx, c.org, c.repo, end)
+	ctx, cancel = c.requestContext()
+	endCommit, _, err := client.Git.GetCommit(ctx, c.org, c.repo, end)
+	cancel()
 	if err != nil {
 		return nil, err
 	}
@@ -378,14 +416,18 @@ func ListCommits(client *github.Client, branch, start, end string, opts ...Githu
 		},
 	}
 
-	commits, resp, err := client.Repositories.ListCommits(c.ctx, c.org, c.repo, clo)
+	ctx, cancel = c.requestContext()
+	commits, resp, err := client.Repositories.ListCommits(ctx, c.org, c.repo, clo)
+	cancel()
 	if err != nil {
 		return nil, err
 	}
 	clo.ListOptions.Page++
 
 	for clo.ListOptions.Page <= resp.LastPage {
-		commitPage, _, err := client.Repositories.ListCommits(c.ctx, c.org, c.repo, clo)
+		ctx, cancel = c.requestContext()
+		commitPage, _, err := client.Repositories.ListCommits(ctx, c.org, c.repo, clo)
+		cancel()
 		if err != nil {
 			return nil, err
 		}
@@ -399,7 +441,8 @@ func ListCommits(client *github.Client, branch, start, end string, opts ...Githu
 // ListCommitsWithNotes list commits that have release notes starting from a
 // given commit SHA and ending at a given commit SHA. This function is similar
 // to ListCommits except that only commits with tagged release notes are
-// returned.
+// returned. Commits for which the PR cannot be fetched are skipped and
+// returned as CommitErrors alongside the remaining commits.
 func ListCommitsWithNotes(
 	client *github.Client,
 	logger log.Logger,
@@ -408,19 +451,77 @@ func ListCommitsWithNotes(
 	end string,
 	opts ...GithubApiOption,
 ) ([]*github.RepositoryCommit, error) {
+	commits, _, err := listCommitsWithNotes(client, logger, branch, start, end, opts...)
+	return commits, err
+}
+
+// listCommitsWithNotes lists the commits with release notes like
+// ListCommitsWithNotes, and returns the PRs of the commits by SHA, so that
+// they do not have to be fetched again.
+func listCommitsWithNotes(
+	client *github.Client,
+	logger log.Logger,
+	branch,
+	start,
+	end string,
+	opts ...GithubApiOption,
+) ([]*github.RepositoryCommit, map[string]*github.PullRequest, error) {
+	c := configFromOpts(opts...)
 	filteredCommits := []*github.RepositoryCommit{}
+	prs := map[string]*github.PullRequest{}
+	commitErrs := CommitErrors{}
 
 	commits, err := ListCommits(client, branch, start, end, opts...)
 	if err != nil {
-		return nil, err
+		return nil, nil, classify(err)
 	}
 
-	for _, commit := range commits {
+	var skipped map[PullRequestRef]bool
+	if c.budget != nil {
+		prs := []PullRequestRef{}
+		for _, commit := range commits {
+			if ref, ok := c.prRef(commit); ok {
+				prs = append(prs, ref)
+			}
+		}
+		skipped = c.planBudget(logger, client, prs)
+	}
+
+	for i, commit := range commits {
+		if err := c.interrupted(); err != nil {
+			return filteredCommits, prs, &InterruptedError{Processed: i, Total: len(commits), Err: err, Failed: commitErrs}
+		}
+
+		// the merge commit is committed when the PR is merged
+		if !c.window.Contains(commit.GetCommit().GetCommitter().GetDate()) {
+			continue
+		}
+		if len(skipped) > 0 {
+			if ref, ok := c.prRef(commit); ok && skipped[ref] {
+				continue
+			}
+		}
+
 		pr, err := PRFromCommit(client, commit, opts...)
 		if err != nil {
 			if err.Error() == "no matches found when parsing PR from commit" {
 				continue
 			}
+			commitErr := &CommitError{SHA: commit.GetSHA(), Err: classify(err)}
+			if c.failFast {
+				return nil, nil, commitErr
+			}
+			commitErrs = append(commitErrs, commitErr)
+			continue
+		}
+		prs[commit.GetSHA()] = pr
+
+		// the markers below are those of the kubernetes PR template
+		if c.extractionRules != DefaultExtractionRules {
+			if _, err := c.extractionRules.NoteText(pr.GetBody()); err == nil {
+				filteredCommits = append(filteredCommits, commit)
+			}
+			continue
 		}
 
 		// exclusionFilters is a list of regular expressions that match commits that
@@ -450,7 +551,7 @@ func ListCommitsWithNotes(
 		for _, filter := range exclusionFilters {
 			match, err := regexp.MatchString(filter, pr.GetBody())
 			if err != nil {
-				return nil, err
+				return nil, nil, err
 			}
 			if match {
 				excluded = true
@@ -472,7 +573,7 @@ func ListCommitsWithNotes(
 		for _, filter := range inclusionFilters {
 			match, err := regexp.MatchString(filter, pr.GetBody())
 			if err != nil {
-				return nil, err
+				return nil, nil, err
 			}
 			if match {
 				filteredCommits = append(filteredCommits, commit)
@@ -480,7 +581,30 @@ func ListCommitsWithNotes(
 		}
 	}
 
-	return filteredCommits, nil
+	if len(skipped) > 0 {
+		return filteredCommits, prs, &InterruptedError{
+			Processed: len(commits) - len(skipped),
+			Total:     len(commits),
+			Err:       ErrQuotaExhausted,
+			Failed:    commitErrs,
+		}
+	}
+	if len(commitErrs) > 0 {
+		return filteredCommits, prs, commitErrs
+	}
+	return filteredCommits, prs, nil
+}
+
+// prRef returns the PR of a commit within the time window
+func (c *githubApiConfig) prRef(commit *github.RepositoryCommit) (PullRequestRef, bool) {
+	if !c.window.Contains(commit.GetCommit().GetCommitter().GetDate()) {
+		return PullRequestRef{}, false
+	}
+	number, err := getPRNumberFromCommitMessage(commit.GetCommit().GetMessage())
+	if err != nil {
+		return PullRequestRef{}, false
+	}
+	return PullRequestRef{Org: c.org, Repo: c.repo, Number: number}, true
 }
 
 // PRFromCommit return an API Pull Request struct given a commit struct. This is
@@ -495,7 +619,9 @@ func PRFromCommit(client *github.Client, commit *github.RepositoryCommit, opts .
 	}
 	// Given the PR number that we've now converted to an integer, get the PR from
 	// the API
-	pr, _, err := client.PullRequests.Get(c.ctx, c.org, c.repo, number)
+	ctx, cancel := c.requestContext()
+	defer cancel()
+	pr, _, err := client.PullRequests.Get(ctx, c.org, c.repo, number)
 	return pr, err
 }
 
@@ -573,14 +699,44 @@ func filterCommits(
 	return filteredCommits, nil
 }
 
+// requestContext returns the context for a single GitHub API request, which
+// honors both the request timeout and the overall deadline
+func (c *githubApiConfig) requestContext() (context.Context, context.CancelFunc) {
+	ctx, cancelDeadline := c.ctx, context.CancelFunc(func() {})
+	if !c.deadline.IsZero() {
+		ctx, cancelDeadline = context.WithDeadline(ctx, c.deadline)
+	}
+	if c.requestTimeout <= 0 {
+		return ctx, cancelDeadline
+	}
+	ctx, cancelTimeout := context.WithTimeout(ctx, c.requestTimeout)
+	return ctx, func() {
+		cancelTimeout()
+		cancelDeadline()
+	}
+}
+
+// interrupted returns the reason why no more GitHub API requests should be
+// made, or nil if processing can continue
+func (c *githubApiConfig) interrupted() error {
+	if err := c.ctx.Err(); err != nil {
+		return err
+	}
+	if !c.deadline.IsZero() && !time.Now().Before(c.deadline) {
+		return context.DeadlineExceeded
+	}
+	return nil
+}
+
 // configFromOpts is an internal helper for turning a set of functional options
 // into a populated *githubApiConfig struct with consistent defaults.
 func configFromOpts(opts ...GithubApiOption) *githubApiConfig {
 	c := &githubApiConfig{
-		ctx:    context.Background(),
-		org:    "kubernetes",
-		repo:   "kubernetes",
-		branch: "master",
+		ctx:             context.Background(),
+		org:             "kubernetes",
+		repo:            "kubernetes",
+		branch:          "master",
+		extractionRules: DefaultExtractionRules,
 	}
 
 	for _, opt := range opts {
@@ -632,7 +788,7 @@ func getPRNumberFromCommitMessage(commitMessage string) (int, error) {
 		// If the PR was squash merged, the regexp is different
 		match = regexp.MustCompile(`\(#(?P<number>\d+)\)`).FindStringSubmatch(commitMessage)
 		if len(match) == 0 {
-			return 0, errors.New("no matches found when parsing PR from commit")
+			return 0, NewError(ErrParse, "no matches found when parsing PR from commit")
 		}
 	}
 	result := map[string]string{}
@@ -3,9 +3,13 @@ package notes
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/google/go-github/github"
 	"github.com/stretchr/testify/require"
@@ -13,16 +17,40 @@ import (
 )
 
 func githubClient(t *testing.T) *github.Client {
+	return github.NewClient(githubHTTPClient(t))
+}
+
+func githubHTTPClient(t *testing.T) *http.Client {
 	token, tokenSet := os.LookupEnv("GITHUB_TOKEN")
 	if !tokenSet {
 		t.Skip("GITHUB_TOKEN is not set")
 	}
 
 	ctx := context.Background()
-	httpClient := oauth2.NewClient(ctx, oauth2.StaticTokenSource(
+	return oauth2.NewClient(ctx, oauth2.StaticTokenSource(
 		&oauth2.Token{AccessToken: token},
 	))
-	return github.NewClient(httpClient)
+}
+
+// cassetteClient returns a GitHub client which replays the interactions of
+// the named cassette in testdata/cassettes. With RECORD_CASSETTES=1 the
+// interactions are recorded from the GitHub API using GITHUB_TOKEN instead,
+// and the cassette is written by the returned save function.
+func cassetteClient(t *testing.T, name string) (*github.Client, func()) {
+	path := filepath.Join("testdata", "cassettes", name+".json")
+	var recorder *Recorder
+	var err error
+	if os.Getenv("RECORD_CASSETTES") == "1" {
+		recorder, err = NewRecorder(RecorderModeRecord, path, githubHTTPClient(t).Transport)
+		require.NoError(t, err)
+		recorder.Redact(os.Getenv("GITHUB_TOKEN"))
+	} else {
+		recorder, err = NewRecorder(RecorderModeReplay, path, nil)
+		require.NoError(t, err)
+	}
+	return github.NewClient(&http.Client{Transport: recorder}), func() {
+		require.NoError(t, recorder.Save())
+	}
 }
 
 func TestConfigFromOpts(t *testing.T) {
@@ -36,6 +64,10 @@ func TestConfigFromOpts(t *testing.T) {
 
 	// test the default value
 	require.Equal(t, "kubernetes", c.repo)
+	require.False(t, c.failFast)
+
+	c = configFromOpts(WithFailFast(true))
+	require.True(t, c.failFast)
 }
 
 func TestStripActionRequired(t *testing.T) {
@@ -77,6 +109,38 @@ func TestReleaseNoteParsing(t *testing.T) {
 	}
 }
 
+func TestReleaseNoteParsingReplay(t *testing.T) {
+	client, save := cassetteClient(t, "release-note-parsing")
+	defer save()
+	opts := []GithubApiOption{WithOrg("example-org"), WithRepo("example-repo")}
+	ctx := context.Background()
+
+	notes := []*ReleaseNote{}
+	for _, sha := range []string{
+		"a000000000000000000000000000000000000001",
+		"a000000000000000000000000000000000000002",
+	} {
+		commit, _, err := client.Repositories.GetCommit(ctx, "example-org", "example-repo", sha)
+		require.NoError(t, err)
+		note, err := ReleaseNoteFromCommit(commit, client, "0.1", opts...)
+		require.NoError(t, err)
+		notes = append(notes, note)
+	}
+
+	require.Equal(t, "example-cli now supports the --example flag", notes[0].Text)
+	require.Equal(t, []string{"cli"}, notes[0].SIGs)
+	require.True(t, notes[0].Feature)
+	require.False(t, notes[0].ActionRequired)
+
+	require.Equal(t, "The example controller no longer crashes on empty specs, remove the --crash-workaround flag", notes[1].Text)
+	require.Equal(t, "https://github.com/example-org/example-repo/pull/1002", notes[1].PrUrl)
+	require.Equal(t, []string{"bug"}, notes[1].Kinds)
+	require.True(t, notes[1].ActionRequired)
+	require.Len(t, notes[1].Documentation, 1)
+	require.Equal(t, "https://example.com/docs/example-controller", notes[1].Documentation[0].URL)
+	require.Equal(t, "a000000000000000000000000000000000000002", notes[1].Commit)
+}
+
 func TestDocumentationFromString(t *testing.T) {
 	const (
 		description1         = "Some description (#123), see :::"
@@ -223,3 +287,63 @@ func TestGetPRNumberFromCommitMessage(t *testing.T) {
 	}
 
 }
+
+func TestRequestContext(t *testing.T) {
+	// no timeout and deadline
+	c := configFromOpts()
+	ctx, cancel := c.requestContext()
+	_, ok := ctx.Deadline()
+	require.False(t, ok)
+	cancel()
+	require.NoError(t, c.interrupted())
+
+	// the request timeout is shorter than the overall deadline
+	deadline := time.Now().Add(time.Hour)
+	c = configFromOpts(WithRequestTimeout(time.Minute), WithOverallDeadline(deadline))
+	ctx, cancel = c.requestContext()
+	requestDeadline, ok := ctx.Deadline()
+	require.True(t, ok)
+	require.True(t, requestDeadline.Before(deadline))
+	cancel()
+	require.Error(t, ctx.Err())
+	require.NoError(t, c.interrupted())
+
+	// the overall deadline is shorter than the request timeout
+	deadline = time.Now().Add(time.Second)
+	c = configFromOpts(WithRequestTimeout(time.Minute), WithOverallDeadline(deadline))
+	ctx, cancel = c.requestContext()
+	requestDeadline, ok = ctx.Deadline()
+	require.True(t, ok)
+	require.Equal(t, deadline, requestDeadline)
+	cancel()
+
+	// the overall deadline has passed
+	c = configFromOpts(WithOverallDeadline(time.Now().Add(-time.Second)))
+	require.Equal(t, context.DeadlineExceeded, c.interrupted())
+
+	// the context has been cancelled
+	parent, cancelParent := context.WithCancel(context.Background())
+	cancelParent()
+	c = configFromOpts(WithContext(parent))
+	require.Equal(t, context.Canceled, c.interrupted())
+}
+
+func TestPRFromCommitRequestTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer server.Close()
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+
+	commit := &github.RepositoryCommit{
+		Commit: &github.Commit{Message: github.String("Merge pull request #1 from foo/bar")},
+	}
+	_, err := PRFromCommit(client, commit, WithRequestTimeout(10*time.Millisecond))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), context.DeadlineExceeded.Error())
+}
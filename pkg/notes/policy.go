@@ -0,0 +1,197 @@
+// Copyright 2019 The Kubernetes Authors All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notes
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// OutputPolicy selects the fields of the release notes an output includes,
+// by their JSON names, e.g. to publish only the text and links of the notes
+// while an internal report also includes the authors and lint warnings.
+type OutputPolicy struct {
+	// Include lists the fields which are kept. Empty means all fields.
+	Include []string `json:"include,omitempty"`
+
+	// Redact lists the fields which are removed
+	Redact []string `json:"redact,omitempty"`
+}
+
+// OutputPolicies are the output policies per format, e.g. markdown or json.
+//
+//	{
+//	  "default": {"include": ["text", "markdown", "pr_url", "documentation"]},
+//	  "formats": {"json": {"redact": ["warnings"]}}
+//	}
+type OutputPolicies struct {
+	// Default applies to the formats without a policy of their own
+	Default *OutputPolicy `json:"default,omitempty"`
+
+	Formats map[string]*OutputPolicy `json:"formats,omitempty"`
+}
+
+// noteFields are the JSON names of the fields of a ReleaseNote. The PR number
+// identifies a note and is always kept.
+var noteFields = func() map[string]bool {
+	fields := map[string]bool{}
+	t := reflect.TypeOf(ReleaseNote{})
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if name != "" && name != "-" && name != "pr_number" {
+			fields[name] = true
+		}
+	}
+	return fields
+}()
+
+// LoadOutputPolicies reads the output policies from a JSON file.
+func LoadOutputPolicies(path string) (*OutputPolicies, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	policies := &OutputPolicies{}
+	if err := json.Unmarshal(content, policies); err != nil {
+		return nil, NewError(ErrParse, "error parsing output policies %s: %v", path, err)
+	}
+	if err := policies.Validate(); err != nil {
+		return nil, err
+	}
+	return policies, nil
+}
+
+// Validate checks that the policies only name known fields.
+func (p *OutputPolicies) Validate() error {
+	if err := p.Default.Validate(); err != nil {
+		return err
+	}
+	for format, policy := range p.Formats {
+		if err := policy.Validate(); err != nil {
+			return NewError(ErrValidation, "output policy of %s: %v", format, err)
+		}
+	}
+	return nil
+}
+
+// Validate checks that the policy only names known fields.
+func (p *OutputPolicy) Validate() error {
+	if p == nil {
+		return nil
+	}
+	for _, field := range append(append([]string{}, p.Include...), p.Redact...) {
+		if !noteFields[field] {
+			known := make([]string, 0, len(noteFields))
+			for name := range noteFields {
+				known = append(known, name)
+			}
+			sort.Strings(known)
+			return NewError(ErrValidation, "%q is not a field of the release notes (fields: %s)", field, strings.Join(known, ", "))
+		}
+	}
+	return nil
+}
+
+// For returns the policy of the format, or nil if all fields are included.
+func (p *OutputPolicies) For(format string) *OutputPolicy {
+	if policy, ok := p.Formats[format]; ok {
+		return policy
+	}
+	return p.Default
+}
+
+// Wrap enforces the policies in the renderers of all formats of the
+// registry. The document fields of a format are kept, so that e.g. the
+// markdown notes are still organized by SIG without listing the SIGs of every
+// note.
+func (p *OutputPolicies) Wrap(registry FormatRegistry) {
+	for _, format := range registry {
+		policy := p.For(format.Name)
+		if policy == nil {
+			continue
+		}
+		renderer := format.Renderer
+		keep := format.DocumentFields
+		format.Renderer = RendererFunc(func(w io.Writer, releaseNotes ReleaseNoteList) error {
+			redacted, err := policy.apply(releaseNotes, keep)
+			if err != nil {
+				return err
+			}
+			return renderer.Render(w, redacted)
+		})
+	}
+}
+
+// courtesyRe matches the SIGs credited at the end of the markdown of a note
+var courtesyRe = regexp.MustCompile(`(?m) Courtesy of SIG [^\n]*$`)
+
+// Apply returns a copy of the notes without the fields the policy excludes.
+// Without the author or the SIGs, the author links or the SIGs credited are
+// also removed from the markdown.
+func (p *OutputPolicy) Apply(releaseNotes ReleaseNoteList) (ReleaseNoteList, error) {
+	return p.apply(releaseNotes, nil)
+}
+
+// apply is Apply keeping the given fields in the notes, though they are still
+// removed from the markdown
+func (p *OutputPolicy) apply(releaseNotes ReleaseNoteList, keep []string) (ReleaseNoteList, error) {
+	excluded := map[string]bool{}
+	if len(p.Include) > 0 {
+		for field := range noteFields {
+			excluded[field] = !HasString(p.Include, field)
+		}
+	}
+	for _, field := range p.Redact {
+		excluded[field] = true
+	}
+
+	redacted := make(ReleaseNoteList, len(releaseNotes))
+	for pr, note := range releaseNotes {
+		content, err := json.Marshal(note)
+		if err != nil {
+			return nil, err
+		}
+		fields := map[string]json.RawMessage{}
+		if err := json.Unmarshal(content, &fields); err != nil {
+			return nil, err
+		}
+		for field, exclude := range excluded {
+			if exclude && !HasString(keep, field) {
+				delete(fields, field)
+			}
+		}
+		if content, err = json.Marshal(fields); err != nil {
+			return nil, err
+		}
+		copied := &ReleaseNote{}
+		if err := json.Unmarshal(content, copied); err != nil {
+			return nil, err
+		}
+		if excluded["sigs"] {
+			copied.Markdown = courtesyRe.ReplaceAllString(copied.Markdown, "")
+		}
+		redacted[pr] = copied
+	}
+
+	if excluded["author"] {
+		(&AttributionPolicy{Attribution: AttributeNone}).Apply(redacted)
+	}
+	return redacted, nil
+}
@@ -0,0 +1,122 @@
+package notes
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOutputPolicy(t *testing.T) {
+	releaseNotes := ReleaseNoteList{
+		1: {
+			PrNumber:  1,
+			PrUrl:     "https://github.com/kubernetes/kubernetes/pull/1",
+			Text:      "Adds a flag",
+			Markdown:  "Adds a flag ([#1](https://github.com/kubernetes/kubernetes/pull/1), [@alice](https://github.com/alice))",
+			Author:    "alice",
+			AuthorUrl: "https://github.com/alice",
+			Warnings:  []string{"too short"},
+			SIGs:      []string{"cli"},
+		},
+	}
+
+	public := &OutputPolicy{Include: []string{"text", "markdown", "pr_url"}}
+	redacted, err := public.Apply(releaseNotes)
+	require.NoError(t, err)
+	require.Equal(t, &ReleaseNote{
+		PrNumber: 1,
+		PrUrl:    "https://github.com/kubernetes/kubernetes/pull/1",
+		Text:     "Adds a flag",
+		Markdown: "Adds a flag ([#1](https://github.com/kubernetes/kubernetes/pull/1))",
+	}, redacted[1])
+
+	// the notes themselves are not modified
+	require.Equal(t, "alice", releaseNotes[1].Author)
+
+	internal := &OutputPolicy{Redact: []string{"warnings"}}
+	redacted, err = internal.Apply(releaseNotes)
+	require.NoError(t, err)
+	require.Empty(t, redacted[1].Warnings)
+	require.Equal(t, "alice", redacted[1].Author)
+	require.Equal(t, releaseNotes[1].Markdown, redacted[1].Markdown)
+}
+
+func TestOutputPolicies(t *testing.T) {
+	dir, err := ioutil.TempDir("", "policies-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "policies.json")
+	require.NoError(t, ioutil.WriteFile(path, []byte(`{
+		"default": {"include": ["text", "markdown", "pr_url"]},
+		"formats": {"json": {"redact": ["warnings"]}}
+	}`), 0644))
+	policies, err := LoadOutputPolicies(path)
+	require.NoError(t, err)
+
+	registry := NewFormatRegistry()
+	policies.Wrap(registry)
+	releaseNotes := ReleaseNoteList{
+		1: {PrNumber: 1, Text: "Adds a flag", Author: "alice", Warnings: []string{"too short"}},
+	}
+
+	format, err := registry.Lookup("json")
+	require.NoError(t, err)
+	var b bytes.Buffer
+	require.NoError(t, format.Renderer.Render(&b, releaseNotes))
+	decoded := ReleaseNoteList{}
+	require.NoError(t, json.Unmarshal(b.Bytes(), &decoded))
+	require.Equal(t, "alice", decoded[1].Author)
+	require.Empty(t, decoded[1].Warnings)
+
+	format, err = registry.Lookup("csv")
+	require.NoError(t, err)
+	b.Reset()
+	require.NoError(t, format.Renderer.Render(&b, releaseNotes))
+	require.NotContains(t, b.String(), "alice")
+
+	require.NoError(t, ioutil.WriteFile(path, []byte(`{"default": {"redact": ["approver"]}}`), 0644))
+	_, err = LoadOutputPolicies(path)
+	require.Equal(t, ErrValidation, Kind(err))
+}
+
+func TestOutputPoliciesMarkdown(t *testing.T) {
+	policies := &OutputPolicies{Default: &OutputPolicy{Include: []string{"text", "markdown", "pr_url", "documentation"}}}
+	registry := NewFormatRegistry()
+	policies.Wrap(registry)
+	releaseNotes := ReleaseNoteList{
+		1: {
+			PrNumber:       1,
+			Text:           "Removes a flag",
+			Markdown:       "Removes a flag ([#1](https://github.com/kubernetes/kubernetes/pull/1), [@alice](https://github.com/alice)) Courtesy of SIG CLI",
+			Author:         "alice",
+			SIGs:           []string{"cli"},
+			ActionRequired: true,
+		},
+		2: {
+			PrNumber: 2,
+			Text:     "Fixes the kubelet",
+			Markdown: "Fixes the kubelet ([#2](https://github.com/kubernetes/kubernetes/pull/2), [@bob](https://github.com/bob))",
+			Author:   "bob",
+			SIGs:     []string{"node"},
+		},
+	}
+
+	format, err := registry.Lookup("markdown")
+	require.NoError(t, err)
+	var b bytes.Buffer
+	require.NoError(t, format.Renderer.Render(&b, releaseNotes))
+	markdown := b.String()
+
+	// the notes are still organized, without the redacted fields
+	require.Contains(t, markdown, "## Action Required\n\n- Removes a flag ([#1](https://github.com/kubernetes/kubernetes/pull/1))\n")
+	require.Contains(t, markdown, "### SIG Node\n\n- Fixes the kubelet ([#2](https://github.com/kubernetes/kubernetes/pull/2))\n")
+	require.NotContains(t, markdown, "Courtesy")
+	require.NotContains(t, markdown, "alice")
+	require.NotContains(t, markdown, "Other Notable Changes")
+}
@@ -0,0 +1,138 @@
+// Copyright 2019 The Kubernetes Authors All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notes
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// PostProcessor rewrites the text of a release note.
+type PostProcessor func(text string) string
+
+// PostProcessing is the pipeline applied to the text of every release note,
+// keeping the cruft of PR templates out of the final document. Callers may
+// append their own processors.
+type PostProcessing struct {
+	// Text are applied to both the text and the markdown of the notes
+	Text []PostProcessor
+
+	// Markdown are only applied to the markdown of the notes, e.g. to add
+	// links
+	Markdown []PostProcessor
+}
+
+// NewPostProcessing creates the default pipeline, which strips HTML comments,
+// normalizes smart quotes and links the bare commits and issue references of
+// the markdown to the given repository.
+func NewPostProcessing(org, repo string) *PostProcessing {
+	return &PostProcessing{
+		Text:     []PostProcessor{StripHTMLComments, NormalizeQuotes},
+		Markdown: []PostProcessor{LinkReferences(org, repo)},
+	}
+}
+
+// Apply runs the pipeline on the notes. Only the note itself is processed in
+// the markdown, not the links to the PR and author appended to it.
+func (p *PostProcessing) Apply(releaseNotes ReleaseNoteList) {
+	for _, note := range releaseNotes {
+		suffix := ""
+		markdown := note.Markdown
+		if strings.HasPrefix(note.Markdown, note.Text) {
+			markdown, suffix = note.Text, note.Markdown[len(note.Text):]
+		}
+
+		for _, process := range p.Text {
+			note.Text = process(note.Text)
+			markdown = process(markdown)
+		}
+		for _, process := range p.Markdown {
+			markdown = process(markdown)
+		}
+		note.Markdown = markdown + suffix
+	}
+}
+
+var htmlCommentRe = regexp.MustCompile(`(?s)<!--.*?-->`)
+
+// StripHTMLComments removes HTML comments, e.g. the instructions of the PR
+// template left in the release note block.
+func StripHTMLComments(text string) string {
+	if !htmlCommentRe.MatchString(text) {
+		return text
+	}
+	return strings.TrimSpace(htmlCommentRe.ReplaceAllString(text, ""))
+}
+
+var quoteReplacer = strings.NewReplacer(
+	"“", `"`, "”", `"`, "„", `"`,
+	"‘", "'", "’", "'", "‚", "'",
+)
+
+// NormalizeQuotes replaces typographic quotes, which editors insert, with
+// plain ones.
+func NormalizeQuotes(text string) string {
+	return quoteReplacer.Replace(text)
+}
+
+// referenceRe matches inline code, existing markdown links and URLs, which
+// are kept, as well as references to issues of other repositories, references
+// to issues of the repository (their # is escaped by NoteTextFromString) and
+// bare commit SHAs
+var referenceRe = regexp.MustCompile(
+	codeSpanRe.String() + `|\[[^\]]*\]\([^)]*\)|https?://\S+` +
+		`|\b([\w.-]+)/([\w.-]+)(?:#|&#35;)(\d+)\b` +
+		`|(?:^|[^\w&])(?:#|&#35;)(\d+)\b` +
+		`|\b[0-9a-f]{7,40}\b`,
+)
+
+var (
+	hasDigitRe  = regexp.MustCompile(`[0-9]`)
+	hasLetterRe = regexp.MustCompile(`[a-f]`)
+)
+
+// LinkReferences returns a PostProcessor which turns bare commit SHAs and
+// issue references like #123 or kubernetes/enhancements#42 into links.
+// Issue references without a repository refer to the given one. Inline code
+// is left alone.
+func LinkReferences(org, repo string) PostProcessor {
+	return func(text string) string {
+		return referenceRe.ReplaceAllStringFunc(text, func(match string) string {
+			groups := referenceRe.FindStringSubmatch(match)
+			switch {
+			case groups[3] != "":
+				return fmt.Sprintf("[%s/%s#%s](https://github.com/%s/%s/issues/%s)", groups[1], groups[2], groups[3], groups[1], groups[2], groups[3])
+			case groups[4] != "":
+				// keep the character preceding the reference
+				prefix := strings.TrimSuffix(match, groups[4])
+				if strings.HasSuffix(prefix, "&#35;") {
+					prefix = strings.TrimSuffix(prefix, "&#35;")
+				} else {
+					prefix = strings.TrimSuffix(prefix, "#")
+				}
+				return fmt.Sprintf("%s[#%s](https://github.com/%s/%s/issues/%s)", prefix, groups[4], org, repo, groups[4])
+			case strings.HasPrefix(match, "`") || strings.HasPrefix(match, "[") || strings.HasPrefix(match, "http"):
+				return match
+			case hasDigitRe.MatchString(match) && hasLetterRe.MatchString(match):
+				// words like "decade" and numbers are valid hex, SHAs
+				// practically always contain both digits and letters
+				return fmt.Sprintf("[%s](https://github.com/%s/%s/commit/%s)", match[:7], org, repo, match)
+			default:
+				return match
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+// Copyright 2019 The Kubernetes Authors All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notes
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStripHTMLComments(t *testing.T) {
+	require.Equal(t, "Fixed a bug.", StripHTMLComments("<!--\nWrite your note here\n-->\nFixed a bug."))
+	require.Equal(t, "Fixed a bug. ", StripHTMLComments("Fixed a bug. "))
+}
+
+func TestNormalizeQuotes(t *testing.T) {
+	require.Equal(t, `The "kubectl" command doesn't panic`, NormalizeQuotes("The “kubectl” command doesn’t panic"))
+}
+
+func TestLinkReferences(t *testing.T) {
+	link := LinkReferences("kubernetes", "kubernetes")
+	for text, expected := range map[string]string{
+		"Reverts &#35;123.": "Reverts [#123](https://github.com/kubernetes/kubernetes/issues/123).",
+		"See #42":           "See [#42](https://github.com/kubernetes/kubernetes/issues/42)",
+		"Implements kubernetes/enhancements&#35;1024":      "Implements [kubernetes/enhancements#1024](https://github.com/kubernetes/enhancements/issues/1024)",
+		"Reverts 4a5b6c7d8e9f0a1b2c3d4e5f6a7b8c9d0e1f2a3b": "Reverts [4a5b6c7](https://github.com/kubernetes/kubernetes/commit/4a5b6c7d8e9f0a1b2c3d4e5f6a7b8c9d0e1f2a3b)",
+		// links, words, numbers and anchors are left alone
+		"See [#42](https://example.com/#42) and https://example.com/4a5b6c7d": "See [#42](https://example.com/#42) and https://example.com/4a5b6c7d",
+		"A decade of 10000000 bytes":                                          "A decade of 10000000 bytes",
+		"Issue&#35;5":                                                         "Issue&#35;5",
+		// and so is inline code
+		"Runs `git checkout 4a5b6c7d8e9f #42` for #42": "Runs `git checkout 4a5b6c7d8e9f #42` for [#42](https://github.com/kubernetes/kubernetes/issues/42)",
+	} {
+		require.Equal(t, expected, link(text), text)
+	}
+}
+
+func TestPostProcessing(t *testing.T) {
+	releaseNotes := ReleaseNoteList{
+		1: {
+			Text:     "<!-- note -->Reverts &#35;12, “finally”",
+			Markdown: "<!-- note -->Reverts &#35;12, “finally” ([#1](https://github.com/o/r/pull/1), [@alice](https://github.com/alice))",
+		},
+	}
+
+	processing := NewPostProcessing("o", "r")
+	processing.Text = append(processing.Text, strings.ToUpper)
+	processing.Apply(releaseNotes)
+
+	require.Equal(t, `REVERTS &#35;12, "FINALLY"`, releaseNotes[1].Text)
+	require.Equal(t, `REVERTS [#12](https://github.com/o/r/issues/12), "FINALLY" ([#1](https://github.com/o/r/pull/1), [@alice](https://github.com/alice))`, releaseNotes[1].Markdown)
+}
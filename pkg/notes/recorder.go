@@ -0,0 +1,283 @@
+// Copyright 2019 The Kubernetes Authors All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notes
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// RecorderMode selects whether a Recorder records or replays interactions.
+type RecorderMode string
+
+const (
+	// RecorderModeRecord performs real requests and records them
+	RecorderModeRecord RecorderMode = "record"
+
+	// RecorderModeReplay answers requests from a previously recorded cassette
+	// without touching the network
+	RecorderModeReplay RecorderMode = "replay"
+)
+
+// Cassette is a sanitized recording of GitHub API interactions.
+type Cassette struct {
+	Interactions []*Interaction `json:"interactions"`
+}
+
+// Interaction is a single recorded request and its response.
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// RecordedRequest is the part of a request used to match it on replay. No
+// headers are recorded, so credentials never end up in a cassette.
+type RecordedRequest struct {
+	Method string `json:"method"`
+	URL    string `json:"url"`
+}
+
+// RecordedResponse is a recorded response.
+type RecordedResponse struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body"`
+}
+
+// recordedHeaders are the response headers kept in cassettes, everything else
+// is dropped
+var recordedHeaders = []string{"Content-Type", "Link"}
+
+// sensitiveQueryParams are removed from the URLs of recorded requests
+var sensitiveQueryParams = []string{"access_token", "client_id", "client_secret"}
+
+// tokenRe matches GitHub tokens and token query parameters, which are scrubbed
+// from the recorded bodies and headers, e.g. from the URLs of paginated
+// responses
+var tokenRe = regexp.MustCompile(`\b(gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})\b|((access_token|client_id|client_secret)=)[^&"\s>]+`)
+
+// slackWebhookHost is the host of Slack incoming webhooks, whose paths are
+// removed from the URLs of recorded requests
+const slackWebhookHost = "hooks.slack.com"
+
+// Recorder is an http.RoundTripper which records GitHub API interactions to a
+// cassette file, or replays them from it. This allows for regression tests of
+// the parsing pipeline against real API responses without a GitHub token.
+//
+// To use it, wrap the transport of the GitHub client:
+//
+//	recorder, err := NewRecorder(RecorderModeReplay, "testdata/cassette.json", nil)
+//	client := github.NewClient(&http.Client{Transport: recorder})
+type Recorder struct {
+	mode      RecorderMode
+	path      string
+	transport http.RoundTripper
+
+	mu       sync.Mutex
+	cassette *Cassette
+	replayed map[*Interaction]bool
+	secrets  []string
+}
+
+// NewRecorder creates a Recorder for the cassette at the given path. In replay
+// mode the cassette is loaded immediately. In record mode requests are
+// performed using the given transport, which defaults to
+// http.DefaultTransport, and the cassette is written by Save.
+func NewRecorder(mode RecorderMode, path string, transport http.RoundTripper) (*Recorder, error) {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	r := &Recorder{
+		mode:      mode,
+		path:      path,
+		transport: transport,
+		cassette:  &Cassette{},
+		replayed:  map[*Interaction]bool{},
+	}
+
+	switch mode {
+	case RecorderModeRecord:
+	case RecorderModeReplay:
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(content, r.cassette); err != nil {
+			return nil, NewError(ErrParse, "error parsing cassette %s: %v", path, err)
+		}
+	default:
+		return nil, NewError(ErrValidation, "%q is an unsupported recorder mode", mode)
+	}
+
+	return r, nil
+}
+
+// Redact adds secrets, e.g. the token of the recorded client, which are
+// replaced in the recorded responses in addition to anything looking like a
+// GitHub token.
+func (r *Recorder) Redact(secrets ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, secret := range secrets {
+		if secret != "" {
+			r.secrets = append(r.secrets, secret)
+		}
+	}
+}
+
+// scrub returns the recorded text without secrets. It is called with r.mu
+// held.
+func (r *Recorder) scrub(text string) string {
+	for _, secret := range r.secrets {
+		text = strings.Replace(text, secret, "redacted", -1)
+	}
+	return tokenRe.ReplaceAllStringFunc(text, func(match string) string {
+		if i := strings.Index(match, "="); i >= 0 {
+			return match[:i+1] + "redacted"
+		}
+		return "redacted"
+	})
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	recorded := RecordedRequest{Method: req.Method, URL: sanitizeURL(req.URL)}
+
+	if r.mode == RecorderModeReplay {
+		interaction, err := r.find(recorded)
+		if err != nil {
+			return nil, err
+		}
+		return interaction.Response.toResponse(req), nil
+	}
+
+	resp, err := r.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	header := http.Header{}
+	for _, key := range recordedHeaders {
+		for _, value := range resp.Header[key] {
+			header.Add(key, r.scrub(value))
+		}
+	}
+	r.cassette.Interactions = append(r.cassette.Interactions, &Interaction{
+		Request: recorded,
+		Response: RecordedResponse{
+			StatusCode: resp.StatusCode,
+			Header:     header,
+			Body:       r.scrub(string(body)),
+		},
+	})
+
+	return resp, nil
+}
+
+// find returns the first interaction matching the request which has not been
+// replayed yet. If all matching interactions have been replayed, the last one
+// is returned again.
+func (r *Recorder) find(req RecordedRequest) (*Interaction, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var match *Interaction
+	for _, interaction := range r.cassette.Interactions {
+		if interaction.Request != req {
+			continue
+		}
+		match = interaction
+		if !r.replayed[interaction] {
+			r.replayed[interaction] = true
+			return interaction, nil
+		}
+	}
+	if match == nil {
+		return nil, NewError(ErrNotFound, "no recorded interaction for %s %s in %s", req.Method, req.URL, r.path)
+	}
+	return match, nil
+}
+
+// Save writes the recorded interactions to the cassette file. It is a no-op
+// in replay mode.
+func (r *Recorder) Save() error {
+	if r.mode != RecorderModeRecord {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	content, err := json.MarshalIndent(r.cassette, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(r.path, append(content, '\n'), 0644)
+}
+
+func (r *RecordedResponse) toResponse(req *http.Request) *http.Response {
+	header := http.Header{}
+	for key, value := range r.Header {
+		header[key] = value
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", r.StatusCode, http.StatusText(r.StatusCode)),
+		StatusCode:    r.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(bytes.NewBufferString(r.Body)),
+		ContentLength: int64(len(r.Body)),
+		Request:       req,
+	}
+}
+
+// sanitizeURL returns the URL without credentials
+func sanitizeURL(u *url.URL) string {
+	sanitized := *u
+	sanitized.User = nil
+	if sanitized.Host == slackWebhookHost {
+		// the path of a Slack incoming webhook is its secret
+		sanitized.Path = "/services/redacted"
+		sanitized.RawPath = ""
+	}
+	query := sanitized.Query()
+	for _, param := range sensitiveQueryParams {
+		query.Del(param)
+	}
+	sanitized.RawQuery = query.Encode()
+	return sanitized.String()
+}
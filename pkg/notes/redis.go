@@ -0,0 +1,230 @@
+// Copyright 2019 The Kubernetes Authors All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notes
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// RedisStore keeps every value in a key of a Redis server, so that CI
+// runners can share it. It speaks the Redis protocol over a single
+// connection, which is reopened after errors.
+type RedisStore struct {
+	Addr     string
+	Password string
+	DB       int
+
+	// Prefix is prepended to the keys
+	Prefix string
+
+	// Timeout limits connecting and every command, unless the context of the
+	// command has an earlier deadline
+	Timeout time.Duration
+
+	mu   sync.Mutex
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+// NewRedisStore creates a RedisStore from a location in the form
+// redis://[:password@]host[:port][/db][?prefix=release-notes/]. The
+// connection is opened by the first command.
+func NewRedisStore(location string) (*RedisStore, error) {
+	u, err := url.Parse(location)
+	if err != nil || u.Scheme != "redis" || u.Hostname() == "" {
+		return nil, NewError(ErrValidation, "invalid Redis location %q, expected redis://[:password@]host[:port][/db]", location)
+	}
+
+	s := &RedisStore{
+		Addr:    u.Host,
+		Prefix:  u.Query().Get("prefix"),
+		Timeout: 10 * time.Second,
+	}
+	if u.Port() == "" {
+		s.Addr = net.JoinHostPort(u.Hostname(), "6379")
+	}
+	if password, ok := u.User.Password(); ok {
+		s.Password = password
+	}
+	if db := strings.Trim(u.Path, "/"); db != "" {
+		if s.DB, err = strconv.Atoi(db); err != nil {
+			return nil, NewError(ErrValidation, "invalid Redis database %q in %q", db, location)
+		}
+	}
+	return s, nil
+}
+
+// Get implements Store.
+func (s *RedisStore) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	reply, err := s.do(ctx, "GET", s.Prefix+key)
+	if err != nil || reply == nil {
+		return nil, false, err
+	}
+	value, ok := reply.([]byte)
+	if !ok {
+		return nil, false, errors.Errorf("unexpected Redis reply to GET: %v", reply)
+	}
+	return value, true, nil
+}
+
+// Put implements Store.
+func (s *RedisStore) Put(ctx context.Context, key string, value []byte) error {
+	_, err := s.do(ctx, "SET", s.Prefix+key, string(value))
+	return err
+}
+
+// Close closes the connection to the server.
+func (s *RedisStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.disconnect()
+}
+
+func (s *RedisStore) disconnect() error {
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
+
+// do sends a command and returns its reply, connecting first if needed
+func (s *RedisStore) do(ctx context.Context, args ...string) (interface{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	deadline := time.Now().Add(s.Timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	if s.conn == nil {
+		if err := s.connect(ctx, deadline); err != nil {
+			return nil, err
+		}
+	}
+
+	reply, err := s.command(deadline, args...)
+	if _, ok := err.(redisError); err != nil && !ok {
+		// the state of the connection is unknown after a network error
+		s.disconnect()
+	}
+	return reply, err
+}
+
+// connect opens the connection, authenticates and selects the database
+func (s *RedisStore) connect(ctx context.Context, deadline time.Time) error {
+	dialer := &net.Dialer{Deadline: deadline}
+	conn, err := dialer.DialContext(ctx, "tcp", s.Addr)
+	if err != nil {
+		return errors.Wrapf(err, "error connecting to Redis at %s", s.Addr)
+	}
+	s.conn = conn
+	s.r = bufio.NewReader(conn)
+
+	if s.Password != "" {
+		if _, err := s.command(deadline, "AUTH", s.Password); err != nil {
+			s.disconnect()
+			return errors.Wrap(err, "error authenticating to Redis")
+		}
+	}
+	if s.DB != 0 {
+		if _, err := s.command(deadline, "SELECT", strconv.Itoa(s.DB)); err != nil {
+			s.disconnect()
+			return errors.Wrapf(err, "error selecting Redis database %d", s.DB)
+		}
+	}
+	return nil
+}
+
+// command writes a command as array of bulk strings and reads the reply
+func (s *RedisStore) command(deadline time.Time, args ...string) (interface{}, error) {
+	if err := s.conn.SetDeadline(deadline); err != nil {
+		return nil, err
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	if _, err := io.WriteString(s.conn, b.String()); err != nil {
+		return nil, err
+	}
+	return readRedisReply(s.r)
+}
+
+// redisError is an error reply of the server
+type redisError string
+
+func (e redisError) Error() string {
+	return "Redis error: " + string(e)
+}
+
+// readRedisReply reads a reply: a string for simple strings, an int64 for
+// integers, []byte or nil for bulk strings and []interface{} for arrays
+func readRedisReply(r *bufio.Reader) (interface{}, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return nil, errors.New("empty Redis reply")
+	}
+
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, redisError(line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		value := make([]byte, n+2)
+		if _, err := io.ReadFull(r, value); err != nil {
+			return nil, err
+		}
+		return value[:n], nil
+	case '*':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		values := make([]interface{}, n)
+		for i := range values {
+			if values[i], err = readRedisReply(r); err != nil {
+				return nil, err
+			}
+		}
+		return values, nil
+	}
+	return nil, errors.Errorf("invalid Redis reply %q", line)
+}
@@ -0,0 +1,191 @@
+// Copyright 2019 The Kubernetes Authors All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notes
+
+import (
+	"strings"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/google/go-github/github"
+	"github.com/pkg/errors"
+)
+
+// maxSearchResults is the number of results the GitHub Search API returns at
+// most for a single query
+const maxSearchResults = 1000
+
+// SearchPullRequests returns all pull requests matching a raw GitHub search
+// query, e.g. "repo:kubernetes/kubernetes is:pr is:merged label:sig/cli". The
+// search results are paginated and every result which is a pull request is
+// fetched in full, since search results lack e.g. the merge commit. Issues
+// matching the query are skipped. The query is restricted to the time window
+// of the options, if any. PRs which cannot be fetched are returned as
+// CommitErrors alongside the others, unless WithFailFast is set.
+func SearchPullRequests(client *github.Client, logger log.Logger, query string, opts ...GithubApiOption) ([]*github.PullRequest, error) {
+	c := configFromOpts(opts...)
+	if qualifier := c.window.SearchQualifier(); qualifier != "" {
+		query = query + " " + qualifier
+	}
+
+	searchOpts := &github.SearchOptions{
+		Sort:        "created",
+		Order:       "asc",
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+	issues := []github.Issue{}
+	for {
+		ctx, cancel := c.requestContext()
+		result, resp, err := client.Search.Issues(ctx, query, searchOpts)
+		cancel()
+		if err != nil {
+			return nil, classify(errors.Wrapf(err, "error searching for %q", query))
+		}
+		issues = append(issues, result.Issues...)
+
+		if result.GetTotal() > maxSearchResults && searchOpts.Page == 0 {
+			level.Warn(logger).Log(
+				"msg", "the search matches more results than the GitHub Search API returns, narrow down the query",
+				"total", result.GetTotal(),
+				"max", maxSearchResults,
+			)
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		searchOpts.Page = resp.NextPage
+	}
+
+	refs := []PullRequestRef{}
+	for _, issue := range issues {
+		if !issue.IsPullRequest() {
+			continue
+		}
+		org, repo, err := repoFromURL(issue.GetRepositoryURL())
+		if err != nil {
+			return nil, err
+		}
+		refs = append(refs, PullRequestRef{Org: org, Repo: repo, Number: issue.GetNumber()})
+	}
+	skipped := c.planBudget(logger, client, refs)
+
+	prs := []*github.PullRequest{}
+	commitErrs := CommitErrors{}
+	for i, ref := range refs {
+		if err := c.interrupted(); err != nil {
+			return prs, &InterruptedError{Processed: i, Total: len(refs), Err: err, Failed: commitErrs}
+		}
+		if skipped[ref] {
+			continue
+		}
+
+		ctx, cancel := c.requestContext()
+		pr, _, err := client.PullRequests.Get(ctx, ref.Org, ref.Repo, ref.Number)
+		cancel()
+		if err != nil {
+			// the merge commit of the PR is unknown
+			commitErr := &CommitError{SHA: ref.String(), Err: classify(errors.Wrapf(err, "error getting PR %s", ref))}
+			if c.failFast {
+				return nil, commitErr
+			}
+			level.Error(logger).Log("msg", "error getting PR while searching", "pr", ref, "err", err)
+			commitErrs = append(commitErrs, commitErr)
+			continue
+		}
+		// the search range includes its end
+		if !c.window.Contains(pr.GetMergedAt()) {
+			continue
+		}
+		prs = append(prs, pr)
+	}
+
+	if len(skipped) > 0 {
+		return prs, &InterruptedError{Processed: len(refs) - len(skipped), Total: len(refs), Err: ErrQuotaExhausted, Failed: commitErrs}
+	}
+	if len(commitErrs) > 0 {
+		return prs, commitErrs
+	}
+	return prs, nil
+}
+
+// ListReleaseNotesFromSearch produces a list of fully contextualized release
+// notes for the pull requests matching a raw GitHub search query. This gives
+// full control over which PRs are considered, instead of the commit range of
+// ListReleaseNotes. PRs without a release note are skipped. If some of the
+// PRs cannot be processed, the notes of all other PRs are returned together
+// with a CommitErrors error.
+func ListReleaseNotesFromSearch(
+	client *github.Client,
+	logger log.Logger,
+	query,
+	relVer string,
+	opts ...GithubApiOption,
+) (ReleaseNoteList, error) {
+	c := configFromOpts(opts...)
+	prs, err := SearchPullRequests(client, logger, query, opts...)
+	interrupted, skipped := err.(*InterruptedError)
+	commitErrs, partial := err.(CommitErrors)
+	if err != nil && !skipped && !partial {
+		return nil, err
+	}
+	if skipped {
+		commitErrs = interrupted.Failed
+	}
+
+	notes := make(ReleaseNoteList)
+	for _, pr := range prs {
+		org, repo, err := repoFromURL(pr.GetBase().GetRepo().GetURL())
+		var note *ReleaseNote
+		if err == nil {
+			note, err = ReleaseNoteFromPR(pr, relVer, append(opts, WithOrg(org), WithRepo(repo))...)
+			if Kind(err) == ErrParse {
+				level.Debug(logger).Log("msg", "skipping PR without release note", "pr", pr.GetHTMLURL())
+				continue
+			}
+		}
+		if err != nil {
+			commitErr := &CommitError{SHA: pr.GetMergeCommitSHA(), Err: classify(err)}
+			if c.failFast {
+				return nil, commitErr
+			}
+			commitErrs = append(commitErrs, commitErr)
+			continue
+		}
+
+		if strings.TrimSpace(note.Text) == "NONE" {
+			continue
+		}
+		notes[note.PrNumber] = note
+	}
+
+	if skipped {
+		interrupted.Failed = commitErrs
+		return notes, interrupted
+	}
+	if len(commitErrs) > 0 {
+		return notes, commitErrs
+	}
+	return notes, nil
+}
+
+// repoFromURL returns the organization and repository of a GitHub API
+// repository URL, e.g. https://api.github.com/repos/kubernetes/kubernetes
+func repoFromURL(url string) (org, repo string, err error) {
+	parts := strings.Split(strings.TrimSuffix(url, "/"), "/")
+	if len(parts) < 3 || parts[len(parts)-3] != "repos" {
+		return "", "", NewError(ErrParse, "%q is not a GitHub API repository URL", url)
+	}
+	return parts[len(parts)-2], parts[len(parts)-1], nil
+}
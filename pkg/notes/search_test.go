@@ -0,0 +1,116 @@
+package notes
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"github.com/google/go-github/github"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListReleaseNotesFromSearch(t *testing.T) {
+	var server *httptest.Server
+	mux := http.NewServeMux()
+	mux.HandleFunc("/search/issues", func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "repo:o/r is:pr label:sig/cli", r.URL.Query().Get("q"))
+		if r.URL.Query().Get("page") == "" {
+			w.Header().Set("Link", fmt.Sprintf(`<%s/search/issues?page=2>; rel="next"`, server.URL))
+			fmt.Fprintf(w, `{"total_count": 3, "items": [
+				{"number": 1, "repository_url": "%[1]s/repos/o/r", "pull_request": {}},
+				{"number": 2, "repository_url": "%[1]s/repos/o/r"}
+			]}`, server.URL)
+			return
+		}
+		fmt.Fprintf(w, `{"total_count": 3, "items": [
+			{"number": 3, "repository_url": "%s/repos/o/r", "pull_request": {}}
+		]}`, server.URL)
+	})
+	pr := func(number int, body string) string {
+		return fmt.Sprintf(`{
+			"number": %[1]d,
+			"body": %[2]q,
+			"merge_commit_sha": "sha%[1]d",
+			"user": {"login": "alice"},
+			"labels": [{"name": "sig/cli"}],
+			"base": {"repo": {"url": "%[3]s/repos/o/r"}}
+		}`, number, body, server.URL)
+	}
+	mux.HandleFunc("/repos/o/r/pulls/1", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, pr(1, "```release-note\nAdds a flag to kubectl.\n```"))
+	})
+	mux.HandleFunc("/repos/o/r/pulls/3", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, pr(3, "No note in here"))
+	})
+	server = httptest.NewServer(mux)
+	defer server.Close()
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+
+	notes, err := ListReleaseNotesFromSearch(client, log.NewNopLogger(), "repo:o/r is:pr label:sig/cli", "v1.15.0")
+	require.NoError(t, err)
+	require.Len(t, notes, 1)
+	require.Equal(t, "Adds a flag to kubectl.", notes[1].Text)
+	require.Equal(t, "sha1", notes[1].Commit)
+	require.Equal(t, "https://github.com/o/r/pull/1", notes[1].PrUrl)
+	require.Equal(t, []string{"cli"}, notes[1].SIGs)
+	require.Equal(t, "v1.15.0", notes[1].ReleaseVersion)
+}
+
+func TestListReleaseNotesFromSearchPartial(t *testing.T) {
+	var server *httptest.Server
+	mux := http.NewServeMux()
+	mux.HandleFunc("/search/issues", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"total_count": 2, "items": [
+			{"number": 1, "repository_url": "%[1]s/repos/o/r", "pull_request": {}},
+			{"number": 2, "repository_url": "%[1]s/repos/o/r", "pull_request": {}}
+		]}`, server.URL)
+	})
+	mux.HandleFunc("/repos/o/r/pulls/1", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"message": "Server Error"}`, http.StatusInternalServerError)
+	})
+	mux.HandleFunc("/repos/o/r/pulls/2", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{
+			"number": 2,
+			"body": "`+"```release-note\\nAdds a flag to kubectl.\\n```"+`",
+			"merge_commit_sha": "sha2",
+			"user": {"login": "alice"},
+			"base": {"repo": {"url": "%s/repos/o/r"}}
+		}`, server.URL)
+	})
+	server = httptest.NewServer(mux)
+	defer server.Close()
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+
+	// the notes of the other PRs are returned with the failed PRs
+	notes, err := ListReleaseNotesFromSearch(client, log.NewNopLogger(), "repo:o/r is:pr", "v1.15.0")
+	require.Error(t, err)
+	commitErrs, ok := err.(CommitErrors)
+	require.True(t, ok)
+	require.Len(t, commitErrs, 1)
+	require.Equal(t, "o/r#1", commitErrs[0].SHA)
+	require.Len(t, notes, 1)
+	require.Equal(t, "Adds a flag to kubectl.", notes[2].Text)
+
+	notes, err = ListReleaseNotesFromSearch(client, log.NewNopLogger(), "repo:o/r is:pr", "v1.15.0", WithFailFast(true))
+	require.Error(t, err)
+	_, ok = err.(*CommitError)
+	require.True(t, ok)
+	require.Nil(t, notes)
+}
+
+func TestRepoFromURL(t *testing.T) {
+	org, repo, err := repoFromURL("https://api.github.com/repos/kubernetes/enhancements")
+	require.NoError(t, err)
+	require.Equal(t, "kubernetes", org)
+	require.Equal(t, "enhancements", repo)
+
+	_, _, err = repoFromURL("https://github.com/kubernetes")
+	require.Error(t, err)
+}
@@ -0,0 +1,333 @@
+// Copyright 2019 The Kubernetes Authors All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notes
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// MaxReleaseBodyLength is the maximum length of the body of a GitHub release.
+const MaxReleaseBodyLength = 125000
+
+// Part is a file of release notes which have been split.
+type Part struct {
+	Name    string
+	Content string
+}
+
+// Splitter splits rendered markdown release notes which are too long for the
+// body of a GitHub release into a main part and supplementary parts, which
+// the main part links to. Links to headings are rewritten so that they keep
+// pointing to the same heading across the parts.
+type Splitter struct {
+	// MaxLength is the maximum length of every part
+	MaxLength int
+
+	// Name is the name of the main part, the supplementary parts are named
+	// after it, e.g. notes.md, notes-2.md, notes-3.md
+	Name string
+
+	// BaseURL is prepended to the names of the parts when linking to them,
+	// e.g. the URL of the directory they are committed to. Parts are linked
+	// relatively if it is empty.
+	BaseURL string
+}
+
+// NewSplitter creates a Splitter for GitHub release bodies.
+func NewSplitter(name string) *Splitter {
+	return &Splitter{MaxLength: MaxReleaseBodyLength, Name: name}
+}
+
+// PartName returns the name of the i-th part, starting at 1, of the notes
+// with the given name.
+func PartName(name string, i int) string {
+	if i <= 1 {
+		return name
+	}
+	ext := path.Ext(name)
+	return fmt.Sprintf("%s-%d%s", strings.TrimSuffix(name, ext), i, ext)
+}
+
+// section is a level two section of the notes, split into blocks which are
+// never split across parts unless they are too long on their own
+type section struct {
+	title  string
+	blocks []string
+}
+
+func (s *section) String() string {
+	return strings.Join(s.blocks, "")
+}
+
+// Split splits the markdown. The first part is the main part, which keeps the
+// content before the first level two heading and as many of the following
+// sections as fit, and lists the sections moved to the supplementary parts.
+// If the content before the first level two heading does not fit on its own,
+// it is continued in the supplementary parts as well. Notes which fit are
+// returned as a single part.
+func (s *Splitter) Split(markdown string) []*Part {
+	if len(markdown) <= s.MaxLength {
+		return []*Part{{Name: s.Name, Content: markdown}}
+	}
+
+	preamble, sections := parseSections(markdown)
+
+	// a preamble which does not fit next to the list of all sections is
+	// continued in the supplementary parts, followed by all sections
+	main, continued := s.splitPreamble(preamble, sections)
+	moved := sections
+	if continued != nil {
+		moved = append([]*section{continued}, sections...)
+	}
+
+	// keep as many sections in the main part as fit next to the list of the
+	// sections which do not
+	kept := 0
+	size := s.size(main)
+	for continued == nil && kept < len(sections) {
+		next := size + s.size(sections[kept].String())
+		if next+len(s.footer(sections[kept+1:], nil)) > s.MaxLength {
+			break
+		}
+		size = next
+		kept++
+	}
+	moved = moved[kept:]
+
+	for _, sec := range sections[:kept] {
+		main += sec.String()
+	}
+	contents := []string{main}
+
+	// pack the remaining blocks into supplementary parts
+	current := ""
+	for _, sec := range moved {
+		for _, block := range sec.blocks {
+			for _, piece := range splitBlock(block, s.MaxLength-s.size(block)+len(block)) {
+				if current != "" && s.size(current)+s.size(piece) > s.MaxLength {
+					contents = append(contents, current)
+					current = ""
+				}
+				current += piece
+			}
+		}
+	}
+	if current != "" {
+		contents = append(contents, current)
+	}
+
+	// map the anchors of the original notes to the part and the anchor the
+	// heading has there
+	parts := make([]*Part, len(contents))
+	targets := map[string]string{}
+	global := map[string]int{}
+	for i, content := range contents {
+		parts[i] = &Part{Name: PartName(s.Name, i+1)}
+		local := map[string]int{}
+		for _, heading := range headings(content) {
+			original := uniqueAnchor(heading, global)
+			targets[original] = fmt.Sprintf("%s#%s", parts[i].Name, uniqueAnchor(heading, local))
+		}
+	}
+
+	contents[0] += s.footer(moved, targets)
+	for i, content := range contents {
+		parts[i].Content = anchorLinkRe.ReplaceAllStringFunc(content, func(link string) string {
+			target, ok := targets[anchorLinkRe.FindStringSubmatch(link)[1]]
+			if !ok {
+				return link
+			}
+			if strings.HasPrefix(target, parts[i].Name+"#") {
+				return "](" + strings.TrimPrefix(target, parts[i].Name) + ")"
+			}
+			return "](" + s.BaseURL + target + ")"
+		})
+	}
+	return parts
+}
+
+// splitPreamble returns the part of the preamble which fits into the main
+// part next to the list of all sections, and the rest of it as an untitled
+// section, or nil if the whole preamble fits.
+func (s *Splitter) splitPreamble(preamble string, sections []*section) (string, *section) {
+	if s.size(preamble)+len(s.footer(sections, nil)) <= s.MaxLength {
+		return preamble, nil
+	}
+	continued := &section{}
+	max := s.MaxLength - len(s.footer(append([]*section{continued}, sections...), nil))
+	pieces := splitBlock(preamble, max-s.size(preamble)+len(preamble))
+	continued.blocks = pieces[1:]
+	return pieces[0], continued
+}
+
+// footer lists the sections moved to the supplementary parts. An untitled
+// section, the continued preamble, always starts the first supplementary
+// part. Without targets, it links to the longest possible target, which gives
+// the maximum length of the footer.
+func (s *Splitter) footer(moved []*section, targets map[string]string) string {
+	if len(moved) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("## Further Release Notes\n\nThe release notes are continued in separate files:\n\n")
+	for _, sec := range moved {
+		if sec.title == "" {
+			target := PartName(s.Name, 2)
+			if targets == nil {
+				target = longestPartName(s.Name)
+			}
+			fmt.Fprintf(&b, "- [Continued](%s%s)\n", s.BaseURL, target)
+			continue
+		}
+		target, ok := targets[anchor(sec.title)]
+		if !ok {
+			target = longestPartName(s.Name) + "#" + anchor(sec.title)
+		}
+		fmt.Fprintf(&b, "- [%s](%s%s)\n", sec.title, s.BaseURL, target)
+	}
+	return b.String()
+}
+
+// longestPartName returns a part name at least as long as the name of any
+// part
+func longestPartName(name string) string {
+	return PartName(name, 999999)
+}
+
+// size returns the length of the markdown once its links to headings have
+// been rewritten to point to another part, at most
+func (s *Splitter) size(markdown string) int {
+	links := len(anchorLinkRe.FindAllStringIndex(markdown, -1))
+	return len(markdown) + links*len(s.BaseURL+longestPartName(s.Name))
+}
+
+var anchorLinkRe = regexp.MustCompile(`\]\(#([^)\s]+)\)`)
+
+// parseSections splits markdown at its level two headings. Every level
+// three heading starts a new block.
+func parseSections(markdown string) (string, []*section) {
+	preamble := ""
+	sections := []*section{}
+	inFence := false
+	for _, line := range strings.SplitAfter(markdown, "\n") {
+		if strings.HasPrefix(line, "```") {
+			inFence = !inFence
+		}
+		switch {
+		case !inFence && strings.HasPrefix(line, "## "):
+			sections = append(sections, &section{
+				title:  strings.TrimSpace(strings.TrimPrefix(line, "## ")),
+				blocks: []string{line},
+			})
+		case len(sections) == 0:
+			preamble += line
+		case !inFence && strings.HasPrefix(line, "### "):
+			sec := sections[len(sections)-1]
+			sec.blocks = append(sec.blocks, line)
+		default:
+			sec := sections[len(sections)-1]
+			sec.blocks[len(sec.blocks)-1] += line
+		}
+	}
+	return preamble, sections
+}
+
+// splitBlock splits a block which is too long at line boundaries, and lines
+// which are too long on their own at the last rune boundary before the
+// maximum length
+func splitBlock(block string, max int) []string {
+	if max < 1 {
+		max = 1
+	}
+	if len(block) <= max {
+		return []string{block}
+	}
+	pieces := []string{}
+	current := ""
+	for _, line := range strings.SplitAfter(block, "\n") {
+		for len(line) > max {
+			cut := max
+			for cut > 0 && !utf8.RuneStart(line[cut]) {
+				cut--
+			}
+			if cut == 0 {
+				// a single rune longer than the maximum length
+				_, cut = utf8.DecodeRuneInString(line)
+			}
+			pieces = append(pieces, line[:cut])
+			line = line[cut:]
+		}
+		if len(current)+len(line) > max {
+			pieces = append(pieces, current)
+			current = ""
+		}
+		current += line
+	}
+	if current != "" {
+		pieces = append(pieces, current)
+	}
+	return pieces
+}
+
+// headings returns the text of the headings of the markdown, skipping code
+// blocks
+func headings(markdown string) []string {
+	result := []string{}
+	inFence := false
+	for _, line := range strings.Split(markdown, "\n") {
+		if strings.HasPrefix(line, "```") {
+			inFence = !inFence
+			continue
+		}
+		if !inFence && strings.HasPrefix(line, "#") {
+			text := strings.TrimLeft(line, "#")
+			if strings.HasPrefix(text, " ") {
+				result = append(result, strings.TrimSpace(text))
+			}
+		}
+	}
+	return result
+}
+
+// anchor returns the anchor GitHub generates for a heading
+func anchor(heading string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(heading) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '_':
+			b.WriteRune(r)
+		case r == ' ':
+			b.WriteRune('-')
+		}
+	}
+	return b.String()
+}
+
+// uniqueAnchor returns the anchor of the heading, suffixed like GitHub does if
+// the same anchor has been seen before
+func uniqueAnchor(heading string, seen map[string]int) string {
+	a := anchor(heading)
+	n := seen[a]
+	seen[a]++
+	if n == 0 {
+		return a
+	}
+	return fmt.Sprintf("%s-%d", a, n)
+}
@@ -0,0 +1,110 @@
+package notes
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitterFits(t *testing.T) {
+	parts := NewSplitter("notes.md").Split("## Bug Fixes\n\n- Fix a bug\n")
+	require.Equal(t, []*Part{{Name: "notes.md", Content: "## Bug Fixes\n\n- Fix a bug\n"}}, parts)
+}
+
+func TestSplitter(t *testing.T) {
+	notes := func(n int, text string) string {
+		return strings.Repeat("- "+text+"\n", n)
+	}
+	markdown := "## Action Required\n\n- See [the CLI notes](#sig-cli) and [the fixes](#bug-fixes)\n\n" +
+		"## Notes from Individual SIGs\n\n" +
+		"### SIG CLI\n\n" + notes(20, "A kubectl change") + "\n" +
+		"### SIG Node\n\n" + notes(20, "A kubelet change") + "\n" +
+		"## Bug Fixes\n\n" + notes(10, "A fix") + "\n" +
+		"## Other Notable Changes\n\n### SIG CLI\n\n- Back to [action required](#action-required)\n"
+
+	splitter := NewSplitter("notes.md")
+	splitter.MaxLength = 500
+	parts := splitter.Split(markdown)
+
+	require.Len(t, parts, 4)
+	for _, part := range parts {
+		require.True(t, len(part.Content) <= splitter.MaxLength, part.Name)
+	}
+	require.Equal(t, []string{"notes.md", "notes-2.md", "notes-3.md", "notes-4.md"}, []string{parts[0].Name, parts[1].Name, parts[2].Name, parts[3].Name})
+
+	// the main part links to the moved sections and headings
+	require.True(t, strings.HasPrefix(parts[0].Content, "## Action Required\n\n- See [the CLI notes](notes-2.md#sig-cli) and [the fixes](notes-3.md#bug-fixes)\n"))
+	require.Contains(t, parts[0].Content, "## Further Release Notes\n")
+	require.Contains(t, parts[0].Content, "- [Notes from Individual SIGs](notes-2.md#notes-from-individual-sigs)\n")
+	require.Contains(t, parts[0].Content, "- [Other Notable Changes](notes-4.md#other-notable-changes)\n")
+
+	// the subsections of a section too long for a single part are split
+	require.True(t, strings.HasPrefix(parts[1].Content, "## Notes from Individual SIGs\n\n### SIG CLI\n"))
+	require.True(t, strings.HasPrefix(parts[2].Content, "### SIG Node\n"))
+	require.Contains(t, parts[2].Content, "\n## Bug Fixes\n")
+
+	// the second SIG CLI heading has a suffixed anchor in the original notes,
+	// but not in its part
+	require.Contains(t, parts[3].Content, "- Back to [action required](notes.md#action-required)\n")
+
+	// all content is kept
+	content := ""
+	for _, part := range parts {
+		content += part.Content
+	}
+	for _, line := range strings.Split(markdown, "\n") {
+		if !strings.Contains(line, "](#") {
+			require.Contains(t, content, line)
+		}
+	}
+}
+
+func TestSplitterBaseURL(t *testing.T) {
+	splitter := NewSplitter("notes.md")
+	splitter.MaxLength = 250
+	splitter.BaseURL = "https://example.com/"
+	parts := splitter.Split("## A\n\n" + strings.Repeat("- a\n", 30) + "\n## B\n\n" + strings.Repeat("- b\n", 30))
+
+	require.Len(t, parts, 2)
+	require.Contains(t, parts[0].Content, "- [B](https://example.com/notes-2.md#b)\n")
+}
+
+func TestSplitterPreamble(t *testing.T) {
+	splitter := NewSplitter("notes.md")
+	splitter.MaxLength = 300
+
+	// notes without a level two heading
+	markdown := "# v1.16.0\n\n" + strings.Repeat("- a change\n", 60)
+	parts := splitter.Split(markdown)
+	require.True(t, len(parts) > 2)
+	content := ""
+	for i, part := range parts {
+		require.True(t, len(part.Content) <= splitter.MaxLength, part.Name)
+		if i > 0 {
+			content += part.Content
+		}
+	}
+	require.True(t, strings.HasPrefix(parts[0].Content, "# v1.16.0\n\n- a change\n"))
+	require.Contains(t, parts[0].Content, "- [Continued](notes-2.md)\n")
+	require.Equal(t, markdown, parts[0].Content[:strings.Index(parts[0].Content, "## Further Release Notes")]+content)
+
+	// a preamble too long for the main part is followed by all sections
+	parts = splitter.Split(strings.Repeat("- a change\n", 40) + "## Bug Fixes\n\n- A fix\n")
+	for _, part := range parts {
+		require.True(t, len(part.Content) <= splitter.MaxLength, part.Name)
+	}
+	require.Contains(t, parts[0].Content, "- [Continued](notes-2.md)\n- [Bug Fixes](notes-3.md#bug-fixes)\n")
+	require.Contains(t, parts[len(parts)-1].Content, "## Bug Fixes\n\n- A fix\n")
+}
+
+func TestSplitBlockRunes(t *testing.T) {
+	pieces := splitBlock(strings.Repeat("ä", 10), 5)
+	require.Equal(t, []string{"ää", "ää", "ää", "ää", "ää"}, pieces)
+	for _, piece := range pieces {
+		require.True(t, utf8.ValidString(piece))
+	}
+	// a rune longer than the maximum length is kept whole
+	require.Equal(t, []string{"ä", "ä"}, splitBlock("ää", 1))
+}
@@ -0,0 +1,187 @@
+// Copyright 2019 The Kubernetes Authors All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notes
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"cloud.google.com/go/storage"
+	"github.com/pkg/errors"
+)
+
+// Store is a key value store backing a ResponseCache. Keys are hex encoded
+// hashes, so they are safe to use as file and object names.
+type Store interface {
+	// Get returns the value of the key and whether it exists.
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+
+	// Put sets the value of the key.
+	Put(ctx context.Context, key string, value []byte) error
+}
+
+// OpenStore opens the store at the location: a gs://bucket/prefix URL for a
+// GCSStore, a redis://host:port URL for a RedisStore, any other non-empty
+// location for a FileStore in that directory, and a MemoryStore if the
+// location is empty. Stores which implement io.Closer must be closed after
+// use.
+func OpenStore(ctx context.Context, location string) (Store, error) {
+	switch {
+	case location == "":
+		return NewMemoryStore(), nil
+	case strings.HasPrefix(location, "gs://"):
+		return NewGCSStore(ctx, location)
+	case strings.HasPrefix(location, "redis://"):
+		return NewRedisStore(location)
+	default:
+		return NewFileStore(location)
+	}
+}
+
+// MemoryStore keeps the values in memory for the lifetime of the process.
+type MemoryStore struct {
+	mu     sync.Mutex
+	values map[string][]byte
+}
+
+// NewMemoryStore creates an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{values: map[string][]byte{}}
+}
+
+// Get implements Store.
+func (s *MemoryStore) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	value, ok := s.values[key]
+	return value, ok, nil
+}
+
+// Put implements Store.
+func (s *MemoryStore) Put(ctx context.Context, key string, value []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.values[key] = value
+	return nil
+}
+
+// FileStore keeps every value in a file of a directory, e.g. a directory
+// restored from the CI cache.
+type FileStore struct {
+	Dir string
+}
+
+// NewFileStore creates a FileStore in the directory, creating it if needed.
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, errors.Wrapf(err, "error creating the cache directory %s", dir)
+	}
+	return &FileStore{Dir: dir}, nil
+}
+
+// Get implements Store.
+func (s *FileStore) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	value, err := ioutil.ReadFile(filepath.Join(s.Dir, key))
+	if os.IsNotExist(err) {
+		return nil, false, nil
+	}
+	return value, err == nil, err
+}
+
+// Put implements Store. The value is written to a temporary file first, so
+// that concurrent readers never see a partial value.
+func (s *FileStore) Put(ctx context.Context, key string, value []byte) error {
+	tmp, err := ioutil.TempFile(s.Dir, key+".tmp-")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(value); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), filepath.Join(s.Dir, key))
+}
+
+// GCSStore keeps every value in an object of a Google Cloud Storage bucket
+// using the application default credentials, so that ephemeral CI runners
+// can share it.
+type GCSStore struct {
+	Bucket string
+	Prefix string
+
+	client *storage.Client
+}
+
+// NewGCSStore creates a GCSStore from a location in the form
+// gs://bucket/prefix.
+func NewGCSStore(ctx context.Context, location string) (*GCSStore, error) {
+	parts := strings.SplitN(strings.TrimPrefix(location, "gs://"), "/", 2)
+	if !strings.HasPrefix(location, "gs://") || parts[0] == "" {
+		return nil, NewError(ErrValidation, "invalid GCS location %q, expected gs://bucket/prefix", location)
+	}
+	client, err := storage.NewClient(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "error creating GCS client")
+	}
+
+	s := &GCSStore{Bucket: parts[0], client: client}
+	if len(parts) == 2 {
+		s.Prefix = strings.Trim(parts[1], "/")
+	}
+	return s, nil
+}
+
+func (s *GCSStore) object(key string) *storage.ObjectHandle {
+	return s.client.Bucket(s.Bucket).Object(path.Join(s.Prefix, key))
+}
+
+// Get implements Store.
+func (s *GCSStore) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	r, err := s.object(key).NewReader(ctx)
+	if err == storage.ErrObjectNotExist {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	defer r.Close()
+	value, err := ioutil.ReadAll(r)
+	return value, err == nil, err
+}
+
+// Put implements Store.
+func (s *GCSStore) Put(ctx context.Context, key string, value []byte) error {
+	w := s.object(key).NewWriter(ctx)
+	if _, err := w.Write(value); err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
+
+// Close closes the GCS client.
+func (s *GCSStore) Close() error {
+	return s.client.Close()
+}
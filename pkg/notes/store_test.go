@@ -0,0 +1,149 @@
+package notes
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenStore(t *testing.T) {
+	ctx := context.Background()
+	dir, err := ioutil.TempDir("", "store-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	memory, err := OpenStore(ctx, "")
+	require.NoError(t, err)
+	require.IsType(t, &MemoryStore{}, memory)
+
+	files, err := OpenStore(ctx, filepath.Join(dir, "cache"))
+	require.NoError(t, err)
+	require.IsType(t, &FileStore{}, files)
+
+	_, err = OpenStore(ctx, "gs://")
+	require.Error(t, err)
+
+	redis, err := OpenStore(ctx, "redis://:secret@localhost/2?prefix=notes/")
+	require.NoError(t, err)
+	require.Equal(t, &RedisStore{Addr: "localhost:6379", Password: "secret", DB: 2, Prefix: "notes/", Timeout: 10 * time.Second}, redis)
+	_, err = OpenStore(ctx, "redis://localhost/db")
+	require.Error(t, err)
+
+	for _, store := range []Store{memory, files} {
+		_, ok, err := store.Get(ctx, "key")
+		require.NoError(t, err)
+		require.False(t, ok)
+
+		require.NoError(t, store.Put(ctx, "key", []byte("value")))
+		value, ok, err := store.Get(ctx, "key")
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Equal(t, "value", string(value))
+	}
+
+	// no temporary files are left behind
+	entries, err := ioutil.ReadDir(filepath.Join(dir, "cache"))
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+}
+
+func TestRedisStore(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	// a fake server of the commands used by the store
+	commands := make(chan string, 100)
+	var mu sync.Mutex
+	values := map[string]string{}
+	serve := func(conn net.Conn) {
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		for {
+			reply, err := readRedisReply(r)
+			if err != nil {
+				return
+			}
+			args := []string{}
+			for _, arg := range reply.([]interface{}) {
+				args = append(args, string(arg.([]byte)))
+			}
+			commands <- args[0]
+
+			mu.Lock()
+			switch args[0] {
+			case "AUTH":
+				if args[1] != "secret" {
+					fmt.Fprint(conn, "-ERR invalid password\r\n")
+					break
+				}
+				fmt.Fprint(conn, "+OK\r\n")
+			case "SELECT":
+				fmt.Fprint(conn, "+OK\r\n")
+			case "GET":
+				value, ok := values[args[1]]
+				if !ok {
+					fmt.Fprint(conn, "$-1\r\n")
+					break
+				}
+				fmt.Fprintf(conn, "$%d\r\n%s\r\n", len(value), value)
+			case "SET":
+				values[args[1]] = args[2]
+				fmt.Fprint(conn, "+OK\r\n")
+			}
+			mu.Unlock()
+		}
+	}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go serve(conn)
+		}
+	}()
+
+	ctx := context.Background()
+	store, err := NewRedisStore("redis://:secret@" + listener.Addr().String() + "/1?prefix=notes/")
+	require.NoError(t, err)
+	defer store.Close()
+
+	_, ok, err := store.Get(ctx, "key")
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.NoError(t, store.Put(ctx, "key", []byte("multi\r\nline value")))
+	value, ok, err := store.Get(ctx, "key")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, "multi\r\nline value", string(value))
+
+	// the store reconnects after the connection has been closed
+	store.Close()
+	_, ok, err = store.Get(ctx, "key")
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	sent := []string{}
+	for len(commands) > 0 {
+		sent = append(sent, <-commands)
+	}
+	require.Equal(t, "AUTH SELECT GET SET GET AUTH SELECT GET", strings.Join(sent, " "))
+
+	// error replies are returned
+	store, err = NewRedisStore("redis://:wrong@" + listener.Addr().String())
+	require.NoError(t, err)
+	_, _, err = store.Get(ctx, "key")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "invalid password")
+}
@@ -0,0 +1,135 @@
+// Copyright 2019 The Kubernetes Authors All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notes
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Summarizer proposes shorter versions of over-long note texts.
+type Summarizer interface {
+	// Summarize returns a version of the text with at most maxWords words,
+	// or an empty string if it cannot propose one.
+	Summarize(text string, maxWords int) string
+}
+
+// ExtractiveSummarizer shortens a text by picking its most significant
+// sentences. The significance of a sentence is the average frequency of its
+// words within the whole text, so sentences repeating the subject of the note
+// are preferred over digressions. The picked sentences keep their order, and
+// the first sentence is always picked if it fits, since it usually carries
+// the gist of a note.
+type ExtractiveSummarizer struct{}
+
+// sentenceEndRe matches the end of a sentence
+var sentenceEndRe = regexp.MustCompile(`[.!?](\s+|$)`)
+
+// Summarize implements Summarizer.
+func (s *ExtractiveSummarizer) Summarize(text string, maxWords int) string {
+	if maxWords <= 0 {
+		return ""
+	}
+
+	sentences := splitSentences(text)
+	if len(sentences) == 0 {
+		return ""
+	}
+
+	// a single sentence is too long on its own, so cut it
+	firstWords := strings.Fields(sentences[0])
+	if len(firstWords) > maxWords {
+		return strings.Join(firstWords[:maxWords], " ") + " …"
+	}
+
+	frequency := map[string]int{}
+	for _, word := range significantWords(text) {
+		frequency[word]++
+	}
+
+	type scored struct {
+		index int
+		words int
+		score float64
+	}
+	candidates := []scored{}
+	for i, sentence := range sentences[1:] {
+		words := significantWords(sentence)
+		if len(words) == 0 {
+			continue
+		}
+		total := 0
+		for _, word := range words {
+			total += frequency[word]
+		}
+		candidates = append(candidates, scored{
+			index: i + 1,
+			words: len(strings.Fields(sentence)),
+			score: float64(total) / float64(len(words)),
+		})
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].score > candidates[j].score
+	})
+
+	picked := map[int]bool{0: true}
+	budget := maxWords - len(firstWords)
+	for _, c := range candidates {
+		if c.words <= budget {
+			picked[c.index] = true
+			budget -= c.words
+		}
+	}
+
+	result := []string{}
+	for i, sentence := range sentences {
+		if picked[i] {
+			result = append(result, sentence)
+		}
+	}
+	return strings.Join(result, " ")
+}
+
+// splitSentences splits the text into trimmed, non-empty sentences
+func splitSentences(text string) []string {
+	sentences := []string{}
+	start := 0
+	for _, loc := range sentenceEndRe.FindAllStringIndex(text, -1) {
+		if sentence := strings.TrimSpace(text[start:loc[1]]); sentence != "" {
+			sentences = append(sentences, sentence)
+		}
+		start = loc[1]
+	}
+	if sentence := strings.TrimSpace(text[start:]); sentence != "" {
+		sentences = append(sentences, sentence)
+	}
+	return sentences
+}
+
+// significantWords returns the lowercase words of the text, leaving out short
+// words which are mostly articles and prepositions
+func significantWords(text string) []string {
+	words := []string{}
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if len(word) > 3 {
+			words = append(words, word)
+		}
+	}
+	return words
+}
@@ -0,0 +1,107 @@
+// Copyright 2019 The Kubernetes Authors All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notes
+
+import (
+	"strings"
+
+	"github.com/blang/semver"
+	"github.com/google/go-github/github"
+	"github.com/pkg/errors"
+)
+
+// ReleaseRange is the commit range the release notes of a tag cover.
+type ReleaseRange struct {
+	Tag         string `json:"tag"`
+	PreviousTag string `json:"previous_tag"`
+	StartSHA    string `json:"start_sha"`
+	EndSHA      string `json:"end_sha"`
+}
+
+// PreviousTag returns the tag the release notes of the given tag start from.
+// The notes of a new minor release cover everything since the previous minor
+// release, e.g. v1.15.0 for v1.16.0, and the notes of all other releases
+// cover everything since the highest tag below them, e.g. v1.16.0-rc.1 for
+// v1.16.0-rc.2 and v1.15.2 for v1.15.3. Tags which are no semantic versions
+// are ignored.
+func PreviousTag(tag string, tags []string) (string, error) {
+	v, err := semver.Parse(strings.TrimPrefix(tag, "v"))
+	if err != nil {
+		return "", NewError(ErrValidation, "invalid release tag %s: %v", tag, err)
+	}
+
+	var previous string
+	var previousVersion semver.Version
+	for _, candidate := range tags {
+		cv, err := semver.Parse(strings.TrimPrefix(candidate, "v"))
+		if err != nil || !cv.LT(v) {
+			continue
+		}
+		if v.Patch == 0 && len(v.Pre) == 0 {
+			if cv.Major == v.Major && cv.Minor == v.Minor-1 && cv.Patch == 0 && len(cv.Pre) == 0 {
+				return candidate, nil
+			}
+			continue
+		}
+		if previous == "" || cv.GT(previousVersion) {
+			previous, previousVersion = candidate, cv
+		}
+	}
+	if previous == "" {
+		return "", NewError(ErrNotFound, "no tag to start the release notes of %s from", tag)
+	}
+	return previous, nil
+}
+
+// ResolveReleaseRange resolves the commit range of the release notes of a
+// tag from the tags of the repository.
+func ResolveReleaseRange(client *github.Client, tag string, opts ...GithubApiOption) (*ReleaseRange, error) {
+	c := configFromOpts(opts...)
+
+	shas := map[string]string{}
+	names := []string{}
+	listOpts := &github.ListOptions{PerPage: 100}
+	for {
+		ctx, cancel := c.requestContext()
+		tags, resp, err := client.Repositories.ListTags(ctx, c.org, c.repo, listOpts)
+		cancel()
+		if err != nil {
+			return nil, classify(errors.Wrapf(err, "error listing the tags of %s/%s", c.org, c.repo))
+		}
+		for _, t := range tags {
+			shas[t.GetName()] = t.GetCommit().GetSHA()
+			names = append(names, t.GetName())
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		listOpts.Page = resp.NextPage
+	}
+
+	end, ok := shas[tag]
+	if !ok {
+		return nil, NewError(ErrNotFound, "tag %s does not exist in %s/%s", tag, c.org, c.repo)
+	}
+	previous, err := PreviousTag(tag, names)
+	if err != nil {
+		return nil, err
+	}
+	return &ReleaseRange{
+		Tag:         tag,
+		PreviousTag: previous,
+		StartSHA:    shas[previous],
+		EndSHA:      end,
+	}, nil
+}
@@ -0,0 +1,60 @@
+package notes
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/google/go-github/github"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPreviousTag(t *testing.T) {
+	tags := []string{
+		"v1.14.0", "v1.14.9", "v1.15.0-rc.1", "v1.15.0", "v1.15.1", "v1.15.2",
+		"v1.16.0-alpha.0", "v1.16.0-rc.1", "v1.16.0-rc.2", "v1.16.0", "latest",
+	}
+	for tag, expected := range map[string]string{
+		"v1.16.0":        "v1.15.0",
+		"v1.16.0-rc.2":   "v1.16.0-rc.1",
+		"v1.16.0-rc.1":   "v1.16.0-alpha.0",
+		"v1.15.3":        "v1.15.2",
+		"v1.15.1":        "v1.15.0",
+		"v1.16.0-beta.0": "v1.16.0-alpha.0",
+	} {
+		previous, err := PreviousTag(tag, tags)
+		require.NoError(t, err, tag)
+		require.Equal(t, expected, previous, tag)
+	}
+
+	_, err := PreviousTag("v1.14.0", tags)
+	require.Equal(t, ErrNotFound, Kind(err))
+	_, err = PreviousTag("latest", tags)
+	require.Equal(t, ErrValidation, Kind(err))
+}
+
+func TestResolveReleaseRange(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/repos/o/r/tags", r.URL.Path)
+		if r.URL.Query().Get("page") == "" {
+			w.Header().Set("Link", fmt.Sprintf(`<%s/repos/o/r/tags?page=2>; rel="next"`, server.URL))
+			fmt.Fprint(w, `[{"name": "v1.15.1", "commit": {"sha": "sha1"}}]`)
+			return
+		}
+		fmt.Fprint(w, `[{"name": "v1.15.0", "commit": {"sha": "sha0"}}]`)
+	}))
+	defer server.Close()
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+
+	releaseRange, err := ResolveReleaseRange(client, "v1.15.1", WithOrg("o"), WithRepo("r"))
+	require.NoError(t, err)
+	require.Equal(t, &ReleaseRange{Tag: "v1.15.1", PreviousTag: "v1.15.0", StartSHA: "sha0", EndSHA: "sha1"}, releaseRange)
+
+	_, err = ResolveReleaseRange(client, "v1.15.2", WithOrg("o"), WithRepo("r"))
+	require.Equal(t, ErrNotFound, Kind(err))
+}
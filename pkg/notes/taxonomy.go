@@ -0,0 +1,185 @@
+// Copyright 2019 The Kubernetes Authors All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notes
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Category is a section of a changelog organized by kind.
+type Category struct {
+	Name string `json:"name"`
+
+	// Kinds are the kind/ labels of the notes in the category, without the
+	// prefix, e.g. "bug"
+	Kinds []string `json:"kinds"`
+
+	// Keywords classify notes without a matching kind label. A note matches
+	// if a word of its text starts with a keyword, ignoring case.
+	Keywords []string `json:"keywords,omitempty"`
+
+	compileOnce sync.Once
+	keywordsRe  *regexp.Regexp
+}
+
+// Taxonomy maps release notes to the categories of a changelog. Categories
+// are tried in order by the kind labels of a note. Notes without a matching
+// label go to the category whose keyword occurs first in their text, e.g.
+// "Fixed a crash when adding a node" is a bug fix.
+type Taxonomy struct {
+	Categories []*Category `json:"categories"`
+
+	// Default is the category of notes which match no other category
+	Default string `json:"default"`
+}
+
+// DefaultTaxonomy buckets notes the way the upstream changelogs do.
+var DefaultTaxonomy = &Taxonomy{
+	Categories: []*Category{
+		{Name: "API Change", Kinds: []string{"api-change"}},
+		{Name: "Feature", Kinds: []string{"feature"}, Keywords: []string{"add", "support", "introduc", "allow"}},
+		{Name: "Bug or Regression", Kinds: []string{"bug", "regression"}, Keywords: []string{"fix", "regression", "panic", "crash"}},
+		{Name: "Cleanup", Kinds: []string{"cleanup", "deprecation"}, Keywords: []string{"deprecat", "remov", "clean"}},
+		{Name: "Failing Test", Kinds: []string{"failing-test", "flake"}, Keywords: []string{"flak", "test"}},
+	},
+	Default: "Uncategorized",
+}
+
+// LoadTaxonomy reads a taxonomy from a JSON file.
+func LoadTaxonomy(path string) (*Taxonomy, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	t := &Taxonomy{}
+	if err := json.Unmarshal(content, t); err != nil {
+		return nil, NewError(ErrParse, "error parsing taxonomy %s: %v", path, err)
+	}
+	if err := t.Validate(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Validate checks that all categories have a distinct name.
+func (t *Taxonomy) Validate() error {
+	names := map[string]bool{t.Default: true}
+	if t.Default == "" {
+		return NewError(ErrValidation, "the taxonomy lacks a default category")
+	}
+	for _, category := range t.Categories {
+		if category.Name == "" {
+			return NewError(ErrValidation, "a category of the taxonomy lacks a name")
+		}
+		if names[category.Name] {
+			return NewError(ErrValidation, "the category %q is listed twice", category.Name)
+		}
+		names[category.Name] = true
+	}
+	return nil
+}
+
+// Classify returns the name of the category of the note.
+func (t *Taxonomy) Classify(note *ReleaseNote) string {
+	for _, category := range t.Categories {
+		for _, kind := range category.Kinds {
+			if HasString(note.Kinds, kind) {
+				return category.Name
+			}
+		}
+	}
+	name, first := t.Default, -1
+	for _, category := range t.Categories {
+		re := category.keywords()
+		if re == nil {
+			continue
+		}
+		if loc := re.FindStringIndex(note.Text); loc != nil && (first < 0 || loc[0] < first) {
+			name, first = category.Name, loc[0]
+		}
+	}
+	return name
+}
+
+// keywords returns the expression matching the keywords, or nil if the
+// category has none. It is compiled on first use.
+func (c *Category) keywords() *regexp.Regexp {
+	c.compileOnce.Do(func() {
+		if len(c.Keywords) == 0 {
+			return
+		}
+		quoted := make([]string, len(c.Keywords))
+		for i, keyword := range c.Keywords {
+			quoted[i] = regexp.QuoteMeta(keyword)
+		}
+		c.keywordsRe = regexp.MustCompile(`(?i)\b(` + strings.Join(quoted, "|") + `)`)
+	})
+	return c.keywordsRe
+}
+
+// Apply sets the category of every note.
+func (t *Taxonomy) Apply(notes ReleaseNoteList) {
+	for _, note := range notes {
+		note.Category = t.Classify(note)
+	}
+}
+
+// CategorizedNotes are the notes of a category of a Document.
+type CategorizedNotes struct {
+	Name  string   `json:"name"`
+	Notes []string `json:"notes"`
+}
+
+// CreateDocumentByKind assembles a document which lists the notes requiring
+// action first and buckets all other notes into the categories of the
+// taxonomy, in the order of the taxonomy. Empty categories are omitted.
+func CreateDocumentByKind(notes ReleaseNoteList, t *Taxonomy) (*Document, error) {
+	if err := t.Validate(); err != nil {
+		return nil, err
+	}
+	t.Apply(notes)
+
+	prs := make([]int, 0, len(notes))
+	for pr := range notes {
+		prs = append(prs, pr)
+	}
+	sort.Ints(prs)
+
+	doc := &Document{ActionRequired: []string{}}
+	byCategory := map[string][]string{}
+	for _, pr := range prs {
+		note := notes[pr]
+		if note.ActionRequired {
+			doc.ActionRequired = append(doc.ActionRequired, note.Markdown)
+			continue
+		}
+		byCategory[note.Category] = append(byCategory[note.Category], note.Markdown)
+	}
+
+	for _, category := range t.Categories {
+		if len(byCategory[category.Name]) > 0 {
+			doc.Categories = append(doc.Categories, &CategorizedNotes{Name: category.Name, Notes: byCategory[category.Name]})
+		}
+	}
+	if len(byCategory[t.Default]) > 0 {
+		doc.Categories = append(doc.Categories, &CategorizedNotes{Name: t.Default, Notes: byCategory[t.Default]})
+	}
+	return doc, nil
+}
@@ -0,0 +1,100 @@
+package notes
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTaxonomyClassify(t *testing.T) {
+	cases := []struct {
+		note     *ReleaseNote
+		category string
+	}{
+		{&ReleaseNote{Kinds: []string{"api-change", "bug"}}, "API Change"},
+		{&ReleaseNote{Kinds: []string{"regression"}}, "Bug or Regression"},
+		{&ReleaseNote{Kinds: []string{"flake"}}, "Failing Test"},
+		{&ReleaseNote{Text: "Fixed a panic in kubectl"}, "Bug or Regression"},
+		{&ReleaseNote{Text: "Deprecated the --foo flag"}, "Cleanup"},
+		{&ReleaseNote{Text: "Adds support for IPv6"}, "Feature"},
+		// the earliest keyword wins
+		{&ReleaseNote{Text: "Fixed a crash when adding a node"}, "Bug or Regression"},
+		{&ReleaseNote{Text: "Adds a flag to fix the ownership of volumes"}, "Feature"},
+		{&ReleaseNote{Text: "Removed the deprecated flags to fix the defaults"}, "Cleanup"},
+		// keywords match at the start of words only
+		{&ReleaseNote{Text: "Prefix the metrics"}, "Uncategorized"},
+	}
+	for _, c := range cases {
+		require.Equal(t, c.category, DefaultTaxonomy.Classify(c.note), c.note.Text)
+	}
+}
+
+func TestLoadTaxonomy(t *testing.T) {
+	dir, err := ioutil.TempDir("", "taxonomy-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "taxonomy.json")
+	require.NoError(t, ioutil.WriteFile(path, []byte(`{
+		"categories": [{"name": "Fixes", "kinds": ["bug"]}],
+		"default": "Other"
+	}`), 0644))
+	taxonomy, err := LoadTaxonomy(path)
+	require.NoError(t, err)
+	require.Equal(t, "Fixes", taxonomy.Classify(&ReleaseNote{Kinds: []string{"bug"}}))
+	require.Equal(t, "Other", taxonomy.Classify(&ReleaseNote{Text: "Fixes a bug"}))
+
+	require.NoError(t, ioutil.WriteFile(path, []byte(`{"categories": [{"name": "Other"}], "default": "Other"}`), 0644))
+	_, err = LoadTaxonomy(path)
+	require.True(t, errors.Is(err, ErrValidation))
+}
+
+func TestCreateDocumentByKind(t *testing.T) {
+	notes := ReleaseNoteList{
+		3: {Markdown: "Fix a bug", Kinds: []string{"bug"}},
+		1: {Markdown: "Add a field", Kinds: []string{"api-change"}},
+		2: {Markdown: "Fix another bug", Text: "Fix another bug"},
+		4: {Markdown: "Remove the flag", ActionRequired: true},
+		5: {Markdown: "Bump the version"},
+	}
+
+	doc, err := CreateDocumentByKind(notes, DefaultTaxonomy)
+	require.NoError(t, err)
+	require.Equal(t, []string{"Remove the flag"}, doc.ActionRequired)
+	require.Equal(t, []*CategorizedNotes{
+		{Name: "API Change", Notes: []string{"Add a field"}},
+		{Name: "Bug or Regression", Notes: []string{"Fix another bug", "Fix a bug"}},
+		{Name: "Uncategorized", Notes: []string{"Bump the version"}},
+	}, doc.Categories)
+	require.Equal(t, "Bug or Regression", notes[2].Category)
+
+	b := &bytes.Buffer{}
+	require.NoError(t, RenderMarkdown(doc, b))
+	require.Equal(t, `## Action Required
+
+- Remove the flag
+
+
+## Changes by Kind
+
+### API Change
+
+- Add a field
+
+### Bug or Regression
+
+- Fix another bug
+- Fix a bug
+
+### Uncategorized
+
+- Bump the version
+
+
+`, b.String())
+}
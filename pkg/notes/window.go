@@ -0,0 +1,108 @@
+// Copyright 2019 The Kubernetes Authors All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notes
+
+import (
+	"fmt"
+	"time"
+)
+
+// TimeWindow restricts the collected release notes to the PRs merged within
+// it, e.g. since the last weekly draft, so that incremental updates do not
+// process the whole release range again. A zero bound leaves the window open
+// on that side.
+type TimeWindow struct {
+	// Since is the inclusive start of the window
+	Since time.Time
+
+	// Until is the exclusive end of the window
+	Until time.Time
+}
+
+// IsZero reports whether the window is open on both sides.
+func (w TimeWindow) IsZero() bool {
+	return w.Since.IsZero() && w.Until.IsZero()
+}
+
+// Contains reports whether the time is within the window. A zero time, which
+// is an unknown merge time, is only contained in a window open on both sides.
+func (w TimeWindow) Contains(t time.Time) bool {
+	if w.IsZero() {
+		return true
+	}
+	if t.IsZero() {
+		return false
+	}
+	if !w.Since.IsZero() && t.Before(w.Since) {
+		return false
+	}
+	if !w.Until.IsZero() && !t.Before(w.Until) {
+		return false
+	}
+	return true
+}
+
+// Validate checks that the window is not empty.
+func (w TimeWindow) Validate() error {
+	if !w.Since.IsZero() && !w.Until.IsZero() && !w.Since.Before(w.Until) {
+		return NewError(ErrValidation, "the time window from %s until %s is empty", w.Since.Format(time.RFC3339), w.Until.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// SearchQualifier returns the qualifier restricting a GitHub search to the
+// PRs merged within the window, or an empty string for an open window. The
+// end of the search range is inclusive, which Contains makes up for.
+func (w TimeWindow) SearchQualifier() string {
+	const layout = "2006-01-02T15:04:05Z"
+	switch {
+	case w.IsZero():
+		return ""
+	case w.Until.IsZero():
+		return fmt.Sprintf("merged:>=%s", w.Since.UTC().Format(layout))
+	case w.Since.IsZero():
+		return fmt.Sprintf("merged:<%s", w.Until.UTC().Format(layout))
+	default:
+		return fmt.Sprintf("merged:%s..%s", w.Since.UTC().Format(layout), w.Until.UTC().Format(layout))
+	}
+}
+
+// ParseWindowBound parses a bound of a TimeWindow given as RFC 3339 time, as
+// date in UTC, e.g. 2019-07-01, or as duration before now, e.g. 168h for a
+// week ago. An empty value is a zero time.
+func ParseWindowBound(value string, now time.Time) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return t, nil
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		return now.Add(-d), nil
+	}
+	return time.Time{}, NewError(ErrValidation, "%q is neither a time, a date nor a duration", value)
+}
+
+// WithTimeWindow allows the caller to restrict the release notes to the PRs
+// merged within the window. Commits outside of it are skipped before their
+// PRs are fetched.
+func WithTimeWindow(window TimeWindow) GithubApiOption {
+	return func(c *githubApiConfig) {
+		c.window = window
+	}
+}
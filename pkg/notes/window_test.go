@@ -0,0 +1,67 @@
+// Copyright 2019 The Kubernetes Authors All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notes
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimeWindow(t *testing.T) {
+	since := time.Date(2019, 7, 1, 0, 0, 0, 0, time.UTC)
+	until := since.Add(7 * 24 * time.Hour)
+
+	window := TimeWindow{Since: since, Until: until}
+	require.NoError(t, window.Validate())
+	require.True(t, window.Contains(since))
+	require.True(t, window.Contains(until.Add(-time.Second)))
+	require.False(t, window.Contains(until))
+	require.False(t, window.Contains(since.Add(-time.Second)))
+	require.False(t, window.Contains(time.Time{}))
+	require.Equal(t, "merged:2019-07-01T00:00:00Z..2019-07-08T00:00:00Z", window.SearchQualifier())
+
+	require.Equal(t, "merged:>=2019-07-01T00:00:00Z", TimeWindow{Since: since}.SearchQualifier())
+	require.Equal(t, "merged:<2019-07-08T00:00:00Z", TimeWindow{Until: until}.SearchQualifier())
+
+	open := TimeWindow{}
+	require.True(t, open.IsZero())
+	require.True(t, open.Contains(time.Time{}))
+	require.Empty(t, open.SearchQualifier())
+
+	err := TimeWindow{Since: until, Until: since}.Validate()
+	require.Error(t, err)
+	require.Equal(t, ErrValidation, Kind(err))
+}
+
+func TestParseWindowBound(t *testing.T) {
+	now := time.Date(2019, 7, 8, 12, 0, 0, 0, time.UTC)
+
+	for value, expected := range map[string]time.Time{
+		"":                     {},
+		"2019-07-01":           time.Date(2019, 7, 1, 0, 0, 0, 0, time.UTC),
+		"2019-07-01T10:00:00Z": time.Date(2019, 7, 1, 10, 0, 0, 0, time.UTC),
+		"168h":                 time.Date(2019, 7, 1, 12, 0, 0, 0, time.UTC),
+	} {
+		parsed, err := ParseWindowBound(value, now)
+		require.NoError(t, err, value)
+		require.True(t, expected.Equal(parsed), value)
+	}
+
+	_, err := ParseWindowBound("last week", now)
+	require.Error(t, err)
+	require.Equal(t, ErrValidation, Kind(err))
+}
@@ -0,0 +1,149 @@
+// Copyright 2019 The Kubernetes Authors All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notes
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// renderYAML renders the notes as a YAML mapping of the PR numbers to the
+// notes, sorted by PR number. The fields are named and omitted like in the
+// json format.
+func renderYAML(w io.Writer, releaseNotes ReleaseNoteList) error {
+	bw := bufio.NewWriter(w)
+	if len(releaseNotes) == 0 {
+		bw.WriteString("{}\n")
+	}
+	for _, note := range sortedNotes(releaseNotes) {
+		fmt.Fprintf(bw, "%d:", note.PrNumber)
+		writeYAMLValue(bw, reflect.ValueOf(note), 2)
+	}
+	return bw.Flush()
+}
+
+// writeYAMLValue writes a value following a mapping key or sequence dash.
+// Structs and non-empty slices are written as blocks at the given indent,
+// everything else as scalar on the same line.
+func writeYAMLValue(w *bufio.Writer, v reflect.Value, indent int) {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			w.WriteString(" null\n")
+			return
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		w.WriteString("\n")
+		writeYAMLFields(w, v, indent, false)
+	case reflect.Slice:
+		if v.Len() == 0 {
+			w.WriteString(" []\n")
+			return
+		}
+		w.WriteString("\n")
+		for i := 0; i < v.Len(); i++ {
+			item := v.Index(i)
+			for item.Kind() == reflect.Ptr && !item.IsNil() {
+				item = item.Elem()
+			}
+			w.WriteString(strings.Repeat(" ", indent) + "-")
+			if item.Kind() == reflect.Struct {
+				// the first field follows the dash
+				w.WriteString(" ")
+				writeYAMLFields(w, item, indent+2, true)
+				continue
+			}
+			writeYAMLValue(w, item, indent+2)
+		}
+	case reflect.String:
+		w.WriteString(" " + yamlString(v.String()) + "\n")
+	case reflect.Bool:
+		w.WriteString(" " + strconv.FormatBool(v.Bool()) + "\n")
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		w.WriteString(" " + strconv.FormatInt(v.Int(), 10) + "\n")
+	default:
+		w.WriteString(" " + yamlString(fmt.Sprint(v.Interface())) + "\n")
+	}
+}
+
+// writeYAMLFields writes the fields of a struct as mapping entries, named by
+// their json tags. If inline is set, the first entry is not indented.
+func writeYAMLFields(w *bufio.Writer, v reflect.Value, indent int, inline bool) {
+	t := v.Type()
+	written := false
+	for i := 0; i < t.NumField(); i++ {
+		tag := strings.Split(t.Field(i).Tag.Get("json"), ",")
+		name := tag[0]
+		if name == "-" || t.Field(i).PkgPath != "" {
+			continue
+		}
+		if name == "" {
+			name = t.Field(i).Name
+		}
+		field := v.Field(i)
+		if HasString(tag[1:], "omitempty") && isEmptyValue(field) {
+			continue
+		}
+		if written || !inline {
+			w.WriteString(strings.Repeat(" ", indent))
+		}
+		w.WriteString(name + ":")
+		writeYAMLValue(w, field, indent+2)
+		written = true
+	}
+	if !written {
+		w.WriteString("{}\n")
+	}
+}
+
+// isEmptyValue reports whether encoding/json omits the value of an omitempty
+// field
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	}
+	return false
+}
+
+// yamlPlainRe matches the strings which can be written as plain scalars
+var yamlPlainRe = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9._/-]*(:[A-Za-z0-9._/#?=&%-]+)*$`)
+
+// yamlString writes s as plain scalar if it cannot be mistaken for another
+// type, and double quoted otherwise. Go escapes are valid YAML escapes.
+func yamlString(s string) string {
+	switch strings.ToLower(s) {
+	case "true", "false", "yes", "no", "on", "off", "y", "n", "null":
+		return strconv.Quote(s)
+	}
+	if yamlPlainRe.MatchString(s) {
+		return s
+	}
+	return strconv.Quote(s)
+}
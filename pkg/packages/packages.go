@@ -0,0 +1,230 @@
+// Copyright 2019 The Kubernetes Authors All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package packages generates the version strings, changelog entries and
+// build inputs of the deb and rpm packages of a release.
+package packages
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/blang/semver"
+	"github.com/pkg/errors"
+
+	"k8s.io/release/pkg/notes"
+)
+
+// PackageSIGs maps the packages built from the kubernetes release to the SIGs
+// owning them. The release notes of these SIGs end up in the changelog of the
+// package.
+var PackageSIGs = map[string][]string{
+	"kubeadm": {"cluster-lifecycle"},
+	"kubectl": {"cli"},
+	"kubelet": {"node"},
+}
+
+// Maintainer is the maintainer of the packages as listed in their changelogs.
+const Maintainer = "Kubernetes Authors <kubernetes-dev@googlegroups.com>"
+
+// Versions are the version strings of the packages of a release.
+type Versions struct {
+	// Release is the version of the release, e.g. v1.16.0-beta.1
+	Release string `json:"release"`
+
+	Major uint64 `json:"major"`
+	Minor uint64 `json:"minor"`
+	Patch uint64 `json:"patch"`
+
+	// Deb is the full version of the deb packages, e.g. 1.16.0~beta.1-00.
+	// Pre-releases are separated by a tilde, so that they sort before the
+	// final release.
+	Deb string `json:"deb"`
+
+	// DebRevision is the revision of the deb packages, e.g. 00
+	DebRevision string `json:"deb_revision"`
+
+	// RPMVersion is the version of the rpm packages, e.g. 1.16.0
+	RPMVersion string `json:"rpm_version"`
+
+	// RPMRelease is the release of the rpm packages. Pre-releases get a
+	// release starting with 0, e.g. 0.beta.1, so that they sort before the
+	// final release.
+	RPMRelease string `json:"rpm_release"`
+}
+
+// NewVersions derives the package versions from the release version.
+func NewVersions(release, debRevision, rpmRelease string) (*Versions, error) {
+	v, err := semver.Parse(strings.TrimPrefix(release, "v"))
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid release version %s", release)
+	}
+
+	upstream := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	versions := &Versions{
+		Release:     "v" + v.String(),
+		Major:       v.Major,
+		Minor:       v.Minor,
+		Patch:       v.Patch,
+		Deb:         fmt.Sprintf("%s-%s", upstream, debRevision),
+		DebRevision: debRevision,
+		RPMVersion:  upstream,
+		RPMRelease:  rpmRelease,
+	}
+
+	if len(v.Pre) > 0 {
+		pre := make([]string, 0, len(v.Pre))
+		for _, p := range v.Pre {
+			pre = append(pre, p.String())
+		}
+		versions.Deb = fmt.Sprintf("%s~%s-%s", upstream, strings.Join(pre, "."), debRevision)
+		versions.RPMRelease = "0." + strings.Join(pre, ".")
+	}
+	return versions, nil
+}
+
+// ChangelogURL returns the URL of the section of the release in the
+// kubernetes changelog.
+func (v *Versions) ChangelogURL() string {
+	return fmt.Sprintf(
+		"https://github.com/kubernetes/kubernetes/blob/master/CHANGELOG-%d.%d.md#%s",
+		v.Major, v.Minor, strings.Replace(v.Release, ".", "", -1),
+	)
+}
+
+// ChangelogEntries returns the changelog entries of a package: a pointer to
+// the full changelog, the notes which require action, and the notes of the
+// SIGs owning the package.
+func ChangelogEntries(pkg string, v *Versions, releaseNotes notes.ReleaseNoteList) []string {
+	prs := make([]int, 0, len(releaseNotes))
+	for pr := range releaseNotes {
+		prs = append(prs, pr)
+	}
+	sort.Ints(prs)
+
+	actionRequired := []string{}
+	owned := []string{}
+	for _, pr := range prs {
+		note := releaseNotes[pr]
+		text := fmt.Sprintf("%s (#%d)", strings.Join(strings.Fields(note.Text), " "), note.PrNumber)
+		switch {
+		case note.ActionRequired:
+			actionRequired = append(actionRequired, "ACTION REQUIRED: "+text)
+		case ownedBy(note, PackageSIGs[pkg]):
+			owned = append(owned, text)
+		}
+	}
+
+	entries := []string{fmt.Sprintf("Kubernetes %s, see %s", v.Release, v.ChangelogURL())}
+	entries = append(entries, actionRequired...)
+	return append(entries, owned...)
+}
+
+func ownedBy(note *notes.ReleaseNote, sigs []string) bool {
+	for _, sig := range sigs {
+		if notes.HasString(note.SIGs, sig) {
+			return true
+		}
+	}
+	return false
+}
+
+// DebianChangelog renders an entry of debian/changelog.
+func DebianChangelog(pkg, distribution string, v *Versions, entries []string, date time.Time) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s (%s) %s; urgency=optional\n\n", pkg, v.Deb, distribution)
+	for _, entry := range entries {
+		fmt.Fprintf(&b, "  * %s\n", entry)
+	}
+	fmt.Fprintf(&b, "\n -- %s  %s\n", Maintainer, date.Format(time.RFC1123Z))
+	return b.String()
+}
+
+// RPMChangelog renders an entry of the %changelog section of a spec. The
+// entries of all packages built from the spec are listed, prefixed by the
+// package name.
+func RPMChangelog(v *Versions, entries map[string][]string, date time.Time) string {
+	pkgs := make([]string, 0, len(entries))
+	for pkg := range entries {
+		pkgs = append(pkgs, pkg)
+	}
+	sort.Strings(pkgs)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "* %s %s - %s-%s\n", date.Format("Mon Jan 02 2006"), Maintainer, v.RPMVersion, v.RPMRelease)
+	seen := map[string]bool{}
+	for _, pkg := range pkgs {
+		for _, entry := range entries[pkg] {
+			// the entries shared by all packages are only listed once
+			if seen[entry] {
+				continue
+			}
+			seen[entry] = true
+			if !strings.HasPrefix(entry, "Kubernetes ") && !strings.HasPrefix(entry, "ACTION REQUIRED: ") {
+				entry = pkg + ": " + entry
+			}
+			fmt.Fprintf(&b, "- %s\n", entry)
+		}
+	}
+	return b.String()
+}
+
+// SpecInputs are the inputs of the package builds of a release.
+type SpecInputs struct {
+	Versions *Versions `json:"versions"`
+
+	// RPMDefines are the macros of rpm/kubelet.spec, to be passed to
+	// rpmbuild using --define
+	RPMDefines map[string]string `json:"rpm_defines"`
+
+	// DebianArgs are the arguments of the deb build in debian/build.go
+	DebianArgs []string `json:"debian_args"`
+}
+
+// NewSpecInputs creates the build inputs for the given versions of the
+// release and its dependencies.
+func NewSpecInputs(v *Versions, cniVersion, criToolsVersion string) *SpecInputs {
+	return &SpecInputs{
+		Versions: v,
+		RPMDefines: map[string]string{
+			"KUBE_MAJOR":        fmt.Sprint(v.Major),
+			"KUBE_MINOR":        fmt.Sprint(v.Minor),
+			"KUBE_PATCH":        fmt.Sprint(v.Patch),
+			"RPM_RELEASE":       v.RPMRelease,
+			"CNI_VERSION":       cniVersion,
+			"CRI_TOOLS_VERSION": criToolsVersion,
+		},
+		DebianArgs: []string{
+			"--kube-version=" + strings.TrimPrefix(v.Release, "v"),
+			"--revision=" + v.DebRevision,
+		},
+	}
+}
+
+// RPMBuildArgs returns the --define arguments of rpmbuild, in a stable order.
+func (s *SpecInputs) RPMBuildArgs() []string {
+	keys := make([]string, 0, len(s.RPMDefines))
+	for key := range s.RPMDefines {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	args := []string{}
+	for _, key := range keys {
+		args = append(args, "--define", fmt.Sprintf("%s %s", key, s.RPMDefines[key]))
+	}
+	return args
+}
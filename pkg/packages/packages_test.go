@@ -0,0 +1,83 @@
+package packages
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/release/pkg/notes"
+)
+
+func TestNewVersions(t *testing.T) {
+	v, err := NewVersions("v1.15.1", "00", "0")
+	require.NoError(t, err)
+	require.Equal(t, "1.15.1-00", v.Deb)
+	require.Equal(t, "1.15.1", v.RPMVersion)
+	require.Equal(t, "0", v.RPMRelease)
+	require.Equal(t, "https://github.com/kubernetes/kubernetes/blob/master/CHANGELOG-1.15.md#v1151", v.ChangelogURL())
+
+	v, err = NewVersions("1.16.0-beta.1", "00", "0")
+	require.NoError(t, err)
+	require.Equal(t, "v1.16.0-beta.1", v.Release)
+	require.Equal(t, "1.16.0~beta.1-00", v.Deb)
+	require.Equal(t, "1.16.0", v.RPMVersion)
+	require.Equal(t, "0.beta.1", v.RPMRelease)
+
+	_, err = NewVersions("latest", "00", "0")
+	require.Error(t, err)
+}
+
+func TestChangelogs(t *testing.T) {
+	v, err := NewVersions("v1.15.1", "00", "0")
+	require.NoError(t, err)
+
+	releaseNotes := notes.ReleaseNoteList{
+		2: {PrNumber: 2, Text: "Fixes a crash\nof the kubelet", SIGs: []string{"node"}},
+		1: {PrNumber: 1, Text: "Removes a flag", SIGs: []string{"cli"}, ActionRequired: true},
+		3: {PrNumber: 3, Text: "Adds a kubectl flag", SIGs: []string{"cli"}},
+	}
+
+	kubelet := ChangelogEntries("kubelet", v, releaseNotes)
+	require.Equal(t, []string{
+		"Kubernetes v1.15.1, see " + v.ChangelogURL(),
+		"ACTION REQUIRED: Removes a flag (#1)",
+		"Fixes a crash of the kubelet (#2)",
+	}, kubelet)
+
+	date := time.Date(2019, 7, 8, 12, 0, 0, 0, time.UTC)
+	require.Equal(t, `kubelet (1.15.1-00) kubernetes-xenial; urgency=optional
+
+  * Kubernetes v1.15.1, see `+v.ChangelogURL()+`
+  * ACTION REQUIRED: Removes a flag (#1)
+  * Fixes a crash of the kubelet (#2)
+
+ -- Kubernetes Authors <kubernetes-dev@googlegroups.com>  Mon, 08 Jul 2019 12:00:00 +0000
+`, DebianChangelog("kubelet", "kubernetes-xenial", v, kubelet, date))
+
+	require.Equal(t, `* Mon Jul 08 2019 Kubernetes Authors <kubernetes-dev@googlegroups.com> - 1.15.1-0
+- Kubernetes v1.15.1, see `+v.ChangelogURL()+`
+- ACTION REQUIRED: Removes a flag (#1)
+- kubectl: Adds a kubectl flag (#3)
+- kubelet: Fixes a crash of the kubelet (#2)
+`, RPMChangelog(v, map[string][]string{
+		"kubelet": kubelet,
+		"kubectl": ChangelogEntries("kubectl", v, releaseNotes),
+	}, date))
+}
+
+func TestSpecInputs(t *testing.T) {
+	v, err := NewVersions("v1.15.1", "00", "0")
+	require.NoError(t, err)
+
+	inputs := NewSpecInputs(v, "0.7.5", "1.13.0")
+	require.Equal(t, []string{"--kube-version=1.15.1", "--revision=00"}, inputs.DebianArgs)
+	require.Equal(t, []string{
+		"--define", "CNI_VERSION 0.7.5",
+		"--define", "CRI_TOOLS_VERSION 1.13.0",
+		"--define", "KUBE_MAJOR 1",
+		"--define", "KUBE_MINOR 15",
+		"--define", "KUBE_PATCH 1",
+		"--define", "RPM_RELEASE 0",
+	}, inputs.RPMBuildArgs())
+}
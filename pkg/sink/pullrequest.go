@@ -0,0 +1,261 @@
+// Copyright 2019 The Kubernetes Authors All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sink
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/google/go-github/github"
+	"github.com/pkg/errors"
+)
+
+// PullRequest publishes the content as a draft pull request. Every write
+// force-updates the (bot owned) branch to a single commit on top of the base
+// branch and opens a draft pull request for it, or updates the description of
+// the pull request which is already open.
+type PullRequest struct {
+	// GitHub holds the repository, branch and path of the published file.
+	GitHub
+
+	// Base is the branch the pull request is opened against. It defaults to
+	// the default branch of the repository.
+	Base string
+
+	// Title is the title of the pull request. It defaults to the commit
+	// message.
+	Title string
+
+	url string
+}
+
+// ParsePullRequestTarget creates a PullRequest sink from a target in the form
+// org/repo@branch:path/to/file.md.
+func ParsePullRequestTarget(client *github.Client, target string) (*PullRequest, error) {
+	gh, err := ParseGitHubTarget(client, target)
+	if err != nil {
+		return nil, err
+	}
+	return &PullRequest{GitHub: *gh}, nil
+}
+
+// Write force-updates the branch and opens or updates the pull request.
+func (p *PullRequest) Write(ctx context.Context, content []byte) error {
+	message := p.Message
+	if message == "" {
+		message = "Update " + p.Path
+	}
+	title := p.Title
+	if title == "" {
+		title = message
+	}
+
+	base := p.Base
+	if base == "" {
+		repo, _, err := p.Client.Repositories.Get(ctx, p.Org, p.Repo)
+		if err != nil {
+			return errors.Wrapf(err, "error getting repository %s/%s", p.Org, p.Repo)
+		}
+		base = repo.GetDefaultBranch()
+	}
+
+	previous, err := p.previousDraft(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := p.commit(ctx, base, message, content); err != nil {
+		return err
+	}
+
+	description := DraftDescription(p.Path, previous, string(content))
+
+	prs, _, err := p.Client.PullRequests.List(ctx, p.Org, p.Repo, &github.PullRequestListOptions{
+		State: "open",
+		Head:  p.Org + ":" + p.Branch,
+		Base:  base,
+	})
+	if err != nil {
+		return errors.Wrap(err, "error listing pull requests")
+	}
+
+	var pr *github.PullRequest
+	if len(prs) > 0 {
+		pr, _, err = p.Client.PullRequests.Edit(ctx, p.Org, p.Repo, prs[0].GetNumber(), &github.PullRequest{
+			Title: &title,
+			Body:  &description,
+		})
+	} else {
+		pr, _, err = p.Client.PullRequests.Create(ctx, p.Org, p.Repo, &github.NewPullRequest{
+			Title: &title,
+			Head:  &p.Branch,
+			Base:  &base,
+			Body:  &description,
+			Draft: github.Bool(true),
+		})
+	}
+	if err != nil {
+		return errors.Wrap(err, "error publishing pull request")
+	}
+
+	p.url = pr.GetHTMLURL()
+	return nil
+}
+
+// previousDraft returns the content of the file on the branch, which is empty
+// if there has not been a draft yet
+func (p *PullRequest) previousDraft(ctx context.Context) (string, error) {
+	file, _, resp, err := p.Client.Repositories.GetContents(
+		ctx, p.Org, p.Repo, p.Path,
+		&github.RepositoryContentGetOptions{Ref: p.Branch},
+	)
+	if isNotFound(resp) {
+		return "", nil
+	}
+	if err != nil {
+		return "", errors.Wrapf(err, "error getting the previous draft of %s", p.Path)
+	}
+	return file.GetContent()
+}
+
+// commit creates a commit containing the content on top of the base branch
+// and points the branch at it
+func (p *PullRequest) commit(ctx context.Context, base, message string, content []byte) error {
+	baseRef, _, err := p.Client.Git.GetRef(ctx, p.Org, p.Repo, "heads/"+base)
+	if err != nil {
+		return errors.Wrapf(err, "error getting branch %s", base)
+	}
+	baseCommit, _, err := p.Client.Git.GetCommit(ctx, p.Org, p.Repo, baseRef.GetObject().GetSHA())
+	if err != nil {
+		return errors.Wrapf(err, "error getting the head commit of %s", base)
+	}
+
+	tree, _, err := p.Client.Git.CreateTree(ctx, p.Org, p.Repo, baseCommit.GetTree().GetSHA(), []github.TreeEntry{{
+		Path:    &p.Path,
+		Mode:    github.String("100644"),
+		Type:    github.String("blob"),
+		Content: github.String(string(content)),
+	}})
+	if err != nil {
+		return errors.Wrap(err, "error creating tree")
+	}
+
+	commit, _, err := p.Client.Git.CreateCommit(ctx, p.Org, p.Repo, &github.Commit{
+		Message: &message,
+		Tree:    tree,
+		Parents: []github.Commit{{SHA: baseCommit.SHA}},
+	})
+	if err != nil {
+		return errors.Wrap(err, "error creating commit")
+	}
+
+	ref := &github.Reference{
+		Ref:    github.String("refs/heads/" + p.Branch),
+		Object: &github.GitObject{SHA: commit.SHA},
+	}
+	_, resp, err := p.Client.Git.UpdateRef(ctx, p.Org, p.Repo, ref, true)
+	if isNotFound(resp) || isUnprocessable(resp) {
+		_, _, err = p.Client.Git.CreateRef(ctx, p.Org, p.Repo, ref)
+	}
+	return errors.Wrapf(err, "error updating branch %s", p.Branch)
+}
+
+// Location returns the URL of the pull request once it has been published.
+func (p *PullRequest) Location() string {
+	if p.url != "" {
+		return p.url
+	}
+	return p.GitHub.Location()
+}
+
+// DraftDescription generates a pull request description summarizing which
+// list entries changed between the previous and the current draft.
+func DraftDescription(path, previous, current string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "This is an automatically generated draft of `%s`.\n\n", path)
+
+	if previous == "" {
+		b.WriteString("This is the first draft.\n")
+		return b.String()
+	}
+
+	added, removed := diffEntries(previous, current)
+	if len(added) == 0 && len(removed) == 0 {
+		b.WriteString("No entries changed since the last draft.\n")
+		return b.String()
+	}
+
+	fmt.Fprintf(&b, "Since the last draft, %s added and %s removed.\n",
+		pluralize(len(added), "entry", "entries"),
+		pluralize(len(removed), "entry", "entries"),
+	)
+	for _, section := range []struct {
+		title   string
+		entries []string
+	}{
+		{"Added", added},
+		{"Removed", removed},
+	} {
+		if len(section.entries) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "\n### %s\n\n", section.title)
+		for _, entry := range section.entries {
+			b.WriteString(entry + "\n")
+		}
+	}
+	return b.String()
+}
+
+// diffEntries returns the markdown list entries which are only in current
+// (added) and only in previous (removed)
+func diffEntries(previous, current string) (added, removed []string) {
+	entries := func(s string) map[string]bool {
+		result := map[string]bool{}
+		for _, line := range strings.Split(s, "\n") {
+			if strings.HasPrefix(line, "- ") {
+				result[line] = true
+			}
+		}
+		return result
+	}
+	previousEntries, currentEntries := entries(previous), entries(current)
+
+	only := func(s string, in, notIn map[string]bool) []string {
+		result := []string{}
+		seen := map[string]bool{}
+		for _, line := range strings.Split(s, "\n") {
+			if in[line] && !notIn[line] && !seen[line] {
+				result = append(result, line)
+				seen[line] = true
+			}
+		}
+		return result
+	}
+	return only(current, currentEntries, previousEntries), only(previous, previousEntries, currentEntries)
+}
+
+func pluralize(n int, singular, plural string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, singular)
+	}
+	return fmt.Sprintf("%d %s", n, plural)
+}
+
+func isUnprocessable(resp *github.Response) bool {
+	return resp != nil && resp.StatusCode == http.StatusUnprocessableEntity
+}
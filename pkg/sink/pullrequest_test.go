@@ -0,0 +1,176 @@
+package sink
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/google/go-github/github"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDraftDescription(t *testing.T) {
+	require.Equal(t,
+		"This is an automatically generated draft of `notes.md`.\n\nThis is the first draft.\n",
+		DraftDescription("notes.md", "", "- a\n"),
+	)
+
+	require.Equal(t,
+		"This is an automatically generated draft of `notes.md`.\n\nNo entries changed since the last draft.\n",
+		DraftDescription("notes.md", "## A\n\n- a\n", "## B\n\n- a\n"),
+	)
+
+	require.Equal(t,
+		"This is an automatically generated draft of `notes.md`.\n\n"+
+			"Since the last draft, 2 entries added and 1 entry removed.\n\n"+
+			"### Added\n\n- c\n- d\n\n"+
+			"### Removed\n\n- b\n",
+		DraftDescription("notes.md",
+			"## Notes\n\n- a\n- b\n",
+			"## Notes\n\n- a\n- c\n- d\n- c\n",
+		),
+	)
+}
+
+// fakePullRequestRepo serves the API calls of PullRequest.Write for the
+// repository o/r, keeping the notes branch and its pull request
+type fakePullRequestRepo struct {
+	t *testing.T
+
+	branch  string // the head commit of the notes branch, empty if missing
+	content string // the content of notes.md on the notes branch
+	tree    []github.TreeEntry
+	commit  struct {
+		Message string   `json:"message"`
+		Tree    string   `json:"tree"`
+		Parents []string `json:"parents"`
+	}
+	forced bool
+	pr     *github.NewPullRequest
+	edited *github.PullRequest
+}
+
+func (f *fakePullRequestRepo) handler() http.Handler {
+	t := f.t
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/o/r", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"default_branch": "master"}`))
+	})
+	mux.HandleFunc("/repos/o/r/contents/notes.md", func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "notes", r.URL.Query().Get("ref"))
+		if f.branch == "" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprintf(w, `{"type": "file", "encoding": "base64", "content": %q}`,
+			base64.StdEncoding.EncodeToString([]byte(f.content)))
+	})
+	mux.HandleFunc("/repos/o/r/git/refs/heads/master", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"ref": "refs/heads/master", "object": {"sha": "base", "type": "commit"}}`))
+	})
+	mux.HandleFunc("/repos/o/r/git/commits/base", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"sha": "base", "tree": {"sha": "basetree"}}`))
+	})
+	mux.HandleFunc("/repos/o/r/git/trees", func(w http.ResponseWriter, r *http.Request) {
+		var tree struct {
+			BaseTree string             `json:"base_tree"`
+			Entries  []github.TreeEntry `json:"tree"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&tree))
+		require.Equal(t, "basetree", tree.BaseTree)
+		f.tree = tree.Entries
+		w.Write([]byte(`{"sha": "tree"}`))
+	})
+	mux.HandleFunc("/repos/o/r/git/commits", func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPost, r.Method)
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&f.commit))
+		w.Write([]byte(`{"sha": "draft"}`))
+	})
+	updateRef := func(r *http.Request) {
+		var ref struct {
+			SHA   string `json:"sha"`
+			Force bool   `json:"force"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&ref))
+		f.branch, f.forced = ref.SHA, ref.Force
+		f.content = f.tree[0].GetContent()
+	}
+	mux.HandleFunc("/repos/o/r/git/refs/heads/notes", func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPatch, r.Method)
+		if f.branch == "" {
+			http.Error(w, `{"message": "Reference does not exist"}`, http.StatusUnprocessableEntity)
+			return
+		}
+		updateRef(r)
+		w.Write([]byte(`{}`))
+	})
+	mux.HandleFunc("/repos/o/r/git/refs", func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPost, r.Method)
+		updateRef(r)
+		w.Write([]byte(`{}`))
+	})
+	mux.HandleFunc("/repos/o/r/pulls", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			require.Equal(t, "o:notes", r.URL.Query().Get("head"))
+			require.Equal(t, "master", r.URL.Query().Get("base"))
+			if f.pr == nil {
+				w.Write([]byte(`[]`))
+				return
+			}
+			w.Write([]byte(`[{"number": 7}]`))
+			return
+		}
+		f.pr = &github.NewPullRequest{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(f.pr))
+		w.Write([]byte(`{"number": 7, "html_url": "https://github.com/o/r/pull/7"}`))
+	})
+	mux.HandleFunc("/repos/o/r/pulls/7", func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPatch, r.Method)
+		f.edited = &github.PullRequest{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(f.edited))
+		w.Write([]byte(`{"number": 7, "html_url": "https://github.com/o/r/pull/7"}`))
+	})
+	return mux
+}
+
+func TestPullRequestWrite(t *testing.T) {
+	repo := &fakePullRequestRepo{t: t}
+	server := httptest.NewServer(repo.handler())
+	defer server.Close()
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+
+	s, err := New(TypePullRequest, "o/r@notes:notes.md", client)
+	require.NoError(t, err)
+
+	// the first draft creates the branch and opens a draft pull request
+	require.NoError(t, s.Write(context.Background(), []byte("## Notes\n\n- a\n")))
+	require.Equal(t, "draft", repo.branch)
+	require.Equal(t, "## Notes\n\n- a\n", repo.content)
+	require.Equal(t, "notes.md", repo.tree[0].GetPath())
+	require.Equal(t, "Update notes.md", repo.commit.Message)
+	require.Equal(t, "tree", repo.commit.Tree)
+	require.Equal(t, []string{"base"}, repo.commit.Parents)
+	require.NotNil(t, repo.pr)
+	require.Equal(t, "notes", repo.pr.GetHead())
+	require.Equal(t, "master", repo.pr.GetBase())
+	require.True(t, repo.pr.GetDraft())
+	require.Equal(t, DraftDescription("notes.md", "", "## Notes\n\n- a\n"), repo.pr.GetBody())
+	require.Nil(t, repo.edited)
+	require.Equal(t, "https://github.com/o/r/pull/7", s.Location())
+
+	// the next draft force-updates the branch and updates the description
+	require.NoError(t, s.Write(context.Background(), []byte("## Notes\n\n- a\n- b\n")))
+	require.True(t, repo.forced)
+	require.Equal(t, "## Notes\n\n- a\n- b\n", repo.content)
+	require.NotNil(t, repo.edited)
+	require.Equal(t, "Update notes.md", repo.edited.GetTitle())
+	require.Contains(t, repo.edited.GetBody(), "Since the last draft, 1 entry added and 0 entries removed.")
+	require.Contains(t, repo.edited.GetBody(), "### Added\n\n- b\n")
+}
@@ -0,0 +1,303 @@
+// Copyright 2019 The Kubernetes Authors All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sink
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"cloud.google.com/go/storage"
+	"github.com/google/go-github/github"
+	"github.com/pkg/errors"
+	"google.golang.org/api/option"
+	htransport "google.golang.org/api/transport/http"
+)
+
+// Sink is a destination for rendered release notes.
+type Sink interface {
+	// Write stores the content, replacing whatever the sink held before.
+	Write(ctx context.Context, content []byte) error
+
+	// Location returns a human readable description of where the content
+	// has been written to.
+	Location() string
+}
+
+// Type is the kind of a Sink as selected on the command line.
+type Type string
+
+const (
+	TypeFile        Type = "file"
+	TypeGitHub      Type = "github"
+	TypePullRequest Type = "pr"
+	TypeGist        Type = "gist"
+	TypeGCS         Type = "gcs"
+)
+
+// New creates a Sink of the given type. The format of the target depends on
+// the type:
+//
+//	file:   a path on the local filesystem
+//	github: org/repo@branch:path/to/file.md
+//	pr:     org/repo@branch:path/to/file.md
+//	gist:   the file name within a new secret gist
+//	gcs:    gs://bucket/path/to/object
+//
+// The GitHub client is only used by the github, pr and gist sinks.
+func New(t Type, target string, client *github.Client) (Sink, error) {
+	switch t {
+	case TypeFile:
+		if target == "" {
+			return nil, errors.New("the file sink requires a path")
+		}
+		return &File{Path: target}, nil
+	case TypeGitHub:
+		return ParseGitHubTarget(client, target)
+	case TypePullRequest:
+		return ParsePullRequestTarget(client, target)
+	case TypeGist:
+		if target == "" {
+			target = "release-notes.md"
+		}
+		return &Gist{Client: client, Filename: target}, nil
+	case TypeGCS:
+		return ParseGCSTarget(target)
+	}
+	return nil, fmt.Errorf("%q is an unsupported output type", t)
+}
+
+// File writes the content to a file on the local filesystem.
+type File struct {
+	Path string
+}
+
+// Write writes the content to the file, truncating it if it already exists.
+func (f *File) Write(ctx context.Context, content []byte) error {
+	return ioutil.WriteFile(f.Path, content, 0644)
+}
+
+// Location returns the path of the file.
+func (f *File) Location() string {
+	return f.Path
+}
+
+// GitHub commits the content as a file to a branch of a GitHub repository.
+// The branch is created from the default branch of the repository if it does
+// not yet exist.
+type GitHub struct {
+	Client *github.Client
+	Org    string
+	Repo   string
+	Branch string
+	Path   string
+
+	// Message is the commit message. It defaults to "Update <path>".
+	Message string
+}
+
+// ParseGitHubTarget creates a GitHub sink from a target in the form
+// org/repo@branch:path/to/file.md.
+func ParseGitHubTarget(client *github.Client, target string) (*GitHub, error) {
+	invalid := fmt.Errorf("invalid GitHub target %q, expected org/repo@branch:path", target)
+
+	ref := strings.SplitN(target, ":", 2)
+	if len(ref) != 2 || ref[1] == "" {
+		return nil, invalid
+	}
+	repoBranch := strings.SplitN(ref[0], "@", 2)
+	if len(repoBranch) != 2 || repoBranch[1] == "" {
+		return nil, invalid
+	}
+	orgRepo := strings.Split(repoBranch[0], "/")
+	if len(orgRepo) != 2 || orgRepo[0] == "" || orgRepo[1] == "" {
+		return nil, invalid
+	}
+
+	return &GitHub{
+		Client: client,
+		Org:    orgRepo[0],
+		Repo:   orgRepo[1],
+		Branch: repoBranch[1],
+		Path:   ref[1],
+	}, nil
+}
+
+// Write commits the content to the branch, creating the branch if needed.
+func (g *GitHub) Write(ctx context.Context, content []byte) error {
+	if err := g.ensureBranch(ctx); err != nil {
+		return err
+	}
+
+	message := g.Message
+	if message == "" {
+		message = "Update " + g.Path
+	}
+	opts := &github.RepositoryContentFileOptions{
+		Message: &message,
+		Content: content,
+		Branch:  &g.Branch,
+	}
+
+	existing, _, resp, err := g.Client.Repositories.GetContents(
+		ctx, g.Org, g.Repo, g.Path,
+		&github.RepositoryContentGetOptions{Ref: g.Branch},
+	)
+	switch {
+	case err == nil:
+		opts.SHA = existing.SHA
+		_, _, err = g.Client.Repositories.UpdateFile(ctx, g.Org, g.Repo, g.Path, opts)
+	case isNotFound(resp):
+		_, _, err = g.Client.Repositories.CreateFile(ctx, g.Org, g.Repo, g.Path, opts)
+	}
+	return errors.Wrapf(err, "error committing %s", g.Location())
+}
+
+// ensureBranch creates the branch from the head of the default branch of the
+// repository if it does not exist yet
+func (g *GitHub) ensureBranch(ctx context.Context) error {
+	_, resp, err := g.Client.Git.GetRef(ctx, g.Org, g.Repo, "heads/"+g.Branch)
+	if err == nil {
+		return nil
+	}
+	if !isNotFound(resp) {
+		return errors.Wrapf(err, "error getting branch %s", g.Branch)
+	}
+
+	repo, _, err := g.Client.Repositories.Get(ctx, g.Org, g.Repo)
+	if err != nil {
+		return errors.Wrapf(err, "error getting repository %s/%s", g.Org, g.Repo)
+	}
+	base, _, err := g.Client.Git.GetRef(ctx, g.Org, g.Repo, "heads/"+repo.GetDefaultBranch())
+	if err != nil {
+		return errors.Wrapf(err, "error getting default branch %s", repo.GetDefaultBranch())
+	}
+
+	_, _, err = g.Client.Git.CreateRef(ctx, g.Org, g.Repo, &github.Reference{
+		Ref:    github.String("refs/heads/" + g.Branch),
+		Object: base.Object,
+	})
+	return errors.Wrapf(err, "error creating branch %s", g.Branch)
+}
+
+// Location returns the URL of the file on GitHub.
+func (g *GitHub) Location() string {
+	return fmt.Sprintf("https://github.com/%s/%s/blob/%s/%s", g.Org, g.Repo, g.Branch, g.Path)
+}
+
+// Gist writes the content to a secret gist. The first write creates the
+// gist, later writes update it.
+type Gist struct {
+	Client      *github.Client
+	Filename    string
+	Description string
+
+	// ID is the ID of the gist to update. It is set after the gist has been
+	// created.
+	ID string
+
+	url string
+}
+
+// Write creates or updates the gist.
+func (g *Gist) Write(ctx context.Context, content []byte) error {
+	gist := &github.Gist{
+		Files: map[github.GistFilename]github.GistFile{
+			github.GistFilename(g.Filename): {Content: github.String(string(content))},
+		},
+	}
+	if g.Description != "" {
+		gist.Description = &g.Description
+	}
+
+	var err error
+	if g.ID == "" {
+		gist.Public = github.Bool(false)
+		gist, _, err = g.Client.Gists.Create(ctx, gist)
+	} else {
+		gist, _, err = g.Client.Gists.Edit(ctx, g.ID, gist)
+	}
+	if err != nil {
+		return errors.Wrap(err, "error writing gist")
+	}
+
+	g.ID = gist.GetID()
+	g.url = gist.GetHTMLURL()
+	return nil
+}
+
+// Location returns the URL of the gist once it has been written.
+func (g *Gist) Location() string {
+	if g.url != "" {
+		return g.url
+	}
+	return "gist " + g.Filename
+}
+
+// GCS writes the content to an object in a Google Cloud Storage bucket using
+// the application default credentials.
+type GCS struct {
+	Bucket string
+	Object string
+
+	// Transport is the transport of the uploads below the authentication,
+	// e.g. to audit them. It defaults to http.DefaultTransport.
+	Transport http.RoundTripper
+}
+
+// ParseGCSTarget creates a GCS sink from a target in the form
+// gs://bucket/path/to/object.
+func ParseGCSTarget(target string) (*GCS, error) {
+	parts := strings.SplitN(strings.TrimPrefix(target, "gs://"), "/", 2)
+	if !strings.HasPrefix(target, "gs://") || len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return nil, fmt.Errorf("invalid GCS target %q, expected gs://bucket/object", target)
+	}
+	return &GCS{Bucket: parts[0], Object: parts[1]}, nil
+}
+
+// Write uploads the content to the object, replacing it if it exists.
+func (g *GCS) Write(ctx context.Context, content []byte) error {
+	opts := []option.ClientOption{}
+	if g.Transport != nil {
+		transport, err := htransport.NewTransport(ctx, g.Transport, option.WithScopes(storage.ScopeFullControl))
+		if err != nil {
+			return errors.Wrap(err, "error creating GCS client")
+		}
+		opts = append(opts, option.WithHTTPClient(&http.Client{Transport: transport}))
+	}
+	client, err := storage.NewClient(ctx, opts...)
+	if err != nil {
+		return errors.Wrap(err, "error creating GCS client")
+	}
+	defer client.Close()
+
+	w := client.Bucket(g.Bucket).Object(g.Object).NewWriter(ctx)
+	if _, err := w.Write(content); err != nil {
+		w.Close()
+		return errors.Wrapf(err, "error writing %s", g.Location())
+	}
+	return errors.Wrapf(w.Close(), "error writing %s", g.Location())
+}
+
+// Location returns the gs:// URL of the object.
+func (g *GCS) Location() string {
+	return fmt.Sprintf("gs://%s/%s", g.Bucket, g.Object)
+}
+
+func isNotFound(resp *github.Response) bool {
+	return resp != nil && resp.StatusCode == http.StatusNotFound
+}
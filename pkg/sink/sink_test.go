@@ -0,0 +1,116 @@
+package sink
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-github/github"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sink-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "notes.md")
+	s, err := New(TypeFile, path, nil)
+	require.NoError(t, err)
+	require.Equal(t, path, s.Location())
+
+	require.NoError(t, s.Write(context.Background(), []byte("first")))
+	require.NoError(t, s.Write(context.Background(), []byte("second")))
+
+	content, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, "second", string(content))
+}
+
+func TestNewInvalid(t *testing.T) {
+	for _, tc := range []struct {
+		t      Type
+		target string
+	}{
+		{TypeFile, ""},
+		{TypeGitHub, "kubernetes/sig-release"},
+		{TypeGitHub, "kubernetes/sig-release@notes"},
+		{TypeGitHub, "kubernetes@notes:CHANGELOG.md"},
+		{TypeGCS, "bucket/object"},
+		{TypeGCS, "gs://bucket"},
+		{Type("s3"), "bucket"},
+	} {
+		_, err := New(tc.t, tc.target, nil)
+		require.Error(t, err, tc.target)
+	}
+}
+
+func TestParseTargets(t *testing.T) {
+	gh, err := ParseGitHubTarget(nil, "kubernetes/sig-release@release-notes/v1.16:releases/release-1.16/notes.md")
+	require.NoError(t, err)
+	require.Equal(t, "kubernetes", gh.Org)
+	require.Equal(t, "sig-release", gh.Repo)
+	require.Equal(t, "release-notes/v1.16", gh.Branch)
+	require.Equal(t, "releases/release-1.16/notes.md", gh.Path)
+
+	gcs, err := ParseGCSTarget("gs://kubernetes-release/notes/v1.16.0.md")
+	require.NoError(t, err)
+	require.Equal(t, "kubernetes-release", gcs.Bucket)
+	require.Equal(t, "notes/v1.16.0.md", gcs.Object)
+	require.Equal(t, "gs://kubernetes-release/notes/v1.16.0.md", gcs.Location())
+}
+
+func TestGitHubCreatesBranchAndFile(t *testing.T) {
+	var created struct {
+		Ref string `json:"ref"`
+		SHA string `json:"sha"`
+	}
+	var committed github.RepositoryContentFileOptions
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/o/r/git/refs/heads/notes", func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	})
+	mux.HandleFunc("/repos/o/r", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"default_branch": "master"}`))
+	})
+	mux.HandleFunc("/repos/o/r/git/refs/heads/master", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"ref": "refs/heads/master", "object": {"sha": "abc", "type": "commit"}}`))
+	})
+	mux.HandleFunc("/repos/o/r/git/refs", func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPost, r.Method)
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&created))
+		w.Write([]byte(`{}`))
+	})
+	mux.HandleFunc("/repos/o/r/contents/notes.md", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			http.NotFound(w, r)
+			return
+		}
+		require.Equal(t, http.MethodPut, r.Method)
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&committed))
+		w.Write([]byte(`{}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+
+	s, err := New(TypeGitHub, "o/r@notes:notes.md", client)
+	require.NoError(t, err)
+	require.NoError(t, s.Write(context.Background(), []byte("the notes")))
+
+	require.Equal(t, "refs/heads/notes", created.Ref)
+	require.Equal(t, "abc", created.SHA)
+	require.Equal(t, "notes", committed.GetBranch())
+	require.Equal(t, "Update notes.md", committed.GetMessage())
+	require.Equal(t, "the notes", string(committed.Content))
+	require.Nil(t, committed.SHA)
+}
@@ -0,0 +1,110 @@
+// Copyright 2019 The Kubernetes Authors All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package version holds the build information embedded into the release
+// tools, and checks whether a newer release of the tools is available.
+package version
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"strings"
+
+	"github.com/blang/semver"
+	"github.com/google/go-github/github"
+	"github.com/pkg/errors"
+)
+
+// The build information, which is set at build time using
+//
+//	go install -ldflags "-X k8s.io/release/pkg/version.Version=v0.1.0 \
+//	  -X k8s.io/release/pkg/version.GitCommit=$(git rev-parse HEAD) \
+//	  -X k8s.io/release/pkg/version.BuildDate=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+var (
+	Version   = "dev"
+	GitCommit = "unknown"
+	BuildDate = "unknown"
+)
+
+// Info is the build information of a binary.
+type Info struct {
+	Version   string `json:"version"`
+	GitCommit string `json:"git_commit"`
+	BuildDate string `json:"build_date"`
+	GoVersion string `json:"go_version"`
+	Platform  string `json:"platform"`
+}
+
+// Get returns the build information of the running binary.
+func Get() Info {
+	return Info{
+		Version:   Version,
+		GitCommit: GitCommit,
+		BuildDate: BuildDate,
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+	}
+}
+
+func (i Info) String() string {
+	return fmt.Sprintf(
+		"%s (commit %s, built %s with %s for %s)",
+		i.Version, i.GitCommit, i.BuildDate, i.GoVersion, i.Platform,
+	)
+}
+
+// Check is the result of comparing the running binary against the latest
+// release.
+type Check struct {
+	// Current is the version of the running binary
+	Current string
+
+	// Latest is the tag of the latest release
+	Latest string
+
+	// URL is the URL of the latest release
+	URL string
+
+	// Outdated indicates that the latest release is newer than the running
+	// binary. Binaries built without a semantic version are never outdated,
+	// since they cannot be compared.
+	Outdated bool
+}
+
+// CheckLatest compares the version of the running binary against the latest
+// GitHub release of the given repository.
+func CheckLatest(ctx context.Context, client *github.Client, org, repo string) (*Check, error) {
+	release, _, err := client.Repositories.GetLatestRelease(ctx, org, repo)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error getting the latest release of %s/%s", org, repo)
+	}
+
+	check := &Check{
+		Current: Version,
+		Latest:  release.GetTagName(),
+		URL:     release.GetHTMLURL(),
+	}
+
+	current, err := semver.Parse(strings.TrimPrefix(Version, "v"))
+	if err != nil {
+		return check, nil
+	}
+	latest, err := semver.Parse(strings.TrimPrefix(check.Latest, "v"))
+	if err != nil {
+		return nil, errors.Wrapf(err, "latest release %s of %s/%s is not a semantic version", check.Latest, org, repo)
+	}
+	check.Outdated = latest.GT(current)
+	return check, nil
+}
@@ -0,0 +1,42 @@
+package version
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/google/go-github/github"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckLatest(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/repos/kubernetes/release/releases/latest", r.URL.Path)
+		fmt.Fprint(w, `{"tag_name": "v0.2.0", "html_url": "https://github.com/kubernetes/release/releases/tag/v0.2.0"}`)
+	}))
+	defer server.Close()
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+
+	defer func(v string) { Version = v }(Version)
+	for _, tc := range []struct {
+		version  string
+		outdated bool
+	}{
+		{"dev", false},
+		{"v0.1.3", true},
+		{"v0.2.0", false},
+		{"v0.3.0-beta.0", false},
+	} {
+		Version = tc.version
+		check, err := CheckLatest(context.Background(), client, "kubernetes", "release")
+		require.NoError(t, err)
+		require.Equal(t, tc.version, check.Current)
+		require.Equal(t, "v0.2.0", check.Latest)
+		require.Equal(t, tc.outdated, check.Outdated, tc.version)
+	}
+}